@@ -0,0 +1,175 @@
+// Package audit records every write in the append-only "audit_log" table.
+//
+// Each entry stores the hash of the previous one and its own hash covers every field,
+// so editing, inserting or deleting a row anywhere breaks the chain from that row on (see Verify).
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// GenesisHash is the "prev_hash" of the very first entry
+var GenesisHash = strings.Repeat("0", 64)
+
+// How often Record retries when another instance appended first
+const maxAttempts = 5
+
+// Entry is a single write to record
+type Entry struct {
+	ActorID    *int64 // nil for the system
+	Action     string // "<target_type>.<verb>", e.g. "employee.update"
+	TargetType string
+	TargetID   string
+
+	// State of the target around the write, nil when it didn't exist (create / delete),
+	// anything that marshals to a JSON object
+	Before interface{}
+	After  interface{}
+
+	RequestID string
+	IP        string
+}
+
+// Recorder appends entries to the audit log
+type Recorder struct {
+	queries database.Querier
+	logger  *zap.Logger
+
+	// Appends through this Recorder queue up instead of racing each other for the chain head,
+	// share one per process; other Recorders and instances are left to the retries of Record
+	mu sync.Mutex
+}
+
+func New(queries database.Querier, logger *zap.Logger) *Recorder {
+	return &Recorder{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Log records a write done while serving "r" by the authenticated user, if any.
+// The write already happened, so a failure is logged rather than returned.
+func (a *Recorder) Log(r *http.Request, action string, targetType string, targetID string, before interface{}, after interface{}) {
+	entry := Entry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		RequestID:  middleware.GetRequestID(r.Context()),
+		IP:         clientIP(r),
+	}
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		entry.ActorID = &userInfo.ID
+	}
+
+	// The client hanging up must not drop the entry of a write that already happened
+	if _, err := a.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+		a.logger.Error("couldnot write audit log",
+			zap.Error(err),
+			zap.String("action", entry.Action),
+			zap.String("target", entry.TargetType+":"+entry.TargetID),
+			zap.Any("before", entry.Before),
+			zap.Any("after", entry.After),
+			zap.String("request_id", entry.RequestID),
+		)
+	}
+}
+
+// Record appends the entry at the head of the chain
+func (a *Recorder) Record(ctx context.Context, entry Entry) (*database.AuditLog, error) {
+	diff, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		prevHash := GenesisHash
+		latest, err := a.queries.GetLatestAuditEntry(ctx)
+		if err == nil {
+			prevHash = latest.Hash
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		arg := database.CreateAuditEntryParams{
+			ActorID:    entry.ActorID,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			Diff:       diff,
+			RequestID:  entry.RequestID,
+			Ip:         entry.IP,
+			// Postgres keeps microseconds, the hash has to match what is read back
+			CreatedAt: pgtype.Timestamp{Time: time.Now().UTC().Truncate(time.Microsecond), Valid: true},
+			PrevHash:  prevHash,
+		}
+		arg.Hash = Hash(arg)
+
+		created, err := a.queries.CreateAuditEntry(ctx, arg)
+		// "prev_hash" is UNIQUE, another instance took this spot in the chain
+		if helper.IsUniqueViolation(err) && attempt < maxAttempts {
+			continue
+		}
+		return created, err
+	}
+}
+
+// hashedFields is what an entry hash covers, in a fixed order
+type hashedFields struct {
+	PrevHash   string `json:"prev_hash"`
+	ActorID    *int64 `json:"actor_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Diff       string `json:"diff"`
+	RequestID  string `json:"request_id"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Hash is the hex SHA-256 of the entry, chained through "PrevHash"
+func Hash(arg database.CreateAuditEntryParams) string {
+	fields, _ := json.Marshal(hashedFields{
+		PrevHash:   arg.PrevHash,
+		ActorID:    arg.ActorID,
+		Action:     arg.Action,
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Diff:       string(arg.Diff),
+		RequestID:  arg.RequestID,
+		IP:         arg.Ip,
+		CreatedAt:  arg.CreatedAt.Time.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP is the peer address, proxy headers are not trusted
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"server/sql/database"
+	"server/sql/memdb"
+
+	"go.uber.org/zap"
+)
+
+// tampered reads the audit log of the store through "edit", as if someone had changed the table
+type tampered struct {
+	database.Querier
+	edit func([]*database.AuditLog) []*database.AuditLog
+}
+
+func (t tampered) ListAuditEntriesAfter(ctx context.Context, arg database.ListAuditEntriesAfterParams) ([]*database.AuditLog, error) {
+	entries, err := t.Querier.ListAuditEntriesAfter(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return t.edit(entries), nil
+}
+
+// chain records "n" entries and returns the store with the hash of each
+func chain(t *testing.T, n int) (*memdb.Store, []string) {
+	t.Helper()
+	store := memdb.New()
+	recorder := New(store, zap.NewNop())
+	var hashes []string
+	for i := range n {
+		actor := int64(i + 1)
+		e, err := recorder.Record(context.Background(), Entry{
+			ActorID:    &actor,
+			Action:     "employee.update",
+			TargetType: "employee",
+			TargetID:   "7",
+			Before:     map[string]any{"job_title": "Engineer"},
+			After:      map[string]any{"job_title": "Senior Engineer"},
+			RequestID:  "req",
+			IP:         "203.0.113.7",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, e.Hash)
+	}
+	return store, hashes
+}
+
+func TestHash(t *testing.T) {
+	store, _ := chain(t, 1)
+	entries, err := store.ListAuditEntriesAfter(context.Background(), database.ListAuditEntriesAfterParams{Limit: 1})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("%d entries, %v", len(entries), err)
+	}
+	e := entries[0]
+	if e.PrevHash != GenesisHash {
+		t.Errorf("prev_hash of the first entry = %s", e.PrevHash)
+	}
+	if got := Hash(paramsOf(e)); got != e.Hash {
+		t.Fatalf("Hash of the stored entry = %s, stored %s", got, e.Hash)
+	}
+
+	// Every field is covered
+	edits := map[string]func(*database.CreateAuditEntryParams){
+		"prev_hash":   func(p *database.CreateAuditEntryParams) { p.PrevHash = e.Hash },
+		"actor_id":    func(p *database.CreateAuditEntryParams) { p.ActorID = nil },
+		"action":      func(p *database.CreateAuditEntryParams) { p.Action = "employee.delete" },
+		"target_type": func(p *database.CreateAuditEntryParams) { p.TargetType = "user" },
+		"target_id":   func(p *database.CreateAuditEntryParams) { p.TargetID = "8" },
+		"diff":        func(p *database.CreateAuditEntryParams) { p.Diff = []byte(`{}`) },
+		"request_id":  func(p *database.CreateAuditEntryParams) { p.RequestID = "other" },
+		"ip":          func(p *database.CreateAuditEntryParams) { p.Ip = "198.51.100.1" },
+		"created_at":  func(p *database.CreateAuditEntryParams) { p.CreatedAt.Time = p.CreatedAt.Time.Add(1) },
+	}
+	for field, edit := range edits {
+		arg := paramsOf(e)
+		edit(&arg)
+		if Hash(arg) == e.Hash {
+			t.Errorf("the hash doesn't cover %s", field)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	store, hashes := chain(t, 5)
+
+	tests := []struct {
+		name   string
+		edit   func([]*database.AuditLog) []*database.AuditLog
+		anchor string
+
+		wantEntries int64
+		wantID      int64 // of the *TamperError
+		wantErr     error
+	}{
+		{
+			name:        "intact",
+			edit:        func(entries []*database.AuditLog) []*database.AuditLog { return entries },
+			anchor:      hashes[4],
+			wantEntries: 5,
+		},
+		{
+			name: "modified entry",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				entries[2].TargetID = "8"
+				return entries
+			},
+			wantEntries: 2,
+			wantID:      entries(t, store)[2].ID,
+		},
+		{
+			name: "modified entry with its hash redone",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				entries[2].TargetID = "8"
+				entries[2].Hash = Hash(paramsOf(entries[2]))
+				return entries
+			},
+			wantEntries: 3,
+			wantID:      entries(t, store)[3].ID,
+		},
+		{
+			name: "removed entry",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				return slices.Delete(entries, 1, 2)
+			},
+			wantEntries: 1,
+			wantID:      entries(t, store)[2].ID,
+		},
+		{
+			// The chain alone can't tell
+			name: "truncated tail",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				return entries[:3]
+			},
+			wantEntries: 3,
+		},
+		{
+			name: "truncated tail with an anchor",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				return entries[:3]
+			},
+			anchor:      hashes[4],
+			wantEntries: 3,
+			wantErr:     ErrAnchorMissing,
+		},
+		{
+			name: "anchor still in the chain",
+			edit: func(entries []*database.AuditLog) []*database.AuditLog {
+				return entries
+			},
+			anchor:      hashes[1],
+			wantEntries: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(ctx, tampered{Querier: store, edit: tt.edit}, tt.anchor)
+			if result.Entries != tt.wantEntries {
+				t.Errorf("%d entries verified, want %d", result.Entries, tt.wantEntries)
+			}
+
+			var tamper *TamperError
+			switch {
+			case tt.wantID != 0:
+				if !errors.As(err, &tamper) || tamper.ID != tt.wantID {
+					t.Errorf("err = %v, want a TamperError on entry %d", err, tt.wantID)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+// entries lists the stored audit log, oldest first
+func entries(t *testing.T, store *memdb.Store) []*database.AuditLog {
+	t.Helper()
+	list, err := store.ListAuditEntriesAfter(context.Background(), database.ListAuditEntriesAfterParams{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"server/sql/database"
+)
+
+// How many entries Verify reads at once
+const verifyBatchSize = 1000
+
+// TamperError points at the first entry that doesn't fit the chain
+type TamperError struct {
+	ID     int64
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("audit log entry %d: %s", e.ID, e.Reason)
+}
+
+// Verification is the outcome of walking the whole chain
+type Verification struct {
+	Entries int64
+	// Hash of the last entry, keep a copy elsewhere and pass it to the next Verify
+	HeadHash string
+}
+
+// ErrAnchorMissing means the head hash of an earlier run is gone, entries were removed from the end
+var ErrAnchorMissing = errors.New("audit log: the anchored entry is no longer in the chain")
+
+// Verify recomputes every hash from the first entry on, a *TamperError names the first broken entry.
+// "anchor" is the HeadHash of an earlier run, if set it has to still be in the chain;
+// that catches a truncated tail, which the chain alone can't.
+func Verify(ctx context.Context, queries database.Querier, anchor string) (Verification, error) {
+	result := Verification{HeadHash: GenesisHash}
+	anchored := anchor == "" || anchor == GenesisHash
+	var lastID int64
+
+	for {
+		entries, err := queries.ListAuditEntriesAfter(ctx, database.ListAuditEntriesAfterParams{
+			ID:    lastID,
+			Limit: verifyBatchSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, e := range entries {
+			if e.PrevHash != result.HeadHash {
+				return result, &TamperError{ID: e.ID, Reason: "prev_hash doesn't match the previous entry, an entry was removed or inserted"}
+			}
+			if Hash(paramsOf(e)) != e.Hash {
+				return result, &TamperError{ID: e.ID, Reason: "hash doesn't match the content, the entry was modified"}
+			}
+			result.Entries++
+			result.HeadHash = e.Hash
+			lastID = e.ID
+			anchored = anchored || e.Hash == anchor
+		}
+
+		if len(entries) < verifyBatchSize {
+			if !anchored {
+				return result, ErrAnchorMissing
+			}
+			return result, nil
+		}
+	}
+}
+
+func paramsOf(e *database.AuditLog) database.CreateAuditEntryParams {
+	return database.CreateAuditEntryParams{
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Diff:       e.Diff,
+		RequestID:  e.RequestID,
+		Ip:         e.Ip,
+		CreatedAt:  e.CreatedAt,
+		PrevHash:   e.PrevHash,
+	}
+}
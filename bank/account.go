@@ -0,0 +1,230 @@
+// Package bank validates the bank accounts employees are paid to and writes the payment
+// files banks take in: SEPA credit transfers (ISO 20022 pain.001) for accounts with an IBAN,
+// NACHA ACH files for US routing and account numbers.
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"server/sql/database"
+)
+
+// Payment schemes, "bank_accounts.scheme"
+const (
+	SEPA = "sepa"
+	ACH  = "ach"
+)
+
+// ACH account types, "bank_accounts.account_type"
+const (
+	Checking = "checking"
+	Savings  = "savings"
+)
+
+// Currency is what a scheme pays in
+func Currency(scheme string) string {
+	if scheme == ACH {
+		return "USD"
+	}
+	return "EUR"
+}
+
+// Account is a row of "bank_accounts", the fields of the other scheme are empty
+type Account struct {
+	Scheme        string `json:"scheme"`
+	HolderName    string `json:"holder_name"`
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty"`
+	RoutingNumber string `json:"routing_number,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	AccountType   string `json:"account_type,omitempty"`
+}
+
+// AccountFromDB decodes a "bank_accounts" row
+func AccountFromDB(row *database.BankAccount) Account {
+	return Account{
+		Scheme:        row.Scheme,
+		HolderName:    row.HolderName,
+		IBAN:          row.Iban,
+		BIC:           row.Bic,
+		RoutingNumber: row.RoutingNumber,
+		AccountNumber: row.AccountNumber,
+		AccountType:   row.AccountType,
+	}
+}
+
+// Params is the account as the parameters of "UpsertBankAccount"
+func (a Account) Params(userID int64) database.UpsertBankAccountParams {
+	return database.UpsertBankAccountParams{
+		UserID:        userID,
+		Scheme:        a.Scheme,
+		HolderName:    a.HolderName,
+		Iban:          a.IBAN,
+		Bic:           a.BIC,
+		RoutingNumber: a.RoutingNumber,
+		AccountNumber: a.AccountNumber,
+		AccountType:   a.AccountType,
+	}
+}
+
+// Normalize checks the account and brings it to the form it is stored in: spaces dropped,
+// IBAN and BIC upper cased. The scheme follows from the fields set, an IBAN is SEPA, a
+// routing number ACH, the account type defaults to checking.
+func (a Account) Normalize() (Account, error) {
+	a.HolderName = strings.Join(strings.Fields(a.HolderName), " ")
+	if a.HolderName == "" {
+		return Account{}, errors.New("holder_name is required")
+	}
+	if len([]rune(a.HolderName)) > 70 {
+		return Account{}, errors.New("holder_name can't be longer than 70 characters")
+	}
+
+	a.IBAN = strings.ToUpper(compact(a.IBAN))
+	a.BIC = strings.ToUpper(compact(a.BIC))
+	a.RoutingNumber = compact(a.RoutingNumber)
+	a.AccountNumber = compact(a.AccountNumber)
+	a.AccountType = strings.ToLower(strings.TrimSpace(a.AccountType))
+
+	switch {
+	case a.IBAN != "" && (a.RoutingNumber != "" || a.AccountNumber != ""):
+		return Account{}, errors.New("an account has either an iban or a routing_number and account_number")
+	case a.IBAN != "":
+		a.Scheme = SEPA
+		if err := ValidateIBAN(a.IBAN); err != nil {
+			return Account{}, err
+		}
+		if a.BIC != "" {
+			if err := ValidateBIC(a.BIC); err != nil {
+				return Account{}, err
+			}
+		}
+		if a.AccountType != "" {
+			return Account{}, errors.New("account_type is only for US accounts")
+		}
+	case a.RoutingNumber != "" || a.AccountNumber != "":
+		a.Scheme = ACH
+		if a.BIC != "" {
+			return Account{}, errors.New("bic is only for accounts with an iban")
+		}
+		if err := ValidateRoutingNumber(a.RoutingNumber); err != nil {
+			return Account{}, err
+		}
+		if !accountNumber.MatchString(a.AccountNumber) {
+			return Account{}, errors.New("account_number must be 4 to 17 digits")
+		}
+		if a.AccountType == "" {
+			a.AccountType = Checking
+		}
+		if a.AccountType != Checking && a.AccountType != Savings {
+			return Account{}, fmt.Errorf("account_type must be %q or %q", Checking, Savings)
+		}
+	default:
+		return Account{}, errors.New("iban or routing_number and account_number are required")
+	}
+	return a, nil
+}
+
+// Masked drops the holder name and hides all but the last 4 characters of the account numbers,
+// what the audit log keeps
+func (a Account) Masked() Account {
+	a.HolderName = ""
+	a.IBAN = mask(a.IBAN)
+	a.AccountNumber = mask(a.AccountNumber)
+	return a
+}
+
+func mask(s string) string {
+	if len(s) <= 4 {
+		return s
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
+
+// compact drops the spaces and dashes account numbers are often written with
+func compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var (
+	ibanFormat    = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicFormat     = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	routingNumber = regexp.MustCompile(`^[0-9]{9}$`)
+	accountNumber = regexp.MustCompile(`^[0-9]{4,17}$`)
+)
+
+// ibanLengths is the length of the IBANs of each country of the IBAN registry
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22,
+	"BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "DO": 28,
+	"EE": 20, "EG": 29, "ES": 24, "FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23,
+	"GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18,
+	"NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24,
+	"SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28, "TL": 23, "TN": 24,
+	"TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// ValidateIBAN checks the length of the IBAN for its country and its ISO 7064 mod 97 checksum,
+// the IBAN is upper case without spaces
+func ValidateIBAN(iban string) error {
+	if !ibanFormat.MatchString(iban) {
+		return errors.New("iban must be a country code, 2 check digits and up to 30 letters or digits")
+	}
+	length, ok := ibanLengths[iban[:2]]
+	if !ok {
+		return fmt.Errorf("iban: %s has no IBANs", iban[:2])
+	}
+	if len(iban) != length {
+		return fmt.Errorf("iban: a %s IBAN has %d characters, not %d", iban[:2], length, len(iban))
+	}
+
+	// The country and check digits move to the end, letters are 10 to 35
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprint(&digits, r-'A'+10)
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if n.Mod(n, big.NewInt(97)).Int64() != 1 {
+		return errors.New("iban: wrong check digits")
+	}
+	return nil
+}
+
+// ValidateBIC checks the format of a BIC (ISO 9362), 8 or 11 characters
+func ValidateBIC(bic string) error {
+	if !bicFormat.MatchString(bic) {
+		return errors.New("bic must be 8 or 11 letters or digits, a bank code, a country code and a location")
+	}
+	return nil
+}
+
+// ValidateRoutingNumber checks an ABA routing number, 9 digits weighted 3, 7, 1 summing
+// to a multiple of 10
+func ValidateRoutingNumber(routing string) error {
+	if !routingNumber.MatchString(routing) {
+		return errors.New("routing_number must be 9 digits")
+	}
+	sum := 0
+	for i, r := range routing {
+		sum += int(r-'0') * [3]int{3, 7, 1}[i%3]
+	}
+	if sum%10 != 0 {
+		return errors.New("routing_number: wrong check digit")
+	}
+	return nil
+}
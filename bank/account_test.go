@@ -0,0 +1,140 @@
+package bank
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		iban    string
+		wantErr string
+	}{
+		{iban: "DE89370400440532013000"},
+		{iban: "GB82WEST12345698765432"},
+		{iban: "FR1420041010050500013M02606"},
+		{iban: "NL91ABNA0417164300"},
+		{iban: "NO9386011117947"}, // the shortest
+		{iban: "MT84MALT011000012345MTLCAST001S"},
+		{iban: "DE89370400440532013001", wantErr: "wrong check digits"},
+		{iban: "DE88370400440532013000", wantErr: "wrong check digits"},
+		{iban: "GB82WEST12345698765423", wantErr: "wrong check digits"}, // two digits swapped
+		{iban: "DE8937040044053201300", wantErr: "a DE IBAN has 22 characters, not 21"},
+		{iban: "DE893704004405320130000", wantErr: "a DE IBAN has 22 characters, not 23"},
+		{iban: "US89370400440532013000", wantErr: "US has no IBANs"},
+		{iban: "de89370400440532013000", wantErr: "must be a country code"},
+		{iban: "DE89 3704 0044 0532 0130 00", wantErr: "must be a country code"},
+		{iban: "", wantErr: "must be a country code"},
+	}
+	for _, tt := range tests {
+		err := ValidateIBAN(tt.iban)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateIBAN(%q) = %v, want nil", tt.iban, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateIBAN(%q) = %v, want %q", tt.iban, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRoutingNumber(t *testing.T) {
+	tests := []struct {
+		routing string
+		wantErr string
+	}{
+		{routing: "021000021"}, // 3·0 + 7·2 + 1·1 + 3·0 + 7·0 + 1·0 + 3·0 + 7·2 + 1·1 = 30
+		{routing: "011000015"},
+		{routing: "122105155"},
+		{routing: "021000022", wantErr: "wrong check digit"},
+		{routing: "120000021", wantErr: "wrong check digit"}, // the same digits, weighted otherwise
+		{routing: "02100002", wantErr: "must be 9 digits"},
+		{routing: "0210000210", wantErr: "must be 9 digits"},
+		{routing: "02100002a", wantErr: "must be 9 digits"},
+		{routing: "", wantErr: "must be 9 digits"},
+	}
+	for _, tt := range tests {
+		err := ValidateRoutingNumber(tt.routing)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateRoutingNumber(%q) = %v, want nil", tt.routing, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateRoutingNumber(%q) = %v, want %q", tt.routing, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		account Account
+		want    Account
+		wantErr string
+	}{
+		{
+			name:    "iban written in groups",
+			account: Account{HolderName: "  Jane   Doe ", IBAN: "de89 3704 0044 0532 0130 00", BIC: "cobadeffxxx"},
+			want:    Account{Scheme: SEPA, HolderName: "Jane Doe", IBAN: "DE89370400440532013000", BIC: "COBADEFFXXX"},
+		},
+		{
+			name:    "ach defaults to checking",
+			account: Account{HolderName: "John Doe", RoutingNumber: "021-000-021", AccountNumber: "1234 5678"},
+			want:    Account{Scheme: ACH, HolderName: "John Doe", RoutingNumber: "021000021", AccountNumber: "12345678", AccountType: Checking},
+		},
+		{
+			name:    "both schemes",
+			account: Account{HolderName: "Jane Doe", IBAN: "DE89370400440532013000", RoutingNumber: "021000021"},
+			wantErr: "either an iban or a routing_number",
+		},
+		{
+			name:    "bic on an ach account",
+			account: Account{HolderName: "John Doe", RoutingNumber: "021000021", AccountNumber: "12345678", BIC: "COBADEFF"},
+			wantErr: "bic is only for accounts with an iban",
+		},
+		{
+			name:    "unknown account type",
+			account: Account{HolderName: "John Doe", RoutingNumber: "021000021", AccountNumber: "12345678", AccountType: "brokerage"},
+			wantErr: "account_type must be",
+		},
+		{
+			name:    "no holder",
+			account: Account{IBAN: "DE89370400440532013000"},
+			wantErr: "holder_name is required",
+		},
+		{
+			name:    "no account",
+			account: Account{HolderName: "Jane Doe"},
+			wantErr: "iban or routing_number and account_number are required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.account.Normalize()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasked(t *testing.T) {
+	got := Account{Scheme: SEPA, HolderName: "Jane Doe", IBAN: "DE89370400440532013000", BIC: "COBADEFFXXX"}.Masked()
+	want := Account{Scheme: SEPA, IBAN: "******************3000", BIC: "COBADEFFXXX"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package bank
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NACHA records are 94 characters, written in blocks of 10 records
+const (
+	nachaRecordSize = 94
+	nachaBlocking   = 10
+)
+
+// ACH transaction codes of a credit
+const (
+	achCheckingCredit = "22"
+	achSavingsCredit  = "32"
+)
+
+// achChar is what a NACHA field holds, upper case printable ASCII
+func achChar(r rune) bool {
+	return r >= ' ' && r <= '~'
+}
+
+// alpha is a left justified text field of "size" characters
+func alpha(s string, size int) string {
+	return fmt.Sprintf("%-*s", size, text(strings.ToUpper(s), size, achChar))
+}
+
+// numeric is a right justified, zero filled number field of "size" digits
+func numeric(n int64, size int) string {
+	s := strconv.FormatInt(n, 10)
+	if len(s) > size {
+		s = s[len(s)-size:]
+	}
+	return strings.Repeat("0", size-len(s)) + s
+}
+
+// cents is the amount in cents, the unit of every NACHA amount
+func cents(p Payment) int64 {
+	n, _ := strconv.ParseInt(strings.Replace(p.Amount.Fixed(Currency(ACH)), ".", "", 1), 10, 64)
+	return n
+}
+
+// WriteNACHA writes the batch as a NACHA file of one PPD batch of credits (service class
+// 220) settling on the pay date, every account of the batch must be ACH and every amount in USD
+func WriteNACHA(w io.Writer, o Originator, b Batch) error {
+	odfi := o.RoutingNumber[:8]
+	companyName := alpha(o.Name, 16)
+	companyID := alpha(o.CompanyID, 10)
+	payDate := b.PayDate.Format("060102")
+
+	var records []string
+	records = append(records, "1"+
+		"01"+
+		" "+o.RoutingNumber+ // immediate destination, the company's bank
+		fmt.Sprintf("%10s", o.CompanyID)+ // immediate origin
+		b.Created.UTC().Format("060102")+
+		b.Created.UTC().Format("1504")+
+		"A"+
+		"094"+
+		"10"+
+		"1"+
+		alpha(o.BankName, 23)+
+		alpha(o.Name, 23)+
+		alpha("", 8)) // reference code
+
+	records = append(records, "5"+
+		"220"+
+		companyName+
+		alpha("", 20)+
+		companyID+
+		"PPD"+
+		alpha(b.Description, 10)+
+		payDate+ // descriptive date
+		payDate+ // effective entry date
+		"   "+ // settlement date, filled in by the ACH operator
+		"1"+
+		odfi+
+		numeric(1, 7))
+
+	var hash, credit int64
+	for i, p := range b.Payments {
+		code := achCheckingCredit
+		if p.Account.AccountType == Savings {
+			code = achSavingsCredit
+		}
+		rdfi, _ := strconv.ParseInt(p.Account.RoutingNumber[:8], 10, 64)
+		hash += rdfi
+		amount := cents(p)
+		credit += amount
+
+		records = append(records, "6"+
+			code+
+			p.Account.RoutingNumber+ // RDFI and its check digit
+			alpha(p.Account.AccountNumber, 17)+
+			numeric(amount, 10)+
+			alpha(p.ID, 15)+
+			alpha(p.Account.HolderName, 22)+
+			"  "+
+			"0"+
+			odfi+numeric(int64(i+1), 7)) // trace number
+	}
+
+	entries := int64(len(b.Payments))
+	records = append(records, "8"+
+		"220"+
+		numeric(entries, 6)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credit, 12)+
+		companyID+
+		alpha("", 19)+
+		alpha("", 6)+
+		odfi+
+		numeric(1, 7))
+
+	blocks := (int64(len(records)) + 1 + nachaBlocking - 1) / nachaBlocking
+	records = append(records, "9"+
+		numeric(1, 6)+
+		numeric(blocks, 6)+
+		numeric(entries, 8)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credit, 12)+
+		alpha("", 39))
+
+	// The last block is filled up with records of 9s
+	for len(records)%nachaBlocking != 0 {
+		records = append(records, strings.Repeat("9", nachaRecordSize))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, record := range records {
+		if len(record) != nachaRecordSize {
+			return fmt.Errorf("nacha: a %c record has %d characters", record[0], len(record))
+		}
+		bw.WriteString(record + "\n")
+	}
+	return bw.Flush()
+}
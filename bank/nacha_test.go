@@ -0,0 +1,122 @@
+package bank
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func nachaOriginator() Originator {
+	return Originator{
+		Name:          "Acme Payroll Inc",
+		RoutingNumber: "021000021",
+		BankName:      "JPMorgan Chase",
+		CompanyID:     "1123456789",
+	}
+}
+
+func TestWriteNACHA(t *testing.T) {
+	batch := testBatch(
+		Payment{
+			ID:      "PAY-1",
+			Account: Account{Scheme: ACH, HolderName: "José Müller", RoutingNumber: "011000015", AccountNumber: "123456789", AccountType: Checking},
+			Amount:  amount(t, "2500.005"), // half up to 2500.01
+		},
+		Payment{
+			ID:      "PAY-2",
+			Account: Account{Scheme: ACH, HolderName: "Jane Doe", RoutingNumber: "122105155", AccountNumber: "9876543210", AccountType: Savings},
+			Amount:  amount(t, "1234.5"),
+		},
+	)
+
+	var buf bytes.Buffer
+	if err := WriteNACHA(&buf, nachaOriginator(), batch); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "payroll.ach", buf.Bytes())
+
+	records := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, record := range records {
+		if len(record) != nachaRecordSize {
+			t.Errorf("record %d has %d characters: %q", i+1, len(record), record)
+		}
+	}
+	// header, batch header, 2 entries, batch control, file control and 4 records of 9s
+	if len(records) != 10 {
+		t.Fatalf("%d records, want one block of 10", len(records))
+	}
+	for _, record := range records[6:] {
+		if record != strings.Repeat("9", nachaRecordSize) {
+			t.Errorf("filler %q", record)
+		}
+	}
+
+	entries := records[2:4]
+	if code := entries[0][1:3]; code != achCheckingCredit {
+		t.Errorf("checking entry has transaction code %s", code)
+	}
+	if code := entries[1][1:3]; code != achSavingsCredit {
+		t.Errorf("savings entry has transaction code %s", code)
+	}
+	if amount := entries[0][29:39]; amount != "0000250001" {
+		t.Errorf("first entry amount %s, want 0000250001", amount)
+	}
+	if name := entries[0][54:76]; name != "JOSE MUELLER          " {
+		t.Errorf("first entry name %q", name)
+	}
+	if trace := entries[1][79:94]; trace != "021000020000002" {
+		t.Errorf("second entry trace number %s", trace)
+	}
+
+	// The entry hash is the sum of the 8 digit RDFI routing numbers: 01100001 + 12210515
+	const hash, credit = "0013310516", "000000373451"
+	batchControl, fileControl := records[4], records[5]
+	if got := batchControl[4:10]; got != "000002" {
+		t.Errorf("batch control entry count %s", got)
+	}
+	if got := batchControl[10:20]; got != hash {
+		t.Errorf("batch control entry hash %s, want %s", got, hash)
+	}
+	if got := batchControl[20:32] + batchControl[32:44]; got != "000000000000"+credit {
+		t.Errorf("batch control debit and credit totals %s", got)
+	}
+	if got := fileControl[1:7] + fileControl[7:13] + fileControl[13:21]; got != "000001"+"000001"+"00000002" {
+		t.Errorf("file control batch, block and entry counts %s", got)
+	}
+	if got := fileControl[21:31]; got != hash {
+		t.Errorf("file control entry hash %s, want %s", got, hash)
+	}
+	if got := fileControl[43:55]; got != credit {
+		t.Errorf("file control credit total %s, want %s", got, credit)
+	}
+}
+
+// The entry hash keeps the 10 rightmost digits of the sum, the file fills up a second block
+func TestWriteNACHAOverflow(t *testing.T) {
+	var payments []Payment
+	for i := 0; i < 1000; i++ {
+		payments = append(payments, Payment{
+			ID:      "PAY",
+			Account: Account{Scheme: ACH, HolderName: "Jane Doe", RoutingNumber: "122105155", AccountNumber: "9876543210"},
+			Amount:  amount(t, "1"),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteNACHA(&buf, nachaOriginator(), testBatch(payments...)); err != nil {
+		t.Fatal(err)
+	}
+	records := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	// 1000 entries and 4 other records are 101 blocks
+	if len(records) != 1010 {
+		t.Fatalf("%d records, want 1010", len(records))
+	}
+	fileControl := records[1003]
+	if got := fileControl[7:13]; got != "000101" {
+		t.Errorf("block count %s, want 000101", got)
+	}
+	// 1000 × 12210515 = 12210515000
+	if got := fileControl[21:31]; got != "2210515000" {
+		t.Errorf("entry hash %s, want 2210515000", got)
+	}
+}
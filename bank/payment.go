@@ -0,0 +1,108 @@
+package bank
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"server/money"
+)
+
+// Originator is the company paying, the account the transfers are debited from
+type Originator struct {
+	Name string
+
+	// SEPA
+	IBAN string
+	BIC  string
+
+	// ACH, the company's bank is the originating DFI
+	RoutingNumber string
+	BankName      string
+	CompanyID     string // 10 characters, usually "1" and the EIN
+}
+
+// Check tells what the originator lacks to send payments of the scheme
+func (o Originator) Check(scheme string) error {
+	if strings.TrimSpace(o.Name) == "" {
+		return errors.New("the company name is not configured")
+	}
+	switch scheme {
+	case SEPA:
+		if o.IBAN == "" {
+			return errors.New("the company IBAN is not configured")
+		}
+		if err := ValidateIBAN(o.IBAN); err != nil {
+			return err
+		}
+		if o.BIC != "" {
+			return ValidateBIC(o.BIC)
+		}
+	case ACH:
+		if o.RoutingNumber == "" || o.CompanyID == "" {
+			return errors.New("the company routing number and ACH company id are not configured")
+		}
+		if err := ValidateRoutingNumber(o.RoutingNumber); err != nil {
+			return err
+		}
+		if len(o.CompanyID) > 10 {
+			return errors.New("the ACH company id can't be longer than 10 characters")
+		}
+	}
+	return nil
+}
+
+// Payment is one credit transfer to an employee
+type Payment struct {
+	ID        string // end to end id, unique in the batch
+	Account   Account
+	Amount    money.Amount // in the currency of the scheme
+	Reference string       // what the employee reads on their statement
+}
+
+// Batch is the payments of one payment file, all due on the same day
+type Batch struct {
+	ID          string // message id, unique per file
+	Description string
+	Created     time.Time
+	PayDate     time.Time
+	Payments    []Payment
+}
+
+// total is the sum of the payments, each rounded to the cent
+func (b Batch) total(currency string) money.Amount {
+	var total money.Amount
+	for _, p := range b.Payments {
+		total = total.Add(p.Amount.Round(currency))
+	}
+	return total
+}
+
+// latin folds the accented letters payment files can't carry to their plain forms
+var latin = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "AE", "Ö", "OE", "Ü", "UE", "ß", "ss",
+	"à", "a", "á", "a", "â", "a", "ã", "a", "å", "a", "æ", "ae", "ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ý", "y", "ÿ", "y",
+	"À", "A", "Á", "A", "Â", "A", "Ã", "A", "Å", "A", "Æ", "AE", "Ç", "C",
+	"È", "E", "É", "E", "Ê", "E", "Ë", "E", "Ì", "I", "Í", "I", "Î", "I", "Ï", "I",
+	"Ñ", "N", "Ò", "O", "Ó", "O", "Ô", "O", "Õ", "O", "Ø", "O", "Œ", "OE",
+	"Ù", "U", "Ú", "U", "Û", "U", "Ý", "Y",
+)
+
+// text keeps the characters "allowed" accepts, the others become spaces, and cuts the
+// result to "size" characters
+func text(s string, size int, allowed func(rune) bool) string {
+	s = strings.Map(func(r rune) rune {
+		if allowed(r) {
+			return r
+		}
+		return ' '
+	}, latin.Replace(s))
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > size {
+		s = strings.TrimSpace(s[:size])
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package bank
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"server/money"
+)
+
+// go test ./bank -update rewrites the golden files from what the writers produce now,
+// review the diff before committing it
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares "got" to testdata/<name>
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from the golden file, run with -update to see how\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func amount(t *testing.T, s string) money.Amount {
+	t.Helper()
+	a, err := money.ParseAmount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func testBatch(payments ...Payment) Batch {
+	return Batch{
+		ID:          "RUN-7-20260131",
+		Description: "SALARY",
+		Created:     time.Date(2026, 1, 28, 9, 30, 15, 0, time.UTC),
+		PayDate:     time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		Payments:    payments,
+	}
+}
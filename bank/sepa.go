@@ -0,0 +1,155 @@
+package bank
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// painNamespace is the version of pain.001 every SEPA bank takes
+const painNamespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+type painDocument struct {
+	XMLName  xml.Name     `xml:"Document"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Initiate painInitiate `xml:"CstmrCdtTrfInitn"`
+}
+
+type painInitiate struct {
+	GroupHeader painGroupHeader `xml:"GrpHdr"`
+	PaymentInfo painPaymentInfo `xml:"PmtInf"`
+}
+
+type painGroupHeader struct {
+	MessageID       string    `xml:"MsgId"`
+	Created         string    `xml:"CreDtTm"`
+	Transactions    int       `xml:"NbOfTxs"`
+	ControlSum      string    `xml:"CtrlSum"`
+	InitiatingParty painParty `xml:"InitgPty"`
+}
+
+type painPaymentInfo struct {
+	ID              string         `xml:"PmtInfId"`
+	Method          string         `xml:"PmtMtd"`
+	BatchBooking    bool           `xml:"BtchBookg"`
+	Transactions    int            `xml:"NbOfTxs"`
+	ControlSum      string         `xml:"CtrlSum"`
+	ServiceLevel    string         `xml:"PmtTpInf>SvcLvl>Cd"`
+	CategoryPurpose string         `xml:"PmtTpInf>CtgyPurp>Cd"`
+	ExecutionDate   string         `xml:"ReqdExctnDt"`
+	Debtor          painParty      `xml:"Dbtr"`
+	DebtorAccount   string         `xml:"DbtrAcct>Id>IBAN"`
+	DebtorAgent     painAgent      `xml:"DbtrAgt"`
+	ChargeBearer    string         `xml:"ChrgBr"`
+	Transfers       []painTransfer `xml:"CdtTrfTxInf"`
+}
+
+type painTransfer struct {
+	EndToEndID      string     `xml:"PmtId>EndToEndId"`
+	Amount          painAmount `xml:"Amt>InstdAmt"`
+	CreditorAgent   *painAgent `xml:"CdtrAgt,omitempty"`
+	Creditor        painParty  `xml:"Cdtr"`
+	CreditorAccount string     `xml:"CdtrAcct>Id>IBAN"`
+	Purpose         string     `xml:"Purp>Cd"`
+	Remittance      string     `xml:"RmtInf>Ustrd"`
+}
+
+type painParty struct {
+	Name string `xml:"Nm"`
+}
+
+// painAgent is a bank, "NOTPROVIDED" when its BIC isn't known
+type painAgent struct {
+	Institution struct {
+		BIC   string     `xml:"BIC,omitempty"`
+		Other *painOther `xml:"Othr,omitempty"`
+	} `xml:"FinInstnId"`
+}
+
+type painOther struct {
+	ID string `xml:"Id"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// sepaChar is the character set of the SEPA rulebooks
+func sepaChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("/-?:().,'+ ", r)
+}
+
+// WriteSEPA writes the batch as a SEPA credit transfer initiation (pain.001.001.03) with
+// the salary category purpose, every account of the batch must be SEPA and every amount in EUR
+func WriteSEPA(w io.Writer, o Originator, b Batch) error {
+	currency := Currency(SEPA)
+	total := b.total(currency).Fixed(currency)
+	name := text(o.Name, 70, sepaChar)
+
+	info := painPaymentInfo{
+		ID:              text(b.ID, 35, sepaChar),
+		Method:          "TRF",
+		BatchBooking:    true,
+		Transactions:    len(b.Payments),
+		ControlSum:      total,
+		ServiceLevel:    "SEPA",
+		CategoryPurpose: "SALA",
+		ExecutionDate:   b.PayDate.Format(time.DateOnly),
+		Debtor:          painParty{Name: name},
+		DebtorAccount:   o.IBAN,
+		DebtorAgent:     agent(o.BIC),
+		ChargeBearer:    "SLEV",
+	}
+	for _, p := range b.Payments {
+		t := painTransfer{
+			EndToEndID:      text(p.ID, 35, sepaChar),
+			Amount:          painAmount{Currency: currency, Value: p.Amount.Fixed(currency)},
+			Creditor:        painParty{Name: text(p.Account.HolderName, 70, sepaChar)},
+			CreditorAccount: p.Account.IBAN,
+			Purpose:         "SALA",
+			Remittance:      text(p.Reference, 140, sepaChar),
+		}
+		if p.Account.BIC != "" {
+			a := agent(p.Account.BIC)
+			t.CreditorAgent = &a
+		}
+		info.Transfers = append(info.Transfers, t)
+	}
+
+	doc := painDocument{
+		Xmlns: painNamespace,
+		Initiate: painInitiate{
+			GroupHeader: painGroupHeader{
+				MessageID:       text(b.ID, 35, sepaChar),
+				Created:         b.Created.UTC().Format("2006-01-02T15:04:05"),
+				Transactions:    len(b.Payments),
+				ControlSum:      total,
+				InitiatingParty: painParty{Name: name},
+			},
+			PaymentInfo: info,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func agent(bic string) painAgent {
+	var a painAgent
+	if bic == "" {
+		a.Institution.Other = &painOther{ID: "NOTPROVIDED"}
+	} else {
+		a.Institution.BIC = bic
+	}
+	return a
+}
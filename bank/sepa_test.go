@@ -0,0 +1,82 @@
+package bank
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteSEPA(t *testing.T) {
+	originator := Originator{
+		Name: "Acme Payroll GmbH",
+		IBAN: "DE89370400440532013000",
+		BIC:  "COBADEFFXXX",
+	}
+	batch := testBatch(
+		Payment{
+			ID:        "PAY-1",
+			Account:   Account{Scheme: SEPA, HolderName: "Zoë Øster & Söhne", IBAN: "NL91ABNA0417164300", BIC: "ABNANL2A"},
+			Amount:    amount(t, "3000.125"), // half up to 3000.13
+			Reference: "Salary January 2026",
+		},
+		Payment{
+			ID:        "PAY-2",
+			Account:   Account{Scheme: SEPA, HolderName: "Jean Dupont", IBAN: "FR1420041010050500013M02606"},
+			Amount:    amount(t, "1999.99"),
+			Reference: "Salary January 2026",
+		},
+	)
+
+	var buf bytes.Buffer
+	if err := WriteSEPA(&buf, originator, batch); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "payroll.pain.001.xml", buf.Bytes())
+
+	var doc painDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	header, info := doc.Initiate.GroupHeader, doc.Initiate.PaymentInfo
+	if doc.Xmlns != painNamespace {
+		t.Errorf("namespace %s", doc.Xmlns)
+	}
+	if header.Transactions != 2 || info.Transactions != 2 {
+		t.Errorf("%d and %d transactions, want 2", header.Transactions, info.Transactions)
+	}
+	if header.ControlSum != "5000.12" || info.ControlSum != "5000.12" {
+		t.Errorf("control sums %s and %s, want 5000.12", header.ControlSum, info.ControlSum)
+	}
+	if got := info.Transfers[0].Creditor.Name; got != "Zoe Oster Soehne" {
+		t.Errorf("creditor name %q, the SEPA character set has no accents and no &", got)
+	}
+	if agent := info.Transfers[1].CreditorAgent; agent != nil {
+		t.Errorf("creditor agent %+v for an account without a BIC", agent)
+	}
+}
+
+// Without a BIC the debtor agent is NOTPROVIDED, as IBAN-only transfers are written
+func TestWriteSEPAWithoutBIC(t *testing.T) {
+	originator := Originator{Name: "Acme Payroll GmbH", IBAN: "DE89370400440532013000"}
+	batch := testBatch(Payment{
+		ID:      "PAY-1",
+		Account: Account{Scheme: SEPA, HolderName: "Jean Dupont", IBAN: "FR1420041010050500013M02606"},
+		Amount:  amount(t, "10"),
+	})
+
+	var buf bytes.Buffer
+	if err := WriteSEPA(&buf, originator, batch); err != nil {
+		t.Fatal(err)
+	}
+	var doc painDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	other := doc.Initiate.PaymentInfo.DebtorAgent.Institution.Other
+	if other == nil || other.ID != "NOTPROVIDED" {
+		t.Errorf("debtor agent %+v, want NOTPROVIDED", doc.Initiate.PaymentInfo.DebtorAgent)
+	}
+	if got := doc.Initiate.PaymentInfo.Transfers[0].Amount.Value; got != "10.00" {
+		t.Errorf("amount %s, want 10.00", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Command bootstrap creates the first superadmin, once one exists it refuses to run
+// and further admins are granted through "/v1/superadmin".
+//
+//	go run ./cmd/bootstrap -username root -email root@example.com
+//
+// The password is read from BOOTSTRAP_PASSWORD, or from stdin when unset.
+// An existing user is promoted as is, the email and password are only used to create a new one.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"server/audit"
+	"server/http/helper"
+	db "server/init"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func run() error {
+	// ".env" is optional, the variables may come from the environment
+	_ = godotenv.Load()
+
+	username := flag.String("username", helper.GetEnv("BOOTSTRAP_USERNAME", ""), "username of the superadmin")
+	email := flag.String("email", helper.GetEnv("BOOTSTRAP_EMAIL", ""), "email, when the user has to be created")
+	flag.Parse()
+
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+	if db.LoadConfig().Driver == "memory" {
+		return errors.New("DB_DRIVER=memory keeps nothing, set BOOTSTRAP_USERNAME for the server instead")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, queries, err := db.ConnectDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.DisconnectDB(pool)
+
+	user, err := helper.BootstrapSuperadmin(ctx, queries, *username, *email, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = audit.New(queries, zap.NewNop()).Record(ctx, audit.Entry{
+		Action:     "role.assign",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(int(user.ID)),
+		After:      map[string]string{"role": helper.SuperadminRole},
+		RequestID:  "bootstrap",
+	})
+	if err != nil {
+		return fmt.Errorf("superadmin created, but couldnot write audit log: %w", err)
+	}
+
+	fmt.Printf("user %q (id %d) is now superadmin\n", user.Username, user.ID)
+	return nil
+}
+
+func readPassword() (string, error) {
+	if password := os.Getenv("BOOTSTRAP_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "password (only used to create the user): ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", nil
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "bootstrap:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,115 @@
+// Command importemployees creates a user and an employee for every line of a CSV, the same
+// import as "POST /v1/admin/employees/import" without the HTTP timeouts of a big file.
+//
+//	go run ./cmd/importemployees [-dry-run] staff.csv
+//
+// The file needs a "username,email,job_title,country,salary,currency" header, a "password"
+// column is optional. Every line is checked and either every employee is created, in one
+// transaction, or none is and the errors of every line are printed, exiting 1. The generated
+// passwords are written to stdout as "username,email,password", hand them out and drop the file.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"server/audit"
+	db "server/init"
+	"server/staff"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func run() error {
+	// ".env" is optional, the variables may come from the environment
+	_ = godotenv.Load()
+
+	dryRun := flag.Bool("dry-run", false, "check and write every line, then roll back")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		return errors.New("usage: importemployees [-dry-run] <file.csv>, - reads stdin")
+	}
+	if db.LoadConfig().Driver == "memory" {
+		return errors.New("DB_DRIVER=memory keeps nothing, use the import endpoint of the server instead")
+	}
+
+	var in io.Reader = os.Stdin
+	if name := flag.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	rows, invalid, err := staff.ParseCSV(in)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, queries, err := db.ConnectDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.DisconnectDB(pool)
+
+	fmt.Fprintf(os.Stderr, "importing %d employees, hashing the passwords takes a while...\n", len(rows))
+	result, err := staff.Import(ctx, queries, rows, invalid, staff.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		return fmt.Errorf("%d errors, nothing imported", len(result.Errors))
+	}
+	if result.DryRun {
+		fmt.Fprintf(os.Stderr, "dry run OK: %d employees would be imported\n", len(result.Imported))
+		return nil
+	}
+
+	ids := make([]int32, 0, len(result.Imported))
+	for _, imported := range result.Imported {
+		ids = append(ids, imported.EmployeeID)
+	}
+	_, err = audit.New(queries, zap.NewNop()).Record(ctx, audit.Entry{
+		Action:     "employee.import",
+		TargetType: "employee",
+		After:      map[string]any{"rows": result.Rows, "employee_ids": ids},
+		RequestID:  "importemployees",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "employees imported, but couldnot write audit log:", err)
+	}
+
+	out := csv.NewWriter(os.Stdout)
+	out.Write([]string{"username", "email", "password"})
+	for _, imported := range result.Imported {
+		if imported.Password != "" {
+			out.Write([]string{imported.Username, imported.Email, imported.Password})
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d employees\n", len(result.Imported))
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "importemployees:", err)
+		os.Exit(1)
+	}
+}
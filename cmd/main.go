@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"server/audit"
+	"server/config"
 	"server/http/helper"
 	"server/http/router"
 	db "server/init"
+	"server/jobs"
+	"server/money"
+	"server/sql/database"
+	"server/sql/memdb"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 
 	"go.uber.org/zap"
@@ -37,13 +46,44 @@ func CreateHttpServer(port string, router http.Handler) *http.Server {
 	return server
 }
 
+// openDatabase picks the Querier based on "DB_DRIVER", "memory" runs without Postgres
+func openDatabase(log *zap.Logger) (*pgxpool.Pool, database.Querier, error) {
+	if db.LoadConfig().Driver == "memory" {
+		log.Warn("DB_DRIVER=memory, all data lives in process memory and is lost on shutdown")
+		store := memdb.New()
+
+		// Nothing survives a restart, so the first superadmin comes from the env every time
+		if username := helper.GetEnv("BOOTSTRAP_USERNAME", ""); username != "" {
+			user, err := helper.BootstrapSuperadmin(context.Background(), store, username,
+				helper.GetEnv("BOOTSTRAP_EMAIL", ""), helper.GetEnv("BOOTSTRAP_PASSWORD", ""))
+			if err != nil {
+				return nil, nil, err
+			}
+			_, err = audit.New(store, log).Record(context.Background(), audit.Entry{
+				Action:     "role.assign",
+				TargetType: "user",
+				TargetID:   strconv.Itoa(int(user.ID)),
+				After:      map[string]string{"role": helper.SuperadminRole},
+				RequestID:  "bootstrap",
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			log.Info("bootstrapped superadmin", zap.String("username", username))
+		}
+		return nil, store, nil
+	}
+
+	return db.ConnectDB(context.Background())
+}
+
 func start() int {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatal("ERROR loading env :- ", zap.Error(err))
 	}
-	logEnv := helper.GetEnv("LOG_ENV", "development")
-	log, err := createLogger(logEnv)
+	cfg := config.Load()
+	log, err := createLogger(cfg.LogEnv)
 	if err != nil {
 		log.Info("Error setting up the logger :- ", zap.Error(err))
 		return 1
@@ -54,17 +94,52 @@ func start() int {
 		_ = log.Sync()
 	}()
 
-	err = db.ConnectDB()
+	if err := money.SetRoundingModes(cfg.RoundingModes); err != nil {
+		log.Sugar().Panicf("Invalid ROUNDING_MODES: %v", err)
+	}
+
+	pool, queries, err := openDatabase(log)
 	if err != nil {
 		log.Sugar().Panicf("Failed to connect to database: %v", err)
 	}
-	defer db.DisconnectDB()
+	defer db.DisconnectDB(pool)
 
-	// extract PORT
-	port := helper.GetEnv("PORT", "8080")
+	// JWT signing keys, a first key is created if there is none yet
+	keys, err := helper.NewKeyRing(queries, cfg.JWTSigningAlg, cfg.JWTKeyRotation, cfg.JWTKeyGrace)
+	if err != nil {
+		log.Sugar().Panicf("Invalid JWT key config: %v", err)
+	}
+	if cfg.JWTKeyEncryptionKey != "" {
+		if err := keys.SetEncryptionKey(cfg.JWTKeyEncryptionKey); err != nil {
+			log.Sugar().Panicf("Invalid JWT_KEY_ENCRYPTION_KEY: %v", err)
+		}
+	} else {
+		log.Warn("JWT_KEY_ENCRYPTION_KEY is not set, the signing keys are stored unencrypted")
+	}
+	if err := keys.Rotate(context.Background()); err != nil {
+		log.Sugar().Panicf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// One recorder for the routes and the jobs, so their appends queue up on the same lock
+	auditLog := audit.New(queries, log)
+
+	// Background jobs live until the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobs.Every(jobsCtx, log, "purge-revocations", cfg.RevocationPurgeInterval, jobs.PurgeRevocations(queries, log))
+	go jobs.Every(jobsCtx, log, "rotate-signing-keys", cfg.JWTKeyCheckInterval, keys.Rotate)
+	go jobs.Every(jobsCtx, log, "apply-scheduled-salaries", cfg.SalaryApplyInterval,
+		jobs.ApplyScheduledSalaries(queries, auditLog, log))
+	if cfg.RetentionDays > 0 {
+		if cfg.RetentionMode != jobs.RetentionPurge && cfg.RetentionMode != jobs.RetentionAnonymize {
+			log.Sugar().Panicf("Invalid RETENTION_MODE %q, purge or anonymize", cfg.RetentionMode)
+		}
+		go jobs.Every(jobsCtx, log, "enforce-retention", cfg.RetentionInterval,
+			jobs.EnforceRetention(queries, auditLog, log, cfg.RetentionDays, cfg.RetentionMode))
+	}
 
 	// Create Http.Server
-	srv := CreateHttpServer(port, router.InitRouter(log))
+	srv := CreateHttpServer(cfg.Port, router.InitRouter(log, queries, auditLog, keys, cfg))
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
@@ -79,11 +154,12 @@ func start() int {
 
 	// Run graceful shutdown in a separate goroutine
 	go func() {
-		helper.GracefulShutdown(srv, done, db.DB)
+		helper.GracefulShutdown(srv, done, pool)
 	}()
 
 	// Wait for the graceful shutdown to complete
 	<-done
+	stopJobs()
 	log.Info("Graceful shutdown complete.")
 	return 0
 }
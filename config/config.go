@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"server/http/helper"
+)
+
+// Config holds the server settings shared by the router, handlers and middlewares
+type Config struct {
+	Port   string
+	LogEnv string
+
+	// Cookie used to carry the JWT
+	CookieDomain string
+	CookieSecure bool
+
+	// Lifetime of the short lived access JWT and of the refresh token that renews it
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Asymmetric JWT signing, keys rotate every JWTKeyRotation and keep verifying for JWTKeyGrace
+	JWTSigningAlg       string
+	JWTKeyRotation      time.Duration
+	JWTKeyGrace         time.Duration
+	JWTKeyCheckInterval time.Duration
+	// Encrypts the private keys in "signing_keys", 32 bytes in base64. Empty stores them as plain PEM.
+	JWTKeyEncryptionKey string
+
+	// How often expired revocation list entries are purged
+	RevocationPurgeInterval time.Duration
+
+	// How often salary changes scheduled for today are made current
+	SalaryApplyInterval time.Duration
+
+	// Deleted employees and users stay restorable for RetentionDays (0 keeps them forever), then
+	// the retention job, run every RetentionInterval, "purge"s or "anonymize"s them
+	RetentionDays     int
+	RetentionMode     string
+	RetentionInterval time.Duration
+
+	// ISO 4217 currency salary analytics are converted to when the request doesn't name one
+	ReportingCurrency string
+
+	// Rounding of the currencies not rounded half up, "CHF=half_even,JPY=down"
+	RoundingModes string
+
+	// Header of the PDF payslips, the address lines are separated by ";"
+	CompanyName    string
+	CompanyAddress string
+
+	// The account salaries are paid from, an IBAN (and BIC) for SEPA files, a US routing
+	// number, bank name and ACH company id for NACHA files
+	CompanyIBAN          string
+	CompanyBIC           string
+	CompanyRoutingNumber string
+	CompanyBankName      string
+	CompanyACHID         string
+}
+
+// Load reads the server configuration from environment variables
+func Load() *Config {
+	cfg := &Config{
+		Port:   helper.GetEnv("PORT", "8080"),
+		LogEnv: helper.GetEnv("LOG_ENV", "development"),
+
+		CookieDomain: helper.GetEnv("COOKIE_DOMAIN", "localhost"),
+		CookieSecure: helper.GetEnvBool("COOKIE_SECURE", false),
+
+		AccessTokenTTL:  helper.GetEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: helper.GetEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+
+		JWTSigningAlg:       helper.GetEnv("JWT_SIGNING_ALG", helper.AlgEdDSA),
+		JWTKeyRotation:      helper.GetEnvDuration("JWT_KEY_ROTATION", 30*24*time.Hour),
+		JWTKeyGrace:         helper.GetEnvDuration("JWT_KEY_GRACE", 24*time.Hour),
+		JWTKeyCheckInterval: helper.GetEnvDuration("JWT_KEY_CHECK_INTERVAL", 5*time.Minute),
+		JWTKeyEncryptionKey: helper.GetEnv("JWT_KEY_ENCRYPTION_KEY", ""),
+
+		RevocationPurgeInterval: helper.GetEnvDuration("REVOCATION_PURGE_INTERVAL", time.Hour),
+
+		SalaryApplyInterval: helper.GetEnvDuration("SALARY_APPLY_INTERVAL", time.Hour),
+
+		RetentionDays:     helper.GetEnvInt("RETENTION_DAYS", 90),
+		RetentionMode:     strings.ToLower(helper.GetEnv("RETENTION_MODE", "anonymize")),
+		RetentionInterval: helper.GetEnvDuration("RETENTION_INTERVAL", 24*time.Hour),
+
+		ReportingCurrency: strings.ToUpper(helper.GetEnv("REPORTING_CURRENCY", "USD")),
+		RoundingModes:     helper.GetEnv("ROUNDING_MODES", ""),
+
+		CompanyName:    helper.GetEnv("COMPANY_NAME", "employee-crud"),
+		CompanyAddress: helper.GetEnv("COMPANY_ADDRESS", ""),
+
+		CompanyIBAN:          helper.GetEnv("COMPANY_IBAN", ""),
+		CompanyBIC:           helper.GetEnv("COMPANY_BIC", ""),
+		CompanyRoutingNumber: helper.GetEnv("COMPANY_ROUTING_NUMBER", ""),
+		CompanyBankName:      helper.GetEnv("COMPANY_BANK_NAME", ""),
+		CompanyACHID:         helper.GetEnv("COMPANY_ACH_ID", ""),
+	}
+
+	// A retired key has to outlive every token it signed
+	if cfg.JWTKeyGrace < cfg.AccessTokenTTL {
+		cfg.JWTKeyGrace = cfg.AccessTokenTTL
+	}
+
+	return cfg
+}
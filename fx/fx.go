@@ -0,0 +1,187 @@
+// Package fx converts amounts between currencies with the rates in "fx_rates".
+//
+// A rate says what 1 unit of the base currency is worth in the quote currency on a day,
+// an amount is converted at the latest rate not after the day asked for, read either way
+// round (USD→EUR from a EUR→USD row) or through a third currency (INR→EUR via USD),
+// whichever was set the most recently.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"server/http/helper"
+	"server/money"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrNoRate means no rate, direct, inverse or through a third currency, links two currencies
+var ErrNoRate = errors.New("no exchange rate")
+
+// Sources a rate can come from, "fx_rates.source"
+const (
+	SourceManual = "manual"
+	SourceCSV    = "csv"
+	SourceECB    = "ecb"
+)
+
+// ExchangeRate is a row of "fx_rates", 1 Base is worth Rate Quote on Date
+type ExchangeRate struct {
+	ID     int64      `json:"id,omitempty"`
+	Base   string     `json:"base_currency"`
+	Quote  string     `json:"quote_currency"`
+	Rate   money.Rate `json:"rate"`
+	Date   string     `json:"rate_date"` // "YYYY-MM-DD"
+	Source string     `json:"source,omitempty"`
+}
+
+// maxRate is the first rate "DECIMAL(20,10)" can't hold
+var maxRate = money.NewRate(10_000_000_000)
+
+// Validate normalizes the currency codes and checks the rate can be stored
+func (e *ExchangeRate) Validate() error {
+	e.Base, e.Quote = money.NormalizeCurrency(e.Base), money.NormalizeCurrency(e.Quote)
+	if !money.IsCurrency(e.Base) {
+		return fmt.Errorf("base_currency %q is not an ISO 4217 code", e.Base)
+	}
+	if !money.IsCurrency(e.Quote) {
+		return fmt.Errorf("quote_currency %q is not an ISO 4217 code", e.Quote)
+	}
+	if e.Base == e.Quote {
+		return errors.New("base_currency and quote_currency must differ")
+	}
+	if e.Rate.Sign() <= 0 || e.Rate.Cmp(maxRate) >= 0 {
+		return errors.New("rate must be positive")
+	}
+	if _, err := helper.ParseDate(e.Date); err != nil {
+		return errors.New("rate_date must be YYYY-MM-DD")
+	}
+	return nil
+}
+
+// Params is the upsert of a validated rate
+func (e *ExchangeRate) Params(source string) (database.UpsertFxRateParams, error) {
+	date, err := helper.ParseDate(e.Date)
+	if err != nil {
+		return database.UpsertFxRateParams{}, err
+	}
+	return database.UpsertFxRateParams{
+		BaseCurrency:  e.Base,
+		QuoteCurrency: e.Quote,
+		Rate:          e.Rate.Numeric(),
+		RateDate:      date,
+		Source:        source,
+	}, nil
+}
+
+// FromDB decodes a "fx_rates" row
+func FromDB(row *database.FxRate) ExchangeRate {
+	rate, _ := money.RateFromNumeric(row.Rate)
+	return ExchangeRate{
+		ID:     row.ID,
+		Base:   row.BaseCurrency,
+		Quote:  row.QuoteCurrency,
+		Rate:   rate,
+		Date:   row.RateDate.Time.Format(helper.DateLayout),
+		Source: row.Source,
+	}
+}
+
+type pair struct {
+	base, quote string
+}
+
+// quote is a rate with the day it was set
+type quote struct {
+	rate money.Rate
+	date time.Time
+}
+
+// Converter converts amounts at the rates in effect on one day
+type Converter struct {
+	asOf  pgtype.Date
+	rates map[pair]quote
+
+	// Every currency with a rate, in order, the ones to cross through
+	currencies []string
+}
+
+// Load fetches the rates in effect on asOf
+func Load(ctx context.Context, queries database.Querier, asOf pgtype.Date) (*Converter, error) {
+	rows, err := queries.GetFxRatesAsOf(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Converter{asOf: asOf, rates: make(map[pair]quote, len(rows))}
+	for _, row := range rows {
+		rate, err := money.RateFromNumeric(row.Rate)
+		if err != nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("fx rate %d: invalid rate", row.ID)
+		}
+		c.rates[pair{row.BaseCurrency, row.QuoteCurrency}] = quote{rate: rate, date: row.RateDate.Time}
+		for _, code := range []string{row.BaseCurrency, row.QuoteCurrency} {
+			if !slices.Contains(c.currencies, code) {
+				c.currencies = append(c.currencies, code)
+			}
+		}
+	}
+	slices.Sort(c.currencies)
+	return c, nil
+}
+
+// AsOf is the day the rates are in effect
+func (c *Converter) AsOf() pgtype.Date {
+	return c.asOf
+}
+
+// Rate is what 1 "from" is worth in "to", the most recent of the direct, inverse and cross
+// rates wins, a cross rate is as old as its older leg. On the same day a direct rate wins
+// over a cross one.
+func (c *Converter) Rate(from, to string) (money.Rate, error) {
+	if from == to {
+		return money.NewRate(1), nil
+	}
+	best, found := c.direct(from, to)
+	for _, via := range c.currencies {
+		toVia, okFrom := c.direct(from, via)
+		fromVia, okTo := c.direct(via, to)
+		if !okFrom || !okTo {
+			continue
+		}
+		cross := quote{rate: toVia.rate.Mul(fromVia.rate), date: toVia.date}
+		if fromVia.date.Before(cross.date) {
+			cross.date = fromVia.date
+		}
+		if !found || cross.date.After(best.date) {
+			best, found = cross, true
+		}
+	}
+	if !found {
+		return money.Rate{}, fmt.Errorf("%w from %s to %s on %s", ErrNoRate, from, to, c.asOf.Time.Format(helper.DateLayout))
+	}
+	return best.rate, nil
+}
+
+// Convert turns an amount of "from" into "to", rounded to the minor unit of "to"
+func (c *Converter) Convert(amount money.Amount, from, to string) (money.Amount, error) {
+	rate, err := c.Rate(from, to)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return amount.Mul(rate).Round(to), nil
+}
+
+// direct is the more recent of the "from"→"to" rate and the inverse of the "to"→"from" one
+func (c *Converter) direct(from, to string) (quote, bool) {
+	q, ok := c.rates[pair{from, to}]
+	if inverse, okInverse := c.rates[pair{to, from}]; okInverse && (!ok || inverse.date.After(q.date)) {
+		return quote{rate: inverse.rate.Inverse(), date: inverse.date}, true
+	}
+	return q, ok
+}
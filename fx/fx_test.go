@@ -0,0 +1,146 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"server/http/helper"
+	"server/money"
+	"server/sql/memdb"
+)
+
+// converter loads the rates, each "BASE QUOTE rate YYYY-MM-DD", in effect on asOf
+func converter(t *testing.T, asOf string, rates ...string) *Converter {
+	t.Helper()
+	ctx := context.Background()
+	store := memdb.New()
+	for _, spec := range rates {
+		fields := strings.Fields(spec)
+		rate, err := money.ParseRate(fields[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := ExchangeRate{Base: fields[0], Quote: fields[1], Rate: rate, Date: fields[3]}
+		if err := e.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		arg, err := e.Params(SourceManual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.UpsertFxRate(ctx, arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	date, err := helper.ParseDate(asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(ctx, store, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rates    []string
+		from, to string
+		want     string
+	}{
+		{
+			name: "same currency",
+			from: "EUR", to: "EUR",
+			want: "1",
+		},
+		{
+			name:  "direct",
+			rates: []string{"EUR USD 1.1 2025-06-01"},
+			from:  "EUR", to: "USD",
+			want: "1.1",
+		},
+		{
+			name:  "inverse",
+			rates: []string{"USD EUR 0.8 2025-06-01"},
+			from:  "EUR", to: "USD",
+			want: "1.25",
+		},
+		{
+			name:  "latest not after the day",
+			rates: []string{"EUR USD 1.1 2025-06-01", "EUR USD 1.2 2025-06-20", "EUR USD 5 2025-07-01"},
+			from:  "EUR", to: "USD",
+			want: "1.2",
+		},
+		{
+			name:  "newer inverse over older direct",
+			rates: []string{"EUR USD 1.25 2025-06-01", "USD EUR 0.5 2025-06-20"},
+			from:  "EUR", to: "USD",
+			want: "2",
+		},
+		{
+			name:  "newer direct over older inverse",
+			rates: []string{"EUR USD 1.25 2025-06-01", "USD EUR 0.5 2025-06-20"},
+			from:  "USD", to: "EUR",
+			want: "0.5",
+		},
+		{
+			name:  "cross",
+			rates: []string{"INR USD 0.012 2025-06-20", "USD EUR 0.9 2025-06-20"},
+			from:  "INR", to: "EUR",
+			want: "0.0108",
+		},
+		{
+			name:  "newer cross over stale direct",
+			rates: []string{"INR EUR 0.01 2025-01-02", "INR USD 0.012 2025-06-20", "USD EUR 0.9 2025-06-20"},
+			from:  "INR", to: "EUR",
+			want: "0.0108",
+		},
+		{
+			name:  "direct over a cross of the same day",
+			rates: []string{"INR EUR 0.01 2025-06-20", "INR USD 0.012 2025-06-20", "USD EUR 0.9 2025-06-20"},
+			from:  "INR", to: "EUR",
+			want: "0.01",
+		},
+		{
+			name:  "cross is as old as its older leg",
+			rates: []string{"INR USD 0.012 2025-01-02", "USD EUR 0.9 2025-06-20", "INR EUR 0.01 2025-03-01"},
+			from:  "INR", to: "EUR",
+			want: "0.01",
+		},
+		{
+			// EUR comes first in order, its cross has a stale leg
+			name: "newest cross",
+			rates: []string{
+				"INR EUR 0.01 2025-03-01", "EUR GBP 0.8 2025-06-20",
+				"INR USD 0.012 2025-06-20", "USD GBP 0.75 2025-06-20",
+			},
+			from: "INR", to: "GBP",
+			want: "0.009",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := converter(t, "2025-06-30", tt.rates...).Rate(tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Rate(%s, %s) = %s, want %s", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateMissing(t *testing.T) {
+	c := converter(t, "2025-06-30", "EUR USD 1.1 2025-06-01", "JPY CHF 0.006 2025-07-01")
+	for _, p := range [][2]string{{"EUR", "JPY"}, {"JPY", "CHF"}} {
+		if _, err := c.Rate(p[0], p[1]); !errors.Is(err, ErrNoRate) {
+			t.Errorf("Rate(%s, %s): %v, want ErrNoRate", p[0], p[1], err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"server/money"
+)
+
+// csvColumns are the header names a rates CSV can use, in any order
+var csvColumns = map[string][]string{
+	"date":  {"date", "rate_date"},
+	"base":  {"base", "base_currency"},
+	"quote": {"quote", "quote_currency"},
+	"rate":  {"rate"},
+}
+
+// ParseCSV reads rates from a CSV with a "date,base,quote,rate" header,
+// the whole file is checked before anything is returned
+func ParseCSV(r io.Reader) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(csvColumns))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for column, names := range csvColumns {
+			if slices.Contains(names, name) {
+				index[column] = i
+			}
+		}
+	}
+	for _, column := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("line 1: missing %q column", column)
+		}
+	}
+
+	var rates []ExchangeRate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		rate, err := money.ParseRate(record[index["rate"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate", line)
+		}
+		e := ExchangeRate{
+			Base:  record[index["base"]],
+			Quote: record[index["quote"]],
+			Rate:  rate,
+			Date:  strings.TrimSpace(record[index["date"]]),
+		}
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, e)
+	}
+	return rates, nil
+}
+
+// ecbEnvelope is the layout of the ECB reference rates (eurofxref-daily.xml, eurofxref-hist.xml)
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads the euro reference rates of the European Central Bank,
+// currencies no longer in ISO 4217 (older days of the history) are skipped and listed
+func ParseECB(r io.Reader) (rates []ExchangeRate, skipped []string, err error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, nil, fmt.Errorf("invalid xml: %w", err)
+	}
+	if len(envelope.Days) == 0 {
+		return nil, nil, errors.New("no rates in the file")
+	}
+
+	for _, day := range envelope.Days {
+		for _, quote := range day.Rates {
+			code := money.NormalizeCurrency(quote.Currency)
+			if !money.IsCurrency(code) {
+				if !slices.Contains(skipped, code) {
+					skipped = append(skipped, code)
+				}
+				continue
+			}
+
+			rate, err := money.ParseRate(quote.Rate)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s %s: invalid rate", day.Time, code)
+			}
+			e := ExchangeRate{Base: "EUR", Quote: code, Rate: rate, Date: day.Time}
+			if err := e.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("%s %s: %w", day.Time, code, err)
+			}
+			rates = append(rates, e)
+		}
+	}
+	slices.Sort(skipped)
+	return rates, skipped, nil
+}
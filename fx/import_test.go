@@ -0,0 +1,108 @@
+package fx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// formatRates lists the rates as "date base/quote rate"
+func formatRates(rates []ExchangeRate) []string {
+	lines := make([]string, 0, len(rates))
+	for _, e := range rates {
+		lines = append(lines, e.Date+" "+e.Base+"/"+e.Quote+" "+e.Rate.String())
+	}
+	return lines
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "header aliases in any order, codes normalized",
+			in:   "Rate,Quote_Currency,base,rate_date\n1.0825, usd ,eur,2025-06-02\n0.0108,EUR,INR,2025-06-03\n",
+			want: []string{"2025-06-02 EUR/USD 1.0825", "2025-06-03 INR/EUR 0.0108"},
+		},
+		{name: "empty", in: "", wantErr: "empty file"},
+		{name: "missing column", in: "date,base,rate\n", wantErr: `line 1: missing "quote" column`},
+		{name: "invalid rate", in: "date,base,quote,rate\n2025-06-02,EUR,USD,abc\n", wantErr: "line 2: invalid rate"},
+		{name: "negative rate", in: "date,base,quote,rate\n2025-06-02,EUR,USD,-1\n", wantErr: "line 2: rate must be positive"},
+		{name: "unknown currency", in: "date,base,quote,rate\n2025-06-02,EUR,XYZ,1\n", wantErr: "line 2: quote_currency"},
+		{name: "same currency", in: "date,base,quote,rate\n2025-06-02,EUR,eur,1\n", wantErr: "line 2: base_currency and quote_currency must differ"},
+		{name: "invalid date", in: "date,base,quote,rate\n2025-06-02,EUR,USD,1\n02/06/2025,EUR,USD,1\n", wantErr: "line 3: rate_date must be YYYY-MM-DD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, err := ParseCSV(strings.NewReader(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatRates(rates); !slices.Equal(got, tt.want) {
+				t.Errorf("rates = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ecbHistory is the layout of eurofxref-hist.xml, with a currency withdrawn since
+const ecbHistory = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender><gesmes:name>European Central Bank</gesmes:name></gesmes:Sender>
+	<Cube>
+		<Cube time="2025-06-03">
+			<Cube currency="USD" rate="1.1376"/>
+			<Cube currency="JPY" rate="163.34"/>
+		</Cube>
+		<Cube time="2007-12-31">
+			<Cube currency="USD" rate="1.4721"/>
+			<Cube currency="CYP" rate="0.585274"/>
+			<Cube currency="MTL" rate="0.4293"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestParseECB(t *testing.T) {
+	rates, skipped, err := ParseECB(strings.NewReader(ecbHistory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2025-06-03 EUR/USD 1.1376", "2025-06-03 EUR/JPY 163.34", "2007-12-31 EUR/USD 1.4721"}
+	if got := formatRates(rates); !slices.Equal(got, want) {
+		t.Errorf("rates = %q, want %q", got, want)
+	}
+	if want := []string{"CYP", "MTL"}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped = %q, want %q", skipped, want)
+	}
+}
+
+func TestParseECBInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "not xml", in: "date,base,quote,rate", wantErr: "invalid xml"},
+		{name: "no rates", in: `<Envelope><Cube></Cube></Envelope>`, wantErr: "no rates in the file"},
+		{name: "invalid rate", in: `<Envelope><Cube><Cube time="2025-06-03"><Cube currency="USD" rate="n/a"/></Cube></Cube></Envelope>`, wantErr: "2025-06-03 USD: invalid rate"},
+		{name: "invalid date", in: `<Envelope><Cube><Cube time="03.06.2025"><Cube currency="USD" rate="1.1"/></Cube></Cube></Envelope>`, wantErr: "rate_date must be YYYY-MM-DD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseECB(strings.NewReader(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package adminhandler
+
+import (
+	"server/audit"
+	"server/config"
+	"server/sql/database"
+
+	"go.uber.org/zap"
+)
+
+// Handler serves the role, token and superadmin routes
+type Handler struct {
+	queries  database.Querier
+	auditLog *audit.Recorder
+	logger   *zap.Logger
+	config   *config.Config
+}
+
+func New(queries database.Querier, auditLog *audit.Recorder, logger *zap.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		queries:  queries,
+		auditLog: auditLog,
+		logger:   logger,
+		config:   cfg,
+	}
+}
@@ -0,0 +1,147 @@
+package adminhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 100
+)
+
+// AuditEntry is a single recorded write
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	ActorID    *int64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Diff       json.RawMessage `json:"diff"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// AuditPage is one page of entries, newest first
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// ListAuditEntries returns one page of the audit log, filtered by
+// "actor_id", "action", "target_type", "target_id", "since" and "until" (RFC 3339)
+// and continued with the "next_cursor" of the previous page passed as "cursor"
+func (h *Handler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAuditParams(r)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pageSize := params.PageSize
+
+	// Fetch one extra row to know whether there is a next page
+	params.PageSize++
+	entries, err := h.queries.ListAuditEntries(r.Context(), params)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch audit log")
+		return
+	}
+
+	page := AuditPage{Entries: make([]AuditEntry, 0, len(entries))}
+	if len(entries) > int(pageSize) {
+		entries = entries[:pageSize]
+		page.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	for _, e := range entries {
+		page.Entries = append(page.Entries, dbAuditToAuditEntry(e))
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, page)
+}
+
+func parseAuditParams(r *http.Request) (database.ListAuditEntriesParams, error) {
+	query := r.URL.Query()
+	params := database.ListAuditEntriesParams{PageSize: defaultAuditPageSize}
+
+	if actorID := query.Get("actor_id"); actorID != "" {
+		id, err := strconv.ParseInt(actorID, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid actor_id")
+		}
+		params.ActorID = &id
+	}
+	if action := query.Get("action"); action != "" {
+		params.Action = &action
+	}
+	if targetType := query.Get("target_type"); targetType != "" {
+		params.TargetType = &targetType
+	}
+	if targetID := query.Get("target_id"); targetID != "" {
+		params.TargetID = &targetID
+	}
+
+	var err error
+	if params.Since, err = timeParam(query.Get("since")); err != nil {
+		return params, fmt.Errorf("invalid since, expected RFC 3339")
+	}
+	if params.Until, err = timeParam(query.Get("until")); err != nil {
+		return params, fmt.Errorf("invalid until, expected RFC 3339")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize < 1 || pageSize > maxAuditPageSize {
+			return params, fmt.Errorf("limit must be between 1 and %d", maxAuditPageSize)
+		}
+		params.PageSize = int32(pageSize)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid cursor")
+		}
+		params.CursorID = &id
+	}
+
+	return params, nil
+}
+
+// timeParam parses an optional RFC 3339 time, empty means no filter (NULL)
+func timeParam(value string) (pgtype.Timestamp, error) {
+	if value == "" {
+		return pgtype.Timestamp{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return pgtype.Timestamp{}, err
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}, nil
+}
+
+func dbAuditToAuditEntry(e *database.AuditLog) AuditEntry {
+	return AuditEntry{
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Diff:       json.RawMessage(e.Diff),
+		RequestID:  e.RequestID,
+		IP:         e.Ip,
+		CreatedAt:  e.CreatedAt.Time,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+}
@@ -0,0 +1,236 @@
+package adminhandler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"server/fx"
+	"server/http/helper"
+	"server/http/request"
+	"server/http/response"
+	"server/money"
+	"server/sql/database"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultFxPageSize = 100
+	maxFxPageSize     = 1000
+
+	// eurofxref-hist.xml, every day since 1999, is about 6MB
+	maxFxImportSize = 32 << 20
+)
+
+// FxImport is the outcome of an exchange rate import
+type FxImport struct {
+	Source   string   `json:"source"`
+	Imported int      `json:"imported"`
+	Skipped  []string `json:"skipped,omitempty"` // currencies of the file that are no longer ISO 4217
+}
+
+// ListFxRates returns the exchange rates, newest first, filtered by
+// "currency" (either side of the pair), "since" and "until" (YYYY-MM-DD, inclusive)
+func (h *Handler) ListFxRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := database.ListFxRatesParams{PageSize: defaultFxPageSize}
+
+	if currency := money.NormalizeCurrency(query.Get("currency")); currency != "" {
+		params.Currency = &currency
+	}
+	var err error
+	if since := query.Get("since"); since != "" {
+		if params.Since, err = helper.ParseDate(since); err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "since must be YYYY-MM-DD")
+			return
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		if params.Until, err = helper.ParseDate(until); err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "until must be YYYY-MM-DD")
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize < 1 || pageSize > maxFxPageSize {
+			response.RespondeWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxFxPageSize))
+			return
+		}
+		params.PageSize = int32(pageSize)
+	}
+
+	rows, err := h.queries.ListFxRates(r.Context(), params)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch exchange rates")
+		return
+	}
+
+	rates := make([]fx.ExchangeRate, 0, len(rows))
+	for _, row := range rows {
+		rates = append(rates, fx.FromDB(row))
+	}
+
+	if response.WantsXLSX(w, r) {
+		book, err := fxRatesWorkbook(rates)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+			return
+		}
+		response.RespondeWithXLSX(w, "fx-rates.xlsx", book)
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, rates)
+}
+
+// ConvertAmount converts "amount" from the currency "from" to "to"
+// at the rates in effect on "as_of" (today by default)
+func (h *Handler) ConvertAmount(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	amount, err := money.ParseAmount(query.Get("amount"))
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, "amount must be a number")
+		return
+	}
+	from, to := money.NormalizeCurrency(query.Get("from")), money.NormalizeCurrency(query.Get("to"))
+	if !money.IsCurrency(from) || !money.IsCurrency(to) {
+		response.RespondeWithError(w, http.StatusBadRequest, "from and to must be ISO 4217 codes")
+		return
+	}
+	asOf := helper.Today()
+	if value := query.Get("as_of"); value != "" {
+		if asOf, err = helper.ParseDate(value); err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "as_of must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	converter, err := fx.Load(r.Context(), h.queries, asOf)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot load exchange rates")
+		return
+	}
+	rate, err := converter.Rate(from, to)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, map[string]any{
+		"from":      from,
+		"to":        to,
+		"as_of":     asOf.Time.Format(helper.DateLayout),
+		"rate":      rate,
+		"amount":    amount,
+		"converted": amount.Mul(rate).Round(to),
+	})
+}
+
+// CreateFxRate sets the rate of a currency pair on a day, replacing the one already there
+func (h *Handler) CreateFxRate(w http.ResponseWriter, r *http.Request) {
+	var rate fx.ExchangeRate
+	if !request.DecodeJSON(w, r, &rate) {
+		return
+	}
+	if err := rate.Validate(); err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	arg, err := rate.Params(fx.SourceManual)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	row, err := h.queries.UpsertFxRate(r.Context(), arg)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot save exchange rate")
+		return
+	}
+	h.auditLog.Log(r, "fx_rate.create", "fx_rate", strconv.FormatInt(row.ID, 10), nil, fx.FromDB(row))
+
+	response.RespondeWithJSON(w, http.StatusCreated, fx.FromDB(row))
+}
+
+// ImportFxRates loads a file of rates, a "date,base,quote,rate" CSV (text/csv) or the ECB
+// euro reference rates XML (application/xml), "format" ("csv" or "ecb") overrides the Content-Type.
+// Nothing is written unless the whole file is valid, importing a file again replaces its rates.
+func (h *Handler) ImportFxRates(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = fx.SourceCSV
+		case "application/xml", "text/xml":
+			format = fx.SourceECB
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxFxImportSize)
+	result := FxImport{Source: format}
+	var rates []fx.ExchangeRate
+	var err error
+	switch format {
+	case fx.SourceCSV:
+		rates, err = fx.ParseCSV(body)
+	case fx.SourceECB:
+		rates, result.Skipped, err = fx.ParseECB(body)
+	default:
+		response.RespondeWithError(w, http.StatusUnsupportedMediaType, "send text/csv or application/xml, or set format=csv|ecb")
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		response.RespondeWithError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	for _, rate := range rates {
+		arg, err := rate.Params(format)
+		if err == nil {
+			_, err = h.queries.UpsertFxRate(r.Context(), arg)
+		}
+		if err != nil {
+			response.RespondeWithError(w, http.StatusInternalServerError,
+				fmt.Sprintf("couldnot save %s/%s on %s, %d of %d rates imported", rate.Base, rate.Quote, rate.Date, result.Imported, len(rates)))
+			return
+		}
+		result.Imported++
+	}
+	h.auditLog.Log(r, "fx_rate.import", "fx_rate", "", nil, result)
+
+	response.RespondeWithJSON(w, http.StatusOK, result)
+}
+
+// DeleteFxRate removes the exchange rate "{id}"
+func (h *Handler) DeleteFxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid exchange rate id")
+		return
+	}
+
+	row, err := h.queries.DeleteFxRate(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "exchange rate not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot delete exchange rate")
+		return
+	}
+	h.auditLog.Log(r, "fx_rate.delete", "fx_rate", strconv.FormatInt(row.ID, 10), fx.FromDB(row), nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, fx.FromDB(row))
+}
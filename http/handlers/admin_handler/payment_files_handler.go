@@ -0,0 +1,82 @@
+package adminhandler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/bank"
+	"server/http/response"
+	"server/payroll"
+)
+
+// ExportPayrollRunSEPA returns the SEPA credit transfers (pain.001 XML) paying the net of
+// the EUR payslips of the locked run "{id}"
+func (h *Handler) ExportPayrollRunSEPA(w http.ResponseWriter, r *http.Request) {
+	h.exportPayrollRun(w, r, bank.SEPA, "application/xml", "xml", bank.WriteSEPA)
+}
+
+// ExportPayrollRunNACHA returns the NACHA ACH file paying the net of the USD payslips of the
+// locked run "{id}"
+func (h *Handler) ExportPayrollRunNACHA(w http.ResponseWriter, r *http.Request) {
+	h.exportPayrollRun(w, r, bank.ACH, "text/plain; charset=us-ascii", "ach", bank.WriteNACHA)
+}
+
+// exportPayrollRun writes the payment file of "scheme" for the run, 409 when the run isn't
+// locked, pays nothing in the currency of the scheme or an employee has no account for it
+func (h *Handler) exportPayrollRun(w http.ResponseWriter, r *http.Request, scheme, contentType, extension string,
+	write func(io.Writer, bank.Originator, bank.Batch) error) {
+	originator := bank.Originator{
+		Name:          h.config.CompanyName,
+		IBAN:          h.config.CompanyIBAN,
+		BIC:           h.config.CompanyBIC,
+		RoutingNumber: h.config.CompanyRoutingNumber,
+		BankName:      h.config.CompanyBankName,
+		CompanyID:     h.config.CompanyACHID,
+	}
+	if err := originator.Check(scheme); err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	row, ok := h.targetPayrollRun(w, r)
+	if !ok {
+		return
+	}
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+	accounts, err := h.queries.ListRunBankAccounts(r.Context(), row.ID)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch bank accounts")
+		return
+	}
+
+	batch, err := payroll.Batch(run.Run, run.Payslips, accounts, scheme, time.Now())
+	if err != nil {
+		response.RespondeWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, originator, batch); err != nil {
+		response.RespondeWithFailure(w, err, "couldnot write payment file")
+		return
+	}
+
+	// Nothing of the accounts goes to the log, the payment file is the record of them
+	h.auditLog.Log(r, "payroll_run.export", "payroll_run", strconv.FormatInt(row.ID, 10), nil, map[string]any{
+		"scheme":   scheme,
+		"file_id":  batch.ID,
+		"payments": len(batch.Payments),
+	})
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payroll-run-%d-%s.%s"`, row.ID, scheme, extension))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,495 @@
+package adminhandler
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/http/request"
+	"server/http/response"
+	"server/payroll"
+	"server/sql/database"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPayrollPageSize = 50
+	maxPayrollPageSize     = 500
+)
+
+// errRunNotDraft stops a recalculation that found the run no longer a draft
+var errRunNotDraft = errors.New("payroll run is not a draft")
+
+// PayrollRunBody opens the run of a period, "pay_date" defaults to the last day of the period
+type PayrollRunBody struct {
+	Frequency   string `json:"frequency" validate:"required,oneof=monthly biweekly"`
+	PeriodStart string `json:"period_start" validate:"required,date"` // the 1st of the month for a monthly run
+	PayDate     string `json:"pay_date" validate:"date"`
+}
+
+// Normalize trims the fields and lower cases the frequency
+func (b *PayrollRunBody) Normalize() {
+	b.Frequency = strings.ToLower(strings.TrimSpace(b.Frequency))
+	b.PeriodStart = strings.TrimSpace(b.PeriodStart)
+	b.PayDate = strings.TrimSpace(b.PayDate)
+}
+
+// ReversalBody says why a locked run is reversed
+type ReversalBody struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// Normalize trims the reason
+func (b *ReversalBody) Normalize() {
+	b.Reason = strings.TrimSpace(b.Reason)
+}
+
+// PayrollRun is a run with the payslips it pays, "Skipped" is only set right after
+// the run is calculated
+type PayrollRun struct {
+	payroll.Run
+	Totals   []payroll.Total   `json:"totals"`
+	Payslips []payroll.Payslip `json:"payslips,omitempty"`
+	Skipped  []payroll.Skipped `json:"skipped,omitempty"`
+}
+
+// ListPayrollRuns returns the payroll runs, latest period first, only those in "status" if set
+func (h *Handler) ListPayrollRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := database.ListPayrollRunsParams{PageSize: defaultPayrollPageSize}
+
+	if status := query.Get("status"); status != "" {
+		if status != payroll.StatusDraft && status != payroll.StatusLocked && status != payroll.StatusReversed {
+			response.RespondeWithError(w, http.StatusBadRequest, "status must be draft, locked or reversed")
+			return
+		}
+		params.Status = &status
+	}
+	if limit := query.Get("limit"); limit != "" {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize < 1 || pageSize > maxPayrollPageSize {
+			response.RespondeWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPayrollPageSize))
+			return
+		}
+		params.PageSize = int32(pageSize)
+	}
+
+	rows, err := h.queries.ListPayrollRuns(r.Context(), params)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch payroll runs")
+		return
+	}
+
+	runs := make([]payroll.Run, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, payroll.RunFromDB(row))
+	}
+
+	if response.WantsXLSX(w, r) {
+		book, err := payrollRunsWorkbook(runs)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+			return
+		}
+		response.RespondeWithXLSX(w, "payroll-runs.xlsx", book)
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, runs)
+}
+
+// GetPayrollRun returns the payroll run "{id}" with its payslips, a draft is the preview of the run.
+// Asked for XLSX, the payslips of each currency are a sheet.
+func (h *Handler) GetPayrollRun(w http.ResponseWriter, r *http.Request) {
+	row, ok := h.targetPayrollRun(w, r)
+	if !ok {
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+
+	if response.WantsXLSX(w, r) {
+		book, err := run.workbook()
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+			return
+		}
+		response.RespondeWithXLSX(w, fmt.Sprintf("payroll-run-%d.xlsx", run.ID), book)
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, run)
+}
+
+// CreatePayrollRun calculates the payslips of a period into a draft run, nothing is issued
+// to the employees before the run is locked
+func (h *Handler) CreatePayrollRun(w http.ResponseWriter, r *http.Request) {
+	var body PayrollRunBody
+	if !request.DecodeJSON(w, r, &body) {
+		return
+	}
+	period, err := payroll.NewPeriod(body.Frequency, body.PeriodStart, body.PayDate)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	arg := database.CreatePayrollRunParams{
+		Frequency:   period.Frequency,
+		PeriodStart: period.Start,
+		PeriodEnd:   period.End,
+		PayDate:     period.PayDate,
+	}
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		arg.CreatedBy = &userInfo.ID
+	}
+
+	// The run and its payslips are saved together or not at all
+	var row *database.PayrollRun
+	var skipped []payroll.Skipped
+	err = db.InTx(r.Context(), func(q database.Querier) error {
+		payslips, s, err := payroll.Prepare(r.Context(), q, period)
+		if err != nil {
+			return err
+		}
+		skipped = s
+		if row, err = q.CreatePayrollRun(r.Context(), arg); err != nil {
+			return err
+		}
+		return savePayslips(r.Context(), q, row.ID, payslips)
+	})
+	if helper.IsExclusionViolation(err) {
+		response.RespondeWithError(w, http.StatusConflict,
+			fmt.Sprintf("the %s period %s to %s overlaps another run, reverse it first",
+				period.Frequency, period.Start.Time.Format(time.DateOnly), period.End.Time.Format(time.DateOnly)))
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot create payroll run")
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+	run.Skipped = skipped
+	h.auditLog.Log(r, "payroll_run.create", "payroll_run", strconv.FormatInt(row.ID, 10), nil, run.summary())
+
+	response.RespondeWithJSON(w, http.StatusCreated, run)
+}
+
+// RecalculatePayrollRun calculates the payslips of the draft run "{id}" again, with the
+// salaries, tax regimes and exchange rates as they are now
+func (h *Handler) RecalculatePayrollRun(w http.ResponseWriter, r *http.Request) {
+	row, ok := h.targetPayrollRun(w, r)
+	if !ok {
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	// The run stays locked from the status check to the last payslip, a concurrent lock or
+	// recalculation waits for it
+	var before PayrollRun
+	var skipped []payroll.Skipped
+	err := db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		if row, err = q.GetPayrollRunForUpdate(r.Context(), row.ID); err != nil {
+			return err
+		}
+		if row.Status != payroll.StatusDraft {
+			return errRunNotDraft
+		}
+		if before, err = loadPayrollRun(r.Context(), q, row); err != nil {
+			return err
+		}
+
+		payslips, s, err := payroll.Prepare(r.Context(), q, payroll.PeriodOf(row))
+		if err != nil {
+			return err
+		}
+		skipped = s
+		if err := q.DeleteRunPayslips(r.Context(), row.ID); err != nil {
+			return err
+		}
+		return savePayslips(r.Context(), q, row.ID, payslips)
+	})
+	if errors.Is(err, errRunNotDraft) {
+		response.RespondeWithError(w, http.StatusConflict, fmt.Sprintf("payroll run is %s, only a draft can be recalculated", row.Status))
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot recalculate payslips")
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+	run.Skipped = skipped
+	h.auditLog.Log(r, "payroll_run.recalculate", "payroll_run", strconv.FormatInt(row.ID, 10), before.summary(), run.summary())
+
+	response.RespondeWithJSON(w, http.StatusOK, run)
+}
+
+// LockPayrollRun locks the draft run "{id}", its payslips are issued and never change again
+func (h *Handler) LockPayrollRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	arg := database.LockPayrollRunParams{ID: id}
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		arg.LockedBy = &userInfo.ID
+	}
+	row, err := h.queries.LockPayrollRun(r.Context(), arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.payrollRunConflict(w, r, id, "locked")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot lock payroll run")
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+	h.auditLog.Log(r, "payroll_run.lock", "payroll_run", strconv.FormatInt(row.ID, 10),
+		map[string]string{"status": payroll.StatusDraft}, run.summary())
+
+	response.RespondeWithJSON(w, http.StatusOK, run)
+}
+
+// ReversePayrollRun reverses the locked run "{id}", its payslips stay for the record and the
+// period can be run again
+func (h *Handler) ReversePayrollRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	var body ReversalBody
+	if !request.DecodeJSON(w, r, &body) {
+		return
+	}
+
+	arg := database.ReversePayrollRunParams{ID: id, ReversalReason: body.Reason}
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		arg.ReversedBy = &userInfo.ID
+	}
+	row, err := h.queries.ReversePayrollRun(r.Context(), arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.payrollRunConflict(w, r, id, "reversed")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot reverse payroll run")
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+	h.auditLog.Log(r, "payroll_run.reverse", "payroll_run", strconv.FormatInt(row.ID, 10),
+		map[string]string{"status": payroll.StatusLocked}, run.summary())
+
+	response.RespondeWithJSON(w, http.StatusOK, run)
+}
+
+// DeletePayrollRun discards the draft run "{id}" and its payslips
+func (h *Handler) DeletePayrollRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := h.queries.DeletePayrollRun(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.payrollRunConflict(w, r, id, "deleted")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot delete payroll run")
+		return
+	}
+	h.auditLog.Log(r, "payroll_run.delete", "payroll_run", strconv.FormatInt(row.ID, 10), payroll.RunFromDB(row), nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, payroll.RunFromDB(row))
+}
+
+// DownloadPayrollRunPayslips returns the payslips of the run "{id}" as a ZIP of PDF documents,
+// one per employee. A draft or reversed run is marked as such on every payslip.
+func (h *Handler) DownloadPayrollRunPayslips(w http.ResponseWriter, r *http.Request) {
+	row, ok := h.targetPayrollRun(w, r)
+	if !ok {
+		return
+	}
+
+	run, ok := h.payrollRunWithPayslips(w, r, row)
+	if !ok {
+		return
+	}
+
+	// Every payslip of a run is in the tax year of its pay date, only those of its employees
+	// count in the year to date
+	employeeIDs := make([]int32, 0, len(run.Payslips))
+	for _, payslip := range run.Payslips {
+		employeeIDs = append(employeeIDs, payslip.EmployeeID)
+	}
+	rows, err := h.queries.ListLockedPayslipsForYear(r.Context(), database.ListLockedPayslipsForYearParams{
+		TaxYear:     payroll.PeriodOf(row).TaxYear(),
+		EmployeeIds: employeeIDs,
+	})
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch payslips")
+		return
+	}
+	paid := make([]payroll.Payslip, 0, len(rows))
+	for _, slip := range rows {
+		payslip, err := payroll.PayslipFromDB(slip)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot read payslip")
+			return
+		}
+		paid = append(paid, payslip)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payroll-run-%d.zip"`, run.ID))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is sent, from here on a failure can only cut the archive short
+	company := payroll.Company{Name: h.config.CompanyName, Address: h.config.CompanyAddress}
+	archive := zip.NewWriter(w)
+	for _, payslip := range run.Payslips {
+		payslip.Status = run.Status
+		f, err := archive.Create(fmt.Sprintf("payslip-%d-employee-%d.pdf", payslip.ID, payslip.EmployeeID))
+		if err == nil {
+			err = payslip.WritePDF(f, company, payroll.YearToDateOf(payslip, paid))
+		}
+		if err != nil {
+			h.logger.Error("payslips zip cut short", zap.Int64("run_id", run.ID), zap.Int64("payslip_id", payslip.ID), zap.Error(err))
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		h.logger.Error("payslips zip cut short", zap.Int64("run_id", run.ID), zap.Error(err))
+	}
+}
+
+// summary is the run without its payslips, what the audit log keeps
+func (run PayrollRun) summary() PayrollRun {
+	run.Payslips = nil
+	return run
+}
+
+// savePayslips stores the payslips of the run "runID"
+func savePayslips(ctx context.Context, q database.Querier, runID int64, payslips []database.CreatePayslipParams) error {
+	for _, arg := range payslips {
+		arg.RunID = runID
+		if _, err := q.CreatePayslip(ctx, arg); err != nil {
+			return fmt.Errorf("employee %d: %w", arg.EmployeeID, err)
+		}
+	}
+	return nil
+}
+
+// payrollRunWithPayslips loads the payslips of the run and sums them up
+func (h *Handler) payrollRunWithPayslips(w http.ResponseWriter, r *http.Request, row *database.PayrollRun) (PayrollRun, bool) {
+	run, err := loadPayrollRun(r.Context(), h.queries, row)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot fetch payslips")
+		return PayrollRun{}, false
+	}
+	return run, true
+}
+
+func loadPayrollRun(ctx context.Context, q database.Querier, row *database.PayrollRun) (PayrollRun, error) {
+	rows, err := q.ListRunPayslips(ctx, row.ID)
+	if err != nil {
+		return PayrollRun{}, err
+	}
+
+	run := PayrollRun{Run: payroll.RunFromDB(row), Payslips: make([]payroll.Payslip, 0, len(rows))}
+	for _, slip := range rows {
+		payslip, err := payroll.PayslipFromDB(slip)
+		if err != nil {
+			return PayrollRun{}, err
+		}
+		run.Payslips = append(run.Payslips, payslip)
+	}
+	run.Totals = payroll.Totals(run.Payslips)
+	return run, nil
+}
+
+// payrollRunConflict answers a lock, reversal or delete that matched no run: 404 when
+// there is no such run, 409 when it is not in the status the action needs
+func (h *Handler) payrollRunConflict(w http.ResponseWriter, r *http.Request, id int64, action string) {
+	row, err := h.queries.GetPayrollRun(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "payroll run not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch payroll run")
+		return
+	}
+	response.RespondeWithError(w, http.StatusConflict, fmt.Sprintf("payroll run is %s, it can't be %s", row.Status, action))
+}
+
+// targetPayrollRun reads the "{id}" URL param and fetches the payroll run
+func (h *Handler) targetPayrollRun(w http.ResponseWriter, r *http.Request) (*database.PayrollRun, bool) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	row, err := h.queries.GetPayrollRun(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "payroll run not found")
+		return nil, false
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch payroll run")
+		return nil, false
+	}
+	return row, true
+}
+
+func payrollRunID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid payroll run id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,239 @@
+package adminhandler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Role is a role along with the permissions it grants
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UserRoles is what a user holds
+type UserRoles struct {
+	UserID      int64    `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRoles returns every role and its permissions
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.queries.ListRoles(r.Context())
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+		return
+	}
+
+	resp := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		permissions, err := h.queries.ListRolePermissions(r.Context(), role.ID)
+		if err != nil {
+			response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+			return
+		}
+		resp = append(resp, Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: nonNil(permissions),
+		})
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, resp)
+}
+
+// GetUserRoles returns the roles of the user "{id}"
+func (h *Handler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	h.respondWithUserRoles(w, r, userID)
+}
+
+// AssignUserRole hands the role "{role}" to the user "{id}"
+func (h *Handler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := h.roleChange(w, r)
+	if !ok {
+		return
+	}
+
+	changed, err := h.queries.AssignUserRole(r.Context(), database.AssignUserRoleParams{UserID: userID, RoleID: role.ID})
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot assign role")
+		return
+	}
+	if changed == 0 {
+		h.respondWithUserRoles(w, r, userID)
+		return
+	}
+
+	h.rolesChanged(w, r, userID, role.Name, true)
+}
+
+// RemoveUserRole takes the role "{role}" away from the user "{id}"
+func (h *Handler) RemoveUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := h.roleChange(w, r)
+	if !ok {
+		return
+	}
+
+	// Without a superadmin nobody could hand out the admin role any more
+	if role.Name == helper.SuperadminRole {
+		roles, err := h.queries.ListUserRoles(r.Context(), userID)
+		if err != nil {
+			response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+			return
+		}
+		count, err := h.queries.CountUsersWithRole(r.Context(), helper.SuperadminRole)
+		if err != nil {
+			response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+			return
+		}
+		if count <= 1 && slices.Contains(roles, helper.SuperadminRole) {
+			response.RespondeWithError(w, http.StatusConflict, "cannot remove the last superadmin")
+			return
+		}
+	}
+
+	changed, err := h.queries.RemoveUserRole(r.Context(), database.RemoveUserRoleParams{UserID: userID, RoleID: role.ID})
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot remove role")
+		return
+	}
+	if changed == 0 {
+		h.respondWithUserRoles(w, r, userID)
+		return
+	}
+
+	h.rolesChanged(w, r, userID, role.Name, false)
+}
+
+// roleChange resolves "{id}" and "{role}" and checks the caller may hand the role out:
+// nobody can grant a permission they don't hold themselves, and roles which can hand out
+// roles themselves (admin, superadmin) are reserved to holders of "admins:write"
+func (h *Handler) roleChange(w http.ResponseWriter, r *http.Request) (int64, *database.Role, bool) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	role, err := h.queries.GetRoleByName(r.Context(), chi.URLParam(r, "role"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "role not found")
+		return 0, nil, false
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch role")
+		return 0, nil, false
+	}
+
+	permissions, err := h.queries.ListRolePermissions(r.Context(), role.ID)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch role")
+		return 0, nil, false
+	}
+
+	caller, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return 0, nil, false
+	}
+	for _, permission := range permissions {
+		if !caller.HasPermission(permission) {
+			response.RespondeWithError(w, http.StatusForbidden, "cannot manage a role granting "+permission)
+			return 0, nil, false
+		}
+	}
+	if slices.Contains(permissions, "roles:write") && !caller.HasPermission("admins:write") {
+		response.RespondeWithError(w, http.StatusForbidden, "only a superadmin can manage the "+role.Name+" role")
+		return 0, nil, false
+	}
+
+	return userID, role, true
+}
+
+// rolesChanged records the assigned / removed role and expires the access tokens of the user
+// so the next refresh carries the new roles
+func (h *Handler) rolesChanged(w http.ResponseWriter, r *http.Request, userID int64, role string, assigned bool) {
+	action, before, after := "role.remove", map[string]string{"role": role}, map[string]string(nil)
+	if assigned {
+		action, before, after = "role.assign", nil, map[string]string{"role": role}
+	}
+	h.auditLog.Log(r, action, "user", strconv.FormatInt(userID, 10), before, after)
+
+	err := helper.ExpireAccessTokens(r.Context(), h.queries, userID, h.config.AccessTokenTTL)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot expire access tokens")
+		return
+	}
+
+	h.logger.Info("roles of user changed", zap.Int64("user_id", userID), zap.String(action, role))
+	h.respondWithUserRoles(w, r, userID)
+}
+
+func (h *Handler) respondWithUserRoles(w http.ResponseWriter, r *http.Request, userID int64) {
+	roles, permissions, err := helper.UserAccess(r.Context(), h.queries, userID)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, UserRoles{
+		UserID:      userID,
+		Roles:       nonNil(roles),
+		Permissions: nonNil(permissions),
+	})
+}
+
+// targetUser reads the "{id}" URL param and checks the user exists
+func (h *Handler) targetUser(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return 0, false
+	}
+
+	_, err := h.queries.GetUserById(r.Context(), int32(userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "user not found")
+		return 0, false
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch user")
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// userIDParam reads the "{id}" URL param
+func userIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || userID < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid user id")
+		return 0, false
+	}
+	return userID, true
+}
+
+// nonNil keeps empty lists as "[]" rather than "null"
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
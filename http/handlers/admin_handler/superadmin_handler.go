@@ -0,0 +1,72 @@
+package adminhandler
+
+import (
+	"net/http"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/http/response"
+	"server/sql/database"
+
+	"go.uber.org/zap"
+)
+
+// GrantAdmin gives the admin role to the user "{id}", a no-op if they already hold it
+func (h *Handler) GrantAdmin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	admin, err := h.queries.GetRoleByName(r.Context(), helper.AdminRole)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch role")
+		return
+	}
+
+	changed, err := h.queries.AssignUserRole(r.Context(), database.AssignUserRoleParams{UserID: userID, RoleID: admin.ID})
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot grant admin")
+		return
+	}
+
+	h.adminChanged(w, r, userID, changed > 0, true)
+}
+
+// RevokeAdmin takes the admin role away from the user "{id}", a no-op if they don't hold it
+func (h *Handler) RevokeAdmin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	admin, err := h.queries.GetRoleByName(r.Context(), helper.AdminRole)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch role")
+		return
+	}
+
+	changed, err := h.queries.RemoveUserRole(r.Context(), database.RemoveUserRoleParams{UserID: userID, RoleID: admin.ID})
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot revoke admin")
+		return
+	}
+
+	h.adminChanged(w, r, userID, changed > 0, false)
+}
+
+// adminChanged answers with the roles of the user, expiring their access tokens if the role actually changed
+func (h *Handler) adminChanged(w http.ResponseWriter, r *http.Request, userID int64, changed bool, granted bool) {
+	if !changed {
+		h.respondWithUserRoles(w, r, userID)
+		return
+	}
+
+	verb := "revoked"
+	if granted {
+		verb = "granted"
+	}
+	caller, _ := middleware.GetUserFromContext(r.Context())
+	h.logger.Info("admin role "+verb, zap.Int64("user_id", userID), zap.Int64("by", caller.ID))
+	h.rolesChanged(w, r, userID, helper.AdminRole, granted)
+}
@@ -0,0 +1,218 @@
+package adminhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"server/http/helper"
+	"server/http/request"
+	"server/http/response"
+	"server/money"
+	"server/sql/database"
+	"server/tax"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListTaxRegimes returns every tax regime, only those of "country" if set
+func (h *Handler) ListTaxRegimes(w http.ResponseWriter, r *http.Request) {
+	var country *string
+	if c := r.URL.Query().Get("country"); c != "" {
+		c = tax.NormalizeCountry(c)
+		country = &c
+	}
+
+	rows, err := h.queries.ListTaxRegimes(r.Context(), country)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch tax regimes")
+		return
+	}
+
+	regimes := make([]*tax.Regime, 0, len(rows))
+	for _, row := range rows {
+		regime, err := tax.FromDB(row)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot read tax regime")
+			return
+		}
+		regimes = append(regimes, regime)
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, regimes)
+}
+
+// GetTaxRegime returns the tax regime "{id}"
+func (h *Handler) GetTaxRegime(w http.ResponseWriter, r *http.Request) {
+	regime, ok := h.targetTaxRegime(w, r)
+	if !ok {
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, regime)
+}
+
+// CalculateTax previews the deductions of the tax regime "{id}" on a yearly "salary"
+func (h *Handler) CalculateTax(w http.ResponseWriter, r *http.Request) {
+	regime, ok := h.targetTaxRegime(w, r)
+	if !ok {
+		return
+	}
+
+	salary, err := money.ParseAmount(r.URL.Query().Get("salary"))
+	if err != nil || salary.Sign() < 0 {
+		response.RespondeWithError(w, http.StatusBadRequest, "salary must be a positive number")
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, regime.Calculate(salary))
+}
+
+// CreateTaxRegime adds the rules of a country for a tax year
+func (h *Handler) CreateTaxRegime(w http.ResponseWriter, r *http.Request) {
+	var regime tax.Regime
+	if !request.DecodeJSON(w, r, &regime) {
+		return
+	}
+	regime.Country = tax.NormalizeCountry(regime.Country)
+	regime.Currency = money.NormalizeCurrency(regime.Currency)
+
+	arg, ok := taxRegimeColumns(w, &regime)
+	if !ok {
+		return
+	}
+
+	row, err := h.queries.CreateTaxRegime(r.Context(), database.CreateTaxRegimeParams{
+		Country:           regime.Country,
+		TaxYear:           regime.TaxYear,
+		StandardAllowance: arg.StandardAllowance,
+		Brackets:          arg.Brackets,
+		Contributions:     arg.Contributions,
+		Currency:          regime.Currency,
+	})
+	if helper.IsUniqueViolation(err) {
+		response.RespondeWithError(w, http.StatusConflict, fmt.Sprintf("%s already has a tax regime for %d", regime.Country, regime.TaxYear))
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot create tax regime")
+		return
+	}
+	regime.ID = row.ID
+	h.auditLog.Log(r, "tax_regime.create", "tax_regime", strconv.Itoa(int(row.ID)), nil, regime)
+
+	response.RespondeWithJSON(w, http.StatusCreated, regime)
+}
+
+// UpdateTaxRegime replaces the allowance, brackets and contributions of the tax regime "{id}",
+// the country, tax year and currency stay
+func (h *Handler) UpdateTaxRegime(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.targetTaxRegime(w, r)
+	if !ok {
+		return
+	}
+
+	var regime tax.Regime
+	if !request.DecodeJSON(w, r, &regime) {
+		return
+	}
+	regime.ID, regime.Country, regime.TaxYear, regime.Currency = before.ID, before.Country, before.TaxYear, before.Currency
+
+	arg, ok := taxRegimeColumns(w, &regime)
+	if !ok {
+		return
+	}
+	arg.ID = before.ID
+
+	_, err := h.queries.UpdateTaxRegime(r.Context(), arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "tax regime not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot update tax regime")
+		return
+	}
+	h.auditLog.Log(r, "tax_regime.update", "tax_regime", strconv.Itoa(int(regime.ID)), before, regime)
+
+	response.RespondeWithJSON(w, http.StatusOK, regime)
+}
+
+// DeleteTaxRegime removes the tax regime "{id}", its country falls back to the previous tax year
+func (h *Handler) DeleteTaxRegime(w http.ResponseWriter, r *http.Request) {
+	regime, ok := h.targetTaxRegime(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.queries.DeleteTaxRegime(r.Context(), regime.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "tax regime not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot delete tax regime")
+		return
+	}
+	h.auditLog.Log(r, "tax_regime.delete", "tax_regime", strconv.Itoa(int(regime.ID)), regime, nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, regime)
+}
+
+// taxRegimeColumns validates the regime and encodes it the way "tax_regimes" stores it
+func taxRegimeColumns(w http.ResponseWriter, regime *tax.Regime) (database.UpdateTaxRegimeParams, bool) {
+	if regime.Contributions == nil {
+		regime.Contributions = []tax.Contribution{}
+	}
+	if err := regime.Validate(); err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return database.UpdateTaxRegimeParams{}, false
+	}
+
+	regime.StandardAllowance = regime.StandardAllowance.Round(regime.Currency)
+	brackets, err := json.Marshal(regime.Brackets)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot encode tax regime")
+		return database.UpdateTaxRegimeParams{}, false
+	}
+	contributions, err := json.Marshal(regime.Contributions)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot encode tax regime")
+		return database.UpdateTaxRegimeParams{}, false
+	}
+
+	return database.UpdateTaxRegimeParams{
+		StandardAllowance: regime.StandardAllowance.Numeric(),
+		Brackets:          brackets,
+		Contributions:     contributions,
+	}, true
+}
+
+// targetTaxRegime reads the "{id}" URL param and fetches the tax regime
+func (h *Handler) targetTaxRegime(w http.ResponseWriter, r *http.Request) (*tax.Regime, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid tax regime id")
+		return nil, false
+	}
+
+	row, err := h.queries.GetTaxRegimeById(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "tax regime not found")
+		return nil, false
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch tax regime")
+		return nil, false
+	}
+
+	regime, err := tax.FromDB(row)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot read tax regime")
+		return nil, false
+	}
+	return regime, true
+}
@@ -0,0 +1,32 @@
+package adminhandler
+
+import (
+	"net/http"
+	"strconv"
+
+	"server/http/helper"
+	"server/http/response"
+
+	"go.uber.org/zap"
+)
+
+// RevokeUserTokens logs the user "{id}" out everywhere, e.g. for a stolen laptop
+func (h *Handler) RevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := helper.RevokeAllTokens(r.Context(), h.queries, userID, h.config.AccessTokenTTL)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot revoke tokens")
+		return
+	}
+
+	h.logger.Info("revoked every token of user", zap.Int64("user_id", userID))
+	h.auditLog.Log(r, "user.revoke_tokens", "user", strconv.FormatInt(userID, 10), nil, nil)
+	response.RespondeWithJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  true,
+		"message": "All tokens of the user revoked",
+	})
+}
@@ -0,0 +1,183 @@
+package adminhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// DeletedUser is a user deleted or restored along with their employee profile, if they had one
+type DeletedUser struct {
+	ID         int32      `json:"id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"` // only while deleted, restorable until the retention job
+	EmployeeID *int32     `json:"employee_id,omitempty"`
+}
+
+// DeleteUser deletes the user "{id}" and their employee profile and logs them out everywhere.
+// Both stay restorable until the retention job purges or anonymizes them.
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+	if caller, _ := middleware.GetUserFromContext(r.Context()); caller.ID == userID {
+		response.RespondeWithError(w, http.StatusConflict, "cannot delete yourself")
+		return
+	}
+	if !h.mayManageUser(w, r, userID) {
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	var user *database.User
+	var emp *database.Employee
+	err := db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		if user, err = q.DeleteUserById(r.Context(), int32(userID)); err != nil {
+			return err
+		}
+		emp, err = deleteUserEmployee(r.Context(), q, userID)
+		return err
+	})
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot delete user")
+		return
+	}
+
+	if err := helper.RevokeAllTokens(r.Context(), h.queries, userID, h.config.AccessTokenTTL); err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot revoke tokens")
+		return
+	}
+
+	resp := deletedUserJson(user, emp)
+	h.logger.Info("user deleted", zap.Int64("user_id", userID))
+	h.auditLog.Log(r, "user.delete", "user", strconv.FormatInt(userID, 10), nil, map[string]any{"employee_id": resp.EmployeeID})
+	response.RespondeWithJSON(w, http.StatusOK, resp)
+}
+
+// deleteUserEmployee deletes the live employee profile of a user, nil when there is none
+func deleteUserEmployee(ctx context.Context, q database.Querier, userID int64) (*database.Employee, error) {
+	emp, err := q.GetEmployeByuserById(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q.DeleteEmployeeByUserId(ctx, database.DeleteEmployeeByUserIdParams{UserID: userID, Version: emp.Version})
+}
+
+// RestoreUser brings the deleted user "{id}" back, along with the employee profile deleted with
+// them. A user anonymized by the retention job has nothing left to restore.
+func (h *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.queries.GetDeletedUserById(r.Context(), int32(userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "no deleted user with this id")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch user")
+		return
+	}
+	if deleted.AnonymizedAt.Valid {
+		response.RespondeWithError(w, http.StatusConflict, "the user was anonymized by the retention job, nothing is left to restore")
+		return
+	}
+	if !h.mayManageUser(w, r, userID) {
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	var user *database.User
+	var emp *database.Employee
+	err = db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		if user, err = q.RestoreUserById(r.Context(), int32(userID)); err != nil {
+			return err
+		}
+		emp, err = q.RestoreEmployeeByUserId(r.Context(), database.RestoreEmployeeByUserIdParams{
+			UserID:       userID,
+			DeletedSince: deleted.DeletedAt,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			emp, err = nil, nil
+		}
+		return err
+	})
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot restore user")
+		return
+	}
+
+	resp := deletedUserJson(user, emp)
+	h.logger.Info("user restored", zap.Int64("user_id", userID))
+	h.auditLog.Log(r, "user.restore", "user", strconv.FormatInt(userID, 10), nil, map[string]any{"employee_id": resp.EmployeeID})
+	response.RespondeWithJSON(w, http.StatusOK, resp)
+}
+
+// mayManageUser checks the caller holds every permission of the user they delete or restore,
+// an admin can't delete a superadmin
+func (h *Handler) mayManageUser(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	permissions, err := h.queries.ListUserPermissions(r.Context(), userID)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot fetch roles")
+		return false
+	}
+
+	caller, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return false
+	}
+	for _, permission := range permissions {
+		if !caller.HasPermission(permission) {
+			response.RespondeWithError(w, http.StatusForbidden, "cannot manage a user holding "+permission)
+			return false
+		}
+	}
+	return true
+}
+
+func deletedUserJson(user *database.User, emp *database.Employee) DeletedUser {
+	resp := DeletedUser{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.Time,
+	}
+	if user.DeletedAt.Valid {
+		resp.DeletedAt = &user.DeletedAt.Time
+	}
+	if emp != nil {
+		resp.EmployeeID = &emp.ID
+	}
+	return resp
+}
@@ -0,0 +1,115 @@
+package adminhandler
+
+import (
+	"fmt"
+
+	"server/fx"
+	"server/payroll"
+	"server/sheet"
+)
+
+var payrollRunColumns = []string{"ID", "Frequency", "Period start", "Period end", "Pay date", "Status", "Created at", "Locked at", "Reversed at", "Reversal reason"}
+
+func payrollRunRow(run payroll.Run) []any {
+	row := []any{
+		run.ID,
+		run.Frequency,
+		sheet.Date(run.PeriodStart),
+		sheet.Date(run.PeriodEnd),
+		sheet.Date(run.PayDate),
+		run.Status,
+		run.CreatedAt,
+		nil,
+		nil,
+		run.ReversalReason,
+	}
+	if run.LockedAt != nil {
+		row[7] = *run.LockedAt
+	}
+	if run.ReversedAt != nil {
+		row[8] = *run.ReversedAt
+	}
+	return row
+}
+
+// payrollRunsWorkbook lists the runs on one sheet
+func payrollRunsWorkbook(runs []payroll.Run) (*sheet.Workbook, error) {
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]any, 0, len(runs))
+	for _, run := range runs {
+		rows = append(rows, payrollRunRow(run))
+	}
+	return book, book.AddSheet("Payroll runs", payrollRunColumns, rows)
+}
+
+// workbook is the run, its totals and a sheet of payslips per currency paid
+func (run PayrollRun) workbook() (*sheet.Workbook, error) {
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	if err := book.AddSheet("Run", payrollRunColumns, [][]any{payrollRunRow(run.Run)}); err != nil {
+		return nil, err
+	}
+
+	totals := make([][]any, 0, len(run.Totals))
+	for _, t := range run.Totals {
+		totals = append(totals, []any{
+			t.Currency,
+			t.Payslips,
+			sheet.Money{Amount: t.GrossPay, Currency: t.Currency},
+			sheet.Money{Amount: t.TotalDeductions, Currency: t.Currency},
+			sheet.Money{Amount: t.NetPay, Currency: t.Currency},
+		})
+	}
+	err = book.AddSheet("Totals", []string{"Currency", "Payslips", "Gross pay", "Total deductions", "Net pay"}, totals)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{"Payslip ID", "Employee ID", "User ID", "Job title", "Country", "Tax year", "Annual salary",
+		"Exchange rate", "Gross pay", "Income tax", "Contributions", "Total deductions", "Net pay"}
+	for _, t := range run.Totals {
+		var rows [][]any
+		for _, p := range run.Payslips {
+			if p.Currency != t.Currency {
+				continue
+			}
+			rows = append(rows, []any{
+				p.ID,
+				p.EmployeeID,
+				p.UserID,
+				p.JobTitle,
+				p.Country,
+				p.TaxYear,
+				sheet.Money{Amount: p.AnnualSalary, Currency: p.SalaryCurrency},
+				p.ExchangeRate,
+				sheet.Money{Amount: p.GrossPay, Currency: p.Currency},
+				sheet.Money{Amount: p.IncomeTax, Currency: p.Currency},
+				sheet.Money{Amount: p.Contributions, Currency: p.Currency},
+				sheet.Money{Amount: p.TotalDeductions, Currency: p.Currency},
+				sheet.Money{Amount: p.NetPay, Currency: p.Currency},
+			})
+		}
+		if err := book.AddSheet(fmt.Sprintf("Payslips %s", t.Currency), header, rows); err != nil {
+			return nil, err
+		}
+	}
+	return book, nil
+}
+
+// fxRatesWorkbook lists the rates on one sheet
+func fxRatesWorkbook(rates []fx.ExchangeRate) (*sheet.Workbook, error) {
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]any, 0, len(rates))
+	for _, rate := range rates {
+		rows = append(rows, []any{sheet.Date(rate.Date), rate.Base, rate.Quote, rate.Rate, rate.Source})
+	}
+	return book, book.AddSheet("Exchange rates", []string{"Date", "Base currency", "Quote currency", "Rate", "Source"}, rows)
+}
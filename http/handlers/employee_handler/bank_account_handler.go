@@ -0,0 +1,108 @@
+package employeehandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/bank"
+	"server/http/middleware"
+	"server/http/request"
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BankAccount is the account the logged in user's net pay is transferred to
+type BankAccount struct {
+	bank.Account
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func dbBankAccountToJson(row *database.BankAccount) BankAccount {
+	return BankAccount{Account: bank.AccountFromDB(row), UpdatedAt: row.UpdatedAt.Time}
+}
+
+// GetBankAccount returns the bank account of the logged in user
+func (h *Handler) GetBankAccount(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	row, err := h.queries.GetUserBankAccount(r.Context(), userInfo.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "no bank account registered")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "Couldnot fetch bank account")
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, dbBankAccountToJson(row))
+}
+
+// PutBankAccount registers the bank account of the logged in user, replacing the one before.
+// An "iban" (and "bic") is paid by SEPA transfer, a "routing_number" and "account_number"
+// by ACH, both are checked against their check digits.
+func (h *Handler) PutBankAccount(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	var body bank.Account
+	if !request.DecodeJSON(w, r, &body) {
+		return
+	}
+	account, err := body.Normalize()
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	var before any
+	if old, err := h.queries.GetUserBankAccount(r.Context(), userInfo.ID); err == nil {
+		before = bank.AccountFromDB(old).Masked()
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusInternalServerError, "Couldnot fetch bank account")
+		return
+	}
+
+	row, err := h.queries.UpsertBankAccount(r.Context(), account.Params(userInfo.ID))
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "Couldnot save bank account")
+		return
+	}
+	// Only the last 4 digits of the account go to the log
+	h.auditLog.Log(r, "bank_account.update", "bank_account", strconv.FormatInt(row.ID, 10), before, bank.AccountFromDB(row).Masked())
+
+	response.RespondeWithJSON(w, http.StatusOK, dbBankAccountToJson(row))
+}
+
+// DeleteBankAccount removes the bank account of the logged in user
+func (h *Handler) DeleteBankAccount(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	row, err := h.queries.DeleteUserBankAccount(r.Context(), userInfo.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "no bank account registered")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "Couldnot delete bank account")
+		return
+	}
+	h.auditLog.Log(r, "bank_account.delete", "bank_account", strconv.FormatInt(row.ID, 10), bank.AccountFromDB(row).Masked(), nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, dbBankAccountToJson(row))
+}
@@ -0,0 +1,86 @@
+package employeehandler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// listCursor is the keyset position of the last row of a page,
+// handed to the client as an opaque base64 string
+type listCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d"`
+	Value  string `json:"v"`
+	ID     int32  `json:"id"`
+}
+
+func encodeCursor(last *database.Employee, sortBy string, desc bool) string {
+	cursor := listCursor{SortBy: sortBy, Desc: desc, ID: last.ID}
+
+	switch sortBy {
+	case "user_id":
+		cursor.Value = strconv.FormatInt(last.UserID, 10)
+	case "job_title":
+		cursor.Value = last.JobTitle
+	case "country":
+		cursor.Value = last.Country
+	case "salary":
+		value, _ := last.Salary.Value()
+		cursor.Value, _ = value.(string)
+	case "created_at":
+		cursor.Value = last.CreatedAt.Time.Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor fills the keyset params, the cursor must come from the same sort order
+func decodeCursor(encoded string, params *database.ListEmployeesParams) error {
+	invalid := fmt.Errorf("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return invalid
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return invalid
+	}
+	if cursor.SortBy != params.SortBy || cursor.Desc != params.SortDesc {
+		return fmt.Errorf("cursor does not match the requested sort")
+	}
+
+	params.CursorID = &cursor.ID
+	switch cursor.SortBy {
+	case "user_id":
+		userID, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return invalid
+		}
+		params.CursorInt = &userID
+	case "job_title", "country":
+		params.CursorText = &cursor.Value
+	case "salary":
+		var salary pgtype.Numeric
+		if err := salary.Scan(cursor.Value); err != nil {
+			return invalid
+		}
+		params.CursorSalary = salary
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return invalid
+		}
+		params.CursorTime = pgtype.Timestamp{Time: createdAt, Valid: true}
+	}
+
+	return nil
+}
@@ -0,0 +1,364 @@
+package employeehandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server/http/middleware"
+	"server/http/request"
+	"server/http/response"
+	"server/money"
+	"server/sql/database"
+	"server/tax"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Columns the list can be sorted on, "-" prefix flips to descending
+var sortableColumns = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"job_title":  true,
+	"country":    true,
+	"salary":     true,
+	"created_at": true,
+}
+
+// canReadSalaries tells whether the caller may see the salaries of any employee, "employees:read"
+// alone shows the records without them
+func canReadSalaries(r *http.Request) bool {
+	caller, ok := middleware.GetUserFromContext(r.Context())
+	return ok && caller.HasPermission("salaries:read")
+}
+
+// adminEmployeeJson is an employee as the admin routes answer it, the salary and its currency
+// only for a caller with "salaries:read"
+func adminEmployeeJson(r *http.Request, dbEmp *database.Employee) Employee {
+	emp := dbEmployeeToEmpJson(dbEmp)
+	if !canReadSalaries(r) {
+		emp.Salary, emp.Currency = nil, ""
+	}
+	return emp
+}
+
+// listParamsAllowed answers 403 when the filters or the sort of the list would give the salaries
+// away to a caller without "salaries:read"
+func listParamsAllowed(w http.ResponseWriter, r *http.Request, params database.ListEmployeesParams) bool {
+	bySalary := params.MinSalary.Valid || params.MaxSalary.Valid || params.Currency != nil || params.SortBy == "salary"
+	if bySalary && !canReadSalaries(r) {
+		response.RespondeWithError(w, http.StatusForbidden, "filtering or sorting by salary or currency needs salaries:read")
+		return false
+	}
+	return true
+}
+
+// Admin Route
+// ListEmployees returns one page of employees, filtered by "country", "job_title", "currency",
+// "min_salary", "max_salary", the deleted ones instead with "deleted=true", ordered by "sort" (e.g. "-salary")
+// and continued with the "next_cursor" of the previous page passed as "cursor". Asked for XLSX,
+// it is a spreadsheet of every employee matching the filters, a sheet per salary currency.
+// Without "salaries:read" the salaries are left out and can't be filtered or sorted on.
+func (h *Handler) ListEmployees(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !listParamsAllowed(w, r, params) {
+		return
+	}
+	if response.WantsXLSX(w, r) {
+		h.listEmployeesXLSX(w, r, params)
+		return
+	}
+	pageSize := params.PageSize
+
+	// Fetch one extra row to know whether there is a next page
+	params.PageSize++
+	emps, err := h.queries.ListEmployees(r.Context(), params)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot list Employees")
+		return
+	}
+
+	page := EmployeePage{Employees: make([]Employee, 0, len(emps))}
+	if len(emps) > int(pageSize) {
+		emps = emps[:pageSize]
+		page.NextCursor = encodeCursor(emps[len(emps)-1], params.SortBy, params.SortDesc)
+	}
+	for _, emp := range emps {
+		page.Employees = append(page.Employees, adminEmployeeJson(r, emp))
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, page)
+}
+
+// listEmployeesXLSX answers ListEmployees with a spreadsheet, "limit" is ignored
+func (h *Handler) listEmployeesXLSX(w http.ResponseWriter, r *http.Request, params database.ListEmployeesParams) {
+	var emps []*database.Employee
+	err := h.eachEmployeePage(r.Context(), params, func(page []*database.Employee) error {
+		emps = append(emps, page...)
+		return nil
+	})
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot list Employees")
+		return
+	}
+
+	book, err := employeesWorkbook(emps, canReadSalaries(r))
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+		return
+	}
+	response.RespondeWithXLSX(w, "employees.xlsx", book)
+}
+
+// eachEmployeePage hands every employee matching "params" to fn, a page of exportPageSize
+// at a time in the order of the list
+func (h *Handler) eachEmployeePage(ctx context.Context, params database.ListEmployeesParams, fn func([]*database.Employee) error) error {
+	params.PageSize = exportPageSize
+	for {
+		emps, err := h.queries.ListEmployees(ctx, params)
+		if err != nil {
+			return err
+		}
+		if err := fn(emps); err != nil || len(emps) < exportPageSize {
+			return err
+		}
+		if err := decodeCursor(encodeCursor(emps[len(emps)-1], params.SortBy, params.SortDesc), &params); err != nil {
+			return err
+		}
+	}
+}
+
+// GetEmployeeByID answers 304 when "If-None-Match" names the current version
+func (h *Handler) GetEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	emp, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+	if request.NotModified(w, r, request.ETag(emp.Version)) {
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, adminEmployeeJson(r, emp))
+}
+
+// UpdateEmployeeByID replaces an employee, with "If-Match" only the version it names.
+// Without "salaries:read" the salary and currency of the body are ignored, the stored ones are kept.
+func (h *Handler) UpdateEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	if _, err := employeeIDParam(r); err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var reqBody EmpBody
+
+	if !request.DecodeJSON(w, r, &reqBody) {
+		return
+	}
+
+	// Current state, for the version and the audit log
+	before, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
+		return
+	}
+	if !canReadSalaries(r) {
+		keepStoredSalary(&reqBody, before)
+	}
+
+	h.updateEmployee(w, r, before, reqBody, true)
+}
+
+// PatchEmployeeByID changes some fields of an employee, with a JSON Merge Patch or a JSON Patch,
+// with "If-Match" only the version it names. Without "salaries:read" the patch applies to the
+// employee without its salary (a "test" can't probe it) and the stored salary is kept.
+func (h *Handler) PatchEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
+		return
+	}
+
+	hidden := !canReadSalaries(r)
+	reqBody := empBodyOf(before)
+	if hidden {
+		reqBody.Salary, reqBody.Currency = money.Amount{}, ""
+	}
+	if !request.DecodePatch(w, r, &reqBody) {
+		return
+	}
+	if hidden {
+		keepStoredSalary(&reqBody, before)
+	}
+
+	h.updateEmployee(w, r, before, reqBody, true)
+}
+
+// keepStoredSalary puts the salary of "dbEmp" back in "body", a caller who can't read it can't change it
+func keepStoredSalary(body *EmpBody, dbEmp *database.Employee) {
+	stored := empBodyOf(dbEmp)
+	body.Salary, body.Currency = stored.Salary, stored.Currency
+}
+
+// DeleteEmployeeByID deletes an employee, with "If-Match" only the version it names. It can be
+// restored until the retention job purges it.
+func (h *Handler) DeleteEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
+		return
+	}
+
+	emp, err := h.queries.DeleteEmployeeById(r.Context(), database.DeleteEmployeeByIdParams{
+		ID:      before.ID,
+		Version: before.Version,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithCode(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "the employee was changed meanwhile, fetch it again")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot Delete Employee")
+		return
+	}
+	h.auditLog.Log(r, "employee.delete", "employee", strconv.Itoa(int(emp.ID)), dbEmployeeToEmpJson(emp), nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, adminEmployeeJson(r, emp))
+}
+
+// RestoreEmployeeByID brings a deleted employee back, unless its user is deleted too or has
+// started another employee profile meanwhile
+func (h *Handler) RestoreEmployeeByID(w http.ResponseWriter, r *http.Request) {
+	id, err := employeeIDParam(r)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleted, err := h.queries.GetDeletedEmployeeById(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "no deleted employee with this id")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+
+	_, err = h.queries.GetUserById(r.Context(), int32(deleted.UserID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusConflict, "the user of the employee is deleted, restore the user first")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch User")
+		return
+	}
+
+	emp, err := h.queries.RestoreEmployeeById(r.Context(), id)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot restore Employee")
+		return
+	}
+	h.auditLog.Log(r, "employee.restore", "employee", strconv.Itoa(int(emp.ID)), nil, dbEmployeeToEmpJson(emp))
+
+	w.Header().Set("ETag", request.ETag(emp.Version))
+	response.RespondeWithJSON(w, http.StatusOK, adminEmployeeJson(r, emp))
+}
+
+// employeeIDParam extracts "{id}" from the route
+func employeeIDParam(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid employee id")
+	}
+	return int32(id), nil
+}
+
+// parseListParams turns the query string into ListEmployeesParams
+func parseListParams(r *http.Request) (database.ListEmployeesParams, error) {
+	query := r.URL.Query()
+	params := database.ListEmployeesParams{
+		SortBy:   "id",
+		PageSize: defaultPageSize,
+	}
+
+	if country := tax.NormalizeCountry(query.Get("country")); country != "" {
+		params.Country = &country
+	}
+	if jobTitle := query.Get("job_title"); jobTitle != "" {
+		params.JobTitle = &jobTitle
+	}
+	if currency := money.NormalizeCurrency(query.Get("currency")); currency != "" {
+		params.Currency = &currency
+	}
+
+	var err error
+	if params.MinSalary, err = salaryParam(query.Get("min_salary")); err != nil {
+		return params, fmt.Errorf("invalid min_salary")
+	}
+	if params.MaxSalary, err = salaryParam(query.Get("max_salary")); err != nil {
+		return params, fmt.Errorf("invalid max_salary")
+	}
+
+	if deleted := query.Get("deleted"); deleted != "" {
+		if params.Deleted, err = strconv.ParseBool(deleted); err != nil {
+			return params, fmt.Errorf("deleted must be true or false")
+		}
+	}
+
+	if sortBy := query.Get("sort"); sortBy != "" {
+		params.SortDesc = strings.HasPrefix(sortBy, "-")
+		params.SortBy = strings.TrimPrefix(sortBy, "-")
+		if !sortableColumns[params.SortBy] {
+			return params, fmt.Errorf("cannot sort by %q", params.SortBy)
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return params, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		params.PageSize = int32(pageSize)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if err := decodeCursor(cursor, &params); err != nil {
+			return params, err
+		}
+	}
+
+	return params, nil
+}
+
+// salaryParam parses an optional salary filter, empty means no filter (NULL)
+func salaryParam(value string) (pgtype.Numeric, error) {
+	if value == "" {
+		return pgtype.Numeric{}, nil
+	}
+	salary, err := money.ParseAmount(value)
+	if err != nil {
+		return pgtype.Numeric{}, err
+	}
+	return salary.Numeric(), nil
+}
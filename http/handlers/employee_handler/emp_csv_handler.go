@@ -0,0 +1,140 @@
+package employeehandler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"server/http/middleware"
+	"server/http/response"
+	"server/sql/database"
+	"server/staff"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// A line is about 100 bytes, room for tens of thousands of employees
+	maxEmployeeImportSize = 8 << 20
+
+	// Every password is hashed with bcrypt before the transaction starts, about a second each
+	// spread over the CPUs, the server's write timeout is too short for a big file
+	importWriteTimeout = 15 * time.Minute
+
+	// Employees fetched per query of an export or a spreadsheet
+	exportPageSize = 500
+)
+
+// Admin Route
+// ImportEmployees creates a user and an employee for every line of a CSV (text/csv) with a
+// "username,email,job_title,country,salary,currency" header and an optional "password" column,
+// a user without password gets a generated one returned once in the result. Every line is
+// checked and either every employee is created, in one transaction, or none is and the result
+// lists the errors of every line (422). "dry_run=true" checks and writes everything but commits
+// nothing.
+func (h *Handler) ImportEmployees(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "text/csv" {
+		response.RespondeWithError(w, http.StatusUnsupportedMediaType, "send the employees as text/csv")
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	rows, invalid, err := staff.ParseCSV(http.MaxBytesReader(w, r.Body, maxEmployeeImportSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		response.RespondeWithError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(importWriteTimeout)); err != nil {
+		h.logger.Warn("couldnot extend the write deadline of an import", zap.Error(err))
+	}
+
+	opts := staff.Options{DryRun: dryRun}
+	if caller, ok := middleware.GetUserFromContext(r.Context()); ok {
+		opts.ApprovedBy = &caller.ID
+	}
+	result, err := staff.Import(r.Context(), db, rows, invalid, opts)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot import Employees")
+		return
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		response.RespondeWithJSON(w, http.StatusUnprocessableEntity, result)
+	case result.DryRun:
+		response.RespondeWithJSON(w, http.StatusOK, result)
+	default:
+		// The generated passwords stay out of the log
+		ids := make([]int32, 0, len(result.Imported))
+		for _, imported := range result.Imported {
+			ids = append(ids, imported.EmployeeID)
+		}
+		h.auditLog.Log(r, "employee.import", "employee", "", nil, map[string]any{
+			"rows":         result.Rows,
+			"employee_ids": ids,
+		})
+		response.RespondeWithJSON(w, http.StatusCreated, result)
+	}
+}
+
+// Admin Route
+// ExportEmployees streams every employee matching the filters of ListEmployees as CSV, in the
+// order of "sort" and starting after "cursor" when given, "limit" is ignored. The file imports
+// again with ImportEmployees, unless the caller lacks "salaries:read": it has no salary columns then.
+func (h *Handler) ExportEmployees(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !listParamsAllowed(w, r, params) {
+		return
+	}
+	salaries := canReadSalaries(r)
+
+	// Until the first line is sent, a failure can still be answered
+	var exporter *staff.Exporter
+	started := false
+	err = h.eachEmployeePage(r.Context(), params, func(emps []*database.Employee) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="employees.csv"`)
+			w.WriteHeader(http.StatusOK)
+
+			var err error
+			if exporter, err = staff.NewExporter(w, h.queries, salaries); err != nil {
+				return err
+			}
+		}
+		return exporter.Write(r.Context(), emps)
+	})
+	if err != nil && !started {
+		response.RespondeWithFailure(w, err, "Couldnot list Employees")
+		return
+	}
+	if err != nil {
+		h.logger.Error("employees export cut short", zap.Error(err))
+	}
+}
@@ -1,26 +1,32 @@
 package employeehandler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"server/fx"
 	"server/http/helper"
 	"server/http/middleware"
+	"server/http/request"
 	"server/http/response"
+	"server/money"
 	"server/sql/database"
+	"server/tax"
 
-	db "server/init"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func CreateEmp(w http.ResponseWriter, r *http.Request) {
+// errEmployeeChanged stops an update that lost the race for the version it read
+var errEmployeeChanged = errors.New("the employee was changed meanwhile")
+
+func (h *Handler) CreateEmp(w http.ResponseWriter, r *http.Request) {
 	var reqBody EmpBody
 
-	// Decode the request body into the struct
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&reqBody)
-	if err != nil {
-		response.RespondeWithError(w, http.StatusUnprocessableEntity, "invalid json")
+	if !request.DecodeJSON(w, r, &reqBody) {
 		return
 	}
 
@@ -31,10 +37,15 @@ func CreateEmp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract Salary to pgtype.Numeric
-	salaryNumeric, err := helper.FloatToNumeric(reqBody.Salary, 2)
+	currency, err := salaryCurrency(reqBody.Currency, "")
 	if err != nil {
-		response.RespondeWithError(w, http.StatusUnprocessableEntity, "invalid json")
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
 		return
 	}
 
@@ -43,30 +54,64 @@ func CreateEmp(w http.ResponseWriter, r *http.Request) {
 		UserID:   userInfo.ID,
 		JobTitle: reqBody.JobTitle,
 		Country:  reqBody.Country,
-		Salary:   salaryNumeric,
+		Salary:   reqBody.Salary.Round(currency).Numeric(),
+		Currency: currency,
 	}
 
-	// Create Employee Query Call
-	empCreated, err := db.Queries.CreateEmployee(r.Context(), createEmp)
+	// The employee comes with the start of their salary history or not at all,
+	// payroll only pays the employees with one
+	var empCreated *database.Employee
+	var hire *database.SalaryHistory
+	err = db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		if empCreated, err = q.CreateEmployee(r.Context(), createEmp); err != nil {
+			return err
+		}
+		hire, err = recordSalaryChange(r, q, empCreated.ID, empCreated.Salary, empCreated.Currency, helper.Today(), "hire", "")
+		return err
+	})
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot create Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot create Employee")
 		return
 	}
+	h.auditLog.Log(r, "employee.create", "employee", strconv.Itoa(int(empCreated.ID)), nil, dbEmployeeToEmpJson(empCreated))
+	h.logSalaryChange(r, hire)
 
+	w.Header().Set("ETag", request.ETag(empCreated.Version))
 	response.RespondeWithJSON(w, http.StatusCreated, dbEmployeeToEmpJson(empCreated))
 }
 
-func UpdateEmp(w http.ResponseWriter, r *http.Request) {
+// UpdateEmp replaces the employee of the logged in user, with "If-Match" only the version it names
+func (h *Handler) UpdateEmp(w http.ResponseWriter, r *http.Request) {
 	var reqBody EmpBody
 
-	// Decode the request body into the struct
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&reqBody)
+	if !request.DecodeJSON(w, r, &reqBody) {
+		return
+	}
+
+	// Extract UserInfo req content
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	// Current state, for the version and the audit log
+	before, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusUnprocessableEntity, "invalid json")
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
 		return
 	}
 
+	h.updateEmployee(w, r, before, reqBody, false)
+}
+
+// PatchEmp changes some fields of the employee of the logged in user, with a JSON Merge Patch
+// or a JSON Patch, with "If-Match" only the version it names
+func (h *Handler) PatchEmp(w http.ResponseWriter, r *http.Request) {
 	// Extract UserInfo req content
 	userInfo, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
@@ -74,32 +119,100 @@ func UpdateEmp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract Salary to pgtype.Numeric
-	salaryNumeric, err := helper.FloatToNumeric(reqBody.Salary, 2)
+	before, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusUnprocessableEntity, "invalid json")
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
 		return
 	}
 
-	// Create UpdateEmployeeByUserIdParams
-	updateEmp := database.UpdateEmployeeByUserIdParams{
-		UserID:   userInfo.ID,
-		JobTitle: reqBody.JobTitle,
-		Country:  reqBody.Country,
-		Salary:   salaryNumeric,
+	reqBody := empBodyOf(before)
+	if !request.DecodePatch(w, r, &reqBody) {
+		return
+	}
+
+	h.updateEmployee(w, r, before, reqBody, false)
+}
+
+// updateEmployee stores "reqBody" over "before", provided nobody changed the employee since it
+// was read: a lost race is 412. A new salary goes through the salary history, only on an admin
+// route ("asAdmin") and for "salaries:write": nobody approves their own raise. On an admin route
+// the salary is answered to "salaries:read" only.
+func (h *Handler) updateEmployee(w http.ResponseWriter, r *http.Request, before *database.Employee, reqBody EmpBody, asAdmin bool) {
+	currency, err := salaryCurrency(reqBody.Currency, before.Currency)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	salaryNumeric := reqBody.Salary.Round(currency).Numeric()
+	salaryChanged := !numericEqual(before.Salary, salaryNumeric) || before.Currency != currency
+
+	caller, _ := middleware.GetUserFromContext(r.Context())
+	if salaryChanged && !asAdmin {
+		response.RespondeWithError(w, http.StatusForbidden, "the salary and currency are changed by an admin with salaries:write")
+		return
+	}
+	if salaryChanged && !caller.HasPermission("salaries:write") {
+		response.RespondeWithError(w, http.StatusForbidden, "changing the salary needs salaries:write")
+		return
 	}
 
-	// Update Employee
-	empCreated, err := db.Queries.UpdateEmployeeByUserId(r.Context(), updateEmp)
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	// The fields and the salary change are saved together or not at all
+	var emp *database.Employee
+	var change *database.SalaryHistory
+	err = db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		emp, err = q.UpdateEmployeeById(r.Context(), database.UpdateEmployeeByIdParams{
+			ID:       before.ID,
+			JobTitle: reqBody.JobTitle,
+			Country:  reqBody.Country,
+			Version:  before.Version,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errEmployeeChanged
+		}
+		if err != nil || !salaryChanged {
+			return err
+		}
+
+		// A new salary goes through the salary history, which bumps the version again
+		if change, err = recordSalaryChange(r, q, emp.ID, salaryNumeric, currency, helper.Today(), "adjustment", ""); err != nil {
+			return err
+		}
+		emp, err = q.GetEmployeeById(r.Context(), emp.ID)
+		return err
+	})
+	if errors.Is(err, errEmployeeChanged) {
+		response.RespondeWithCode(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "the employee was changed meanwhile, fetch it again")
+		return
+	}
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot create Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot update Employee")
 		return
 	}
+	h.auditLog.Log(r, "employee.update", "employee", strconv.Itoa(int(emp.ID)), dbEmployeeToEmpJson(before), dbEmployeeToEmpJson(emp))
+	if change != nil {
+		h.logSalaryChange(r, change)
+	}
 
-	response.RespondeWithJSON(w, http.StatusOK, dbEmployeeToEmpJson(empCreated))
+	w.Header().Set("ETag", request.ETag(emp.Version))
+	if asAdmin {
+		response.RespondeWithJSON(w, http.StatusOK, adminEmployeeJson(r, emp))
+		return
+	}
+	response.RespondeWithJSON(w, http.StatusOK, dbEmployeeToEmpJson(emp))
 }
 
-func GetEmployee(w http.ResponseWriter, r *http.Request) {
+// GetEmployee answers 304 when "If-None-Match" names the current version
+func (h *Handler) GetEmployee(w http.ResponseWriter, r *http.Request) {
 	// Extract UserInfo from context
 	userInfo, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
@@ -107,16 +220,21 @@ func GetEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emp, err := db.Queries.GetEmployeByuserById(r.Context(), userInfo.ID)
+	emp, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot fetch Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+	if request.NotModified(w, r, request.ETag(emp.Version)) {
 		return
 	}
 
 	response.RespondeWithJSON(w, http.StatusOK, dbEmployeeToEmpJson(emp))
 }
 
-func DeleteEmployee(w http.ResponseWriter, r *http.Request) {
+// DeleteEmployee deletes the employee of the logged in user, with "If-Match" only the version it names.
+// An admin can restore it until the retention job purges it.
+func (h *Handler) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 	// Extract UserInfo req content
 	userInfo, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
@@ -124,17 +242,36 @@ func DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+	if !request.CheckIfMatch(w, r, request.ETag(before.Version)) {
+		return
+	}
+
 	// Delete Employee
-	emp, err := db.Queries.DeleteEmployeeByUserId(r.Context(), userInfo.ID)
+	emp, err := h.queries.DeleteEmployeeByUserId(r.Context(), database.DeleteEmployeeByUserIdParams{
+		UserID:  userInfo.ID,
+		Version: before.Version,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithCode(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "the employee was changed meanwhile, fetch it again")
+		return
+	}
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot Delete Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot Delete Employee")
 		return
 	}
+	h.auditLog.Log(r, "employee.delete", "employee", strconv.Itoa(int(emp.ID)), dbEmployeeToEmpJson(emp), nil)
 
 	response.RespondeWithJSON(w, http.StatusOK, dbEmployeeToEmpJson(emp))
 }
 
-func NetSalary(w http.ResponseWriter, r *http.Request) {
+// NetSalary breaks the yearly salary of the logged in user down into every deduction,
+// with the tax regime of their country for "tax_year" (the current year by default)
+func (h *Handler) NetSalary(w http.ResponseWriter, r *http.Request) {
 	// Extract UserInfo from context
 	userInfo, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
@@ -142,60 +279,248 @@ func NetSalary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	taxYear := int32(time.Now().UTC().Year())
+	if year := r.URL.Query().Get("tax_year"); year != "" {
+		parsed, err := strconv.ParseInt(year, 10, 32)
+		if err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "invalid tax_year")
+			return
+		}
+		taxYear = int32(parsed)
+	}
+
 	// Fetch Employee Details from DB
-	emp, err := db.Queries.GetEmployeByuserById(r.Context(), userInfo.ID)
+	emp, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+
+	gross, err := money.AmountFromNumeric(emp.Salary)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot fetch Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot read salary")
 		return
 	}
 
-	grossSalary, err := emp.Salary.Float64Value()
+	regime, err := tax.Load(r.Context(), h.queries, emp.Country, taxYear)
+	if errors.Is(err, tax.ErrNoRegime) {
+		response.RespondeWithError(w, http.StatusNotFound, fmt.Sprintf("no tax rules for %q in %d", emp.Country, taxYear))
+		return
+	}
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, "Parsing Float Error")
+		response.RespondeWithFailure(w, err, "Couldnot load tax rules")
 		return
 	}
 
-	// Calculate all Salary types
-	countryTaxRate := helper.GetTaxRatePerCountry(emp.Country)
-	taxAnount := helper.CalculatePercentage(countryTaxRate, grossSalary.Float64)
-	takeHomeSalary := helper.CalculateNetSalary(grossSalary.Float64, taxAnount)
+	// The brackets are in the currency of the regime
+	rate := money.NewRate(1)
+	if emp.Currency != regime.Currency {
+		converter, err := fx.Load(r.Context(), h.queries, helper.Today())
+		if err != nil {
+			response.RespondeWithFailure(w, err, "Couldnot load exchange rates")
+			return
+		}
+		if rate, err = converter.Rate(emp.Currency, regime.Currency); err != nil {
+			response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		gross = gross.Mul(rate)
+	}
 
-	// construct the payload
-	resp := map[string]float64{
-		"real_salary":      grossSalary.Float64,
-		"government_cut":   taxAnount,
-		"take_home_salary": takeHomeSalary,
+	breakdown := regime.Calculate(gross)
+	if emp.Currency != regime.Currency {
+		breakdown.SalaryCurrency, breakdown.ExchangeRate = emp.Currency, rate
 	}
 
 	// Send the Responses
-	response.RespondeWithJSON(w, http.StatusOK, resp)
+	response.RespondeWithJSON(w, http.StatusOK, breakdown)
 }
 
 // Admin Route
-func GetSalaryMetricsByCountry(w http.ResponseWriter, r *http.Request) {
-	// extract country from Query
-	country := r.URL.Query().Get("country")
+// GetSalaryMetricsByCountry sums up the salaries paid in "country", converted to "reporting_currency"
+// at the exchange rates in effect on "as_of" (today by default), as a spreadsheet when asked for XLSX
+func (h *Handler) GetSalaryMetricsByCountry(w http.ResponseWriter, r *http.Request) {
+	// extract country from Query, "in" is "IN"
+	country := tax.NormalizeCountry(r.URL.Query().Get("country"))
 
-	// Delete Employee
-	salaryMetrics, err := db.Queries.GetSalaryMetricsByCountry(r.Context(), country)
+	reportingCurrency, converter, ok := h.reportingParams(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.GetSalaryMetricsByCountry(r.Context(), country)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot Delete Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot fetch Salary Metrics")
 		return
 	}
 
-	response.RespondeWithJSON(w, http.StatusOK, salaryMetrics)
+	groups := make([]CurrencySalaries, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, CurrencySalaries{
+			Currency:      row.Currency,
+			MinSalary:     numericPtr(row.MinSalary),
+			MaxSalary:     numericPtr(row.MaxSalary),
+			TotalSalary:   numericAmount(row.TotalSalary),
+			EmployeeCount: row.EmployeeCount,
+		})
+	}
+
+	total, err := convertSalaries(converter, reportingCurrency, groups)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	metrics := SalaryMetrics{
+		Country:           country,
+		ReportingCurrency: reportingCurrency,
+		AsOf:              converter.AsOf().Time.Format(helper.DateLayout),
+		MinSalary:         total.MinSalary,
+		MaxSalary:         total.MaxSalary,
+		AvgSalary:         total.avg(reportingCurrency),
+		EmployeeCount:     total.EmployeeCount,
+		ByCurrency:        groups,
+	}
+	if response.WantsXLSX(w, r) {
+		book, err := metrics.workbook()
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+			return
+		}
+		response.RespondeWithXLSX(w, "salary-metrics.xlsx", book)
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, metrics)
 }
 
-func GetAvgSalaryPerJobTitle(w http.ResponseWriter, r *http.Request) {
-	// extract country from Query
-	JobTitle := r.URL.Query().Get("job_title")
+// Admin Route
+// GetAvgSalaryPerJobTitle averages the salaries of "job_title", converted to "reporting_currency"
+// at the exchange rates in effect on "as_of" (today by default), as a spreadsheet when asked for XLSX
+func (h *Handler) GetAvgSalaryPerJobTitle(w http.ResponseWriter, r *http.Request) {
+	// extract job title from Query
+	jobTitle := r.URL.Query().Get("job_title")
 
-	// Delete Employee
-	AvgSalaryResp, err := db.Queries.GetAvgSalaryPerJobTitle(r.Context(), JobTitle)
+	reportingCurrency, converter, ok := h.reportingParams(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.GetAvgSalaryPerJobTitle(r.Context(), jobTitle)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldnot Delete Employee %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot fetch Average Salary")
 		return
 	}
 
-	response.RespondeWithJSON(w, http.StatusOK, AvgSalaryResp)
+	groups := make([]CurrencySalaries, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, CurrencySalaries{
+			Currency:      row.Currency,
+			TotalSalary:   numericAmount(row.TotalSalary),
+			EmployeeCount: row.EmployeeCount,
+		})
+	}
+
+	total, err := convertSalaries(converter, reportingCurrency, groups)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	salaries := JobTitleSalaries{
+		JobTitle:          jobTitle,
+		ReportingCurrency: reportingCurrency,
+		AsOf:              converter.AsOf().Time.Format(helper.DateLayout),
+		AverageSalary:     total.avg(reportingCurrency),
+		EmployeeCount:     total.EmployeeCount,
+		ByCurrency:        groups,
+	}
+	if response.WantsXLSX(w, r) {
+		book, err := salaries.workbook()
+		if err != nil {
+			response.RespondeWithFailure(w, err, "couldnot write spreadsheet")
+			return
+		}
+		response.RespondeWithXLSX(w, "salary-by-job-title.xlsx", book)
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, salaries)
+}
+
+// reportingParams reads "reporting_currency" (REPORTING_CURRENCY by default) and "as_of"
+// (today by default) and loads the exchange rates in effect that day
+func (h *Handler) reportingParams(w http.ResponseWriter, r *http.Request) (string, *fx.Converter, bool) {
+	query := r.URL.Query()
+
+	reportingCurrency, err := salaryCurrency(query.Get("reporting_currency"), h.config.ReportingCurrency)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, "reporting_currency must be an ISO 4217 code")
+		return "", nil, false
+	}
+
+	asOf := helper.Today()
+	if value := query.Get("as_of"); value != "" {
+		if asOf, err = helper.ParseDate(value); err != nil {
+			response.RespondeWithError(w, http.StatusBadRequest, "as_of must be YYYY-MM-DD")
+			return "", nil, false
+		}
+	}
+
+	converter, err := fx.Load(r.Context(), h.queries, asOf)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot load exchange rates")
+		return "", nil, false
+	}
+	return reportingCurrency, converter, true
+}
+
+// convertSalaries fills in the rate and average of every currency group and adds them up
+// in the reporting currency, a currency without a rate fails with fx.ErrNoRate
+func convertSalaries(converter *fx.Converter, reportingCurrency string, groups []CurrencySalaries) (CurrencySalaries, error) {
+	total := CurrencySalaries{Currency: reportingCurrency}
+	for i := range groups {
+		group := &groups[i]
+		rate, err := converter.Rate(group.Currency, reportingCurrency)
+		if err != nil {
+			return total, err
+		}
+		group.Rate = rate
+		if avg := group.avg(group.Currency); avg != nil {
+			group.AvgSalary = *avg
+		}
+
+		// unrounded, the average is rounded once
+		total.TotalSalary = total.TotalSalary.Add(group.TotalSalary.Mul(rate))
+		total.EmployeeCount += group.EmployeeCount
+		// a positive rate keeps the order, the smallest converted minimum is the minimum
+		if group.MinSalary != nil {
+			minSalary := group.MinSalary.Mul(rate).Round(reportingCurrency)
+			if total.MinSalary == nil || minSalary.Cmp(*total.MinSalary) < 0 {
+				total.MinSalary = &minSalary
+			}
+		}
+		if group.MaxSalary != nil {
+			maxSalary := group.MaxSalary.Mul(rate).Round(reportingCurrency)
+			if total.MaxSalary == nil || maxSalary.Cmp(*total.MaxSalary) > 0 {
+				total.MaxSalary = &maxSalary
+			}
+		}
+	}
+	return total, nil
+}
+
+// numericAmount is 0 for NULL
+func numericAmount(n pgtype.Numeric) money.Amount {
+	amount, _ := money.AmountFromNumeric(n)
+	return amount
+}
+
+// numericPtr is nil for NULL
+func numericPtr(n pgtype.Numeric) *money.Amount {
+	if !n.Valid {
+		return nil
+	}
+	amount := numericAmount(n)
+	return &amount
 }
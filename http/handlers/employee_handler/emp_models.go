@@ -1,34 +1,208 @@
 package employeehandler
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"server/audit"
+	"server/config"
+	"server/http/helper"
+	"server/money"
 	"server/sql/database"
+	"server/tax"
+
+	"go.uber.org/zap"
 )
 
+// Handler serves the employee routes
+type Handler struct {
+	queries  database.Querier
+	auditLog *audit.Recorder
+	logger   *zap.Logger
+	config   *config.Config
+}
+
+func New(queries database.Querier, auditLog *audit.Recorder, logger *zap.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		queries:  queries,
+		auditLog: auditLog,
+		logger:   logger,
+		config:   cfg,
+	}
+}
+
+// EmpBody creates or updates an employee, the salary fits "DECIMAL(19,4)"
 type EmpBody struct {
-	JobTitle string  `json:"job_title"`
-	Country  string  `json:"country"`
-	Salary   float64 `json:"salary"`
+	JobTitle string       `json:"job_title" validate:"required,max=100"`
+	Country  string       `json:"country" validate:"required,country"`         // ISO 3166-1 alpha-2, "IN"
+	Salary   money.Amount `json:"salary" validate:"min=0,max=999999999999999"` // "1234.56" or 1234.56, rounded to the minor unit of the currency
+	Currency string       `json:"currency" validate:"currency"`                // ISO 4217, required on create, unchanged when left out on update
+}
+
+// Normalize trims the job title and upper cases the codes
+func (b *EmpBody) Normalize() {
+	b.JobTitle = strings.TrimSpace(b.JobTitle)
+	b.Country = tax.NormalizeCountry(b.Country)
+	b.Currency = money.NormalizeCurrency(b.Currency)
 }
 
 type Employee struct {
-	ID       int32   `json:"id"`
-	JobTitle string  `json:"job_title"`
-	Country  string  `json:"country"`
-	Salary   float64 `json:"salary"`
+	ID        int32         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	JobTitle  string        `json:"job_title"`
+	Country   string        `json:"country"`
+	Salary    *money.Amount `json:"salary,omitempty"`     // left out by the admin routes for a caller without "salaries:read"
+	Currency  string        `json:"currency,omitempty"`   // the currency of the salary, left out with it
+	Version   int32         `json:"version"`              // bumped by every change, the ETag of the employee
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"` // only on deleted employees, restorable until the retention job
+}
+
+// EmployeePage is one page of the admin employee list
+type EmployeePage struct {
+	Employees  []Employee `json:"employees"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 func dbEmployeeToEmpJson(dbEmp *database.Employee) Employee {
-	salary, err := dbEmp.Salary.Float64Value()
+	salary, err := money.AmountFromNumeric(dbEmp.Salary)
 	if err != nil {
 		log.Printf("Error :- %v\n", err)
 	}
 
-	return Employee{
+	emp := Employee{
 		ID:       dbEmp.ID,
+		UserID:   dbEmp.UserID,
 		JobTitle: dbEmp.JobTitle,
 		Country:  dbEmp.Country,
-		Salary:   salary.Float64,
+		Salary:   &salary,
+		Currency: dbEmp.Currency,
+		Version:  dbEmp.Version,
+	}
+	if dbEmp.DeletedAt.Valid {
+		emp.DeletedAt = &dbEmp.DeletedAt.Time
+	}
+	return emp
+}
+
+// empBodyOf is the body that would leave "dbEmp" as it is, the state a PATCH applies to
+func empBodyOf(dbEmp *database.Employee) EmpBody {
+	emp := dbEmployeeToEmpJson(dbEmp)
+	return EmpBody{
+		JobTitle: emp.JobTitle,
+		Country:  emp.Country,
+		Salary:   *emp.Salary,
+		Currency: emp.Currency,
+	}
+}
+
+// salaryCurrency checks the ISO 4217 code of a salary, an empty one stays "current"
+func salaryCurrency(code string, current string) (string, error) {
+	code = money.NormalizeCurrency(code)
+	if code == "" {
+		code = current
+	}
+	if !money.IsCurrency(code) {
+		return "", fmt.Errorf("currency must be an ISO 4217 code (e.g. USD)")
+	}
+	return code, nil
+}
+
+// CurrencySalaries are the salaries paid in one currency, 1 "currency" is worth "rate" in the reporting currency
+type CurrencySalaries struct {
+	Currency      string        `json:"currency"`
+	MinSalary     *money.Amount `json:"min_salary,omitempty"`
+	MaxSalary     *money.Amount `json:"max_salary,omitempty"`
+	AvgSalary     money.Amount  `json:"avg_salary"`
+	TotalSalary   money.Amount  `json:"total_salary"`
+	EmployeeCount int64         `json:"employee_count"`
+	Rate          money.Rate    `json:"rate"`
+}
+
+// avg is nil without employees
+func (c CurrencySalaries) avg(currency string) *money.Amount {
+	if c.EmployeeCount == 0 {
+		return nil
+	}
+	avg := c.TotalSalary.Div(c.EmployeeCount).Round(currency)
+	return &avg
+}
+
+// SalaryMetrics are the salary statistics of a country in the reporting currency
+type SalaryMetrics struct {
+	Country           string             `json:"country"`
+	ReportingCurrency string             `json:"reporting_currency"`
+	AsOf              string             `json:"as_of"` // day of the exchange rates
+	MinSalary         *money.Amount      `json:"min_salary"`
+	MaxSalary         *money.Amount      `json:"max_salary"`
+	AvgSalary         *money.Amount      `json:"avg_salary"`
+	EmployeeCount     int64              `json:"employee_count"`
+	ByCurrency        []CurrencySalaries `json:"by_currency"`
+}
+
+// JobTitleSalaries is the average salary of a job title in the reporting currency
+type JobTitleSalaries struct {
+	JobTitle          string             `json:"job_title"`
+	ReportingCurrency string             `json:"reporting_currency"`
+	AsOf              string             `json:"as_of"` // day of the exchange rates
+	AverageSalary     *money.Amount      `json:"average_salary"`
+	EmployeeCount     int64              `json:"employee_count"`
+	ByCurrency        []CurrencySalaries `json:"by_currency"`
+}
+
+// SalaryChangeBody records a salary change, effective today unless "effective_from" says otherwise
+type SalaryChangeBody struct {
+	Salary        money.Amount `json:"salary" validate:"required,min=0,max=999999999999999"`
+	Currency      string       `json:"currency" validate:"currency"`   // the current one when left out
+	EffectiveFrom string       `json:"effective_from" validate:"date"` // "YYYY-MM-DD"
+	Reason        string       `json:"reason" validate:"required"`     // one of helper.SalaryChangeReasons
+	Note          string       `json:"note" validate:"max=1000"`
+}
+
+// Normalize trims the texts and upper cases the currency
+func (b *SalaryChangeBody) Normalize() {
+	b.Currency = money.NormalizeCurrency(b.Currency)
+	b.EffectiveFrom = strings.TrimSpace(b.EffectiveFrom)
+	b.Reason = strings.TrimSpace(b.Reason)
+	b.Note = strings.TrimSpace(b.Note)
+}
+
+// SalaryChange is one entry of the salary history
+type SalaryChange struct {
+	ID            int64        `json:"id"`
+	Salary        money.Amount `json:"salary"`
+	Currency      string       `json:"currency"`
+	EffectiveFrom string       `json:"effective_from"`
+	Reason        string       `json:"reason"`
+	ApprovedBy    *int64       `json:"approved_by"`
+	Note          string       `json:"note"`
+	Status        string       `json:"status,omitempty"` // "past", "current" or "scheduled"
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// SalaryTimeline is the compensation history of an employee, oldest first
+type SalaryTimeline struct {
+	EmployeeID    int32          `json:"employee_id"`
+	CurrentSalary money.Amount   `json:"current_salary"`
+	Currency      string         `json:"currency"`
+	Changes       []SalaryChange `json:"changes"`
+}
+
+func dbSalaryChangeToJson(dbChange *database.SalaryHistory) SalaryChange {
+	salary, err := money.AmountFromNumeric(dbChange.Salary)
+	if err != nil {
+		log.Printf("Error :- %v\n", err)
+	}
+
+	return SalaryChange{
+		ID:            dbChange.ID,
+		Salary:        salary,
+		Currency:      dbChange.Currency,
+		EffectiveFrom: dbChange.EffectiveFrom.Time.Format(helper.DateLayout),
+		Reason:        dbChange.Reason,
+		ApprovedBy:    dbChange.ApprovedBy,
+		Note:          dbChange.Note,
+		CreatedAt:     dbChange.CreatedAt.Time,
 	}
 }
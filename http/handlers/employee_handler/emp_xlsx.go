@@ -0,0 +1,140 @@
+package employeehandler
+
+import (
+	"fmt"
+	"slices"
+
+	"server/money"
+	"server/sheet"
+	"server/sql/database"
+)
+
+// workbook is the summary of the country, then the salaries of each currency
+func (m SalaryMetrics) workbook() (*sheet.Workbook, error) {
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	err = book.AddSheet("Summary",
+		[]string{"Country", "Employees", "Min salary", "Max salary", "Avg salary", "Reporting currency", "Rates as of"},
+		[][]any{{
+			m.Country,
+			m.EmployeeCount,
+			sheet.OptionalMoney(m.MinSalary, m.ReportingCurrency),
+			sheet.OptionalMoney(m.MaxSalary, m.ReportingCurrency),
+			sheet.OptionalMoney(m.AvgSalary, m.ReportingCurrency),
+			m.ReportingCurrency,
+			sheet.Date(m.AsOf),
+		}})
+	if err != nil {
+		return nil, err
+	}
+	return book, addCurrencySheet(book, m.ByCurrency, m.ReportingCurrency)
+}
+
+// workbook is the summary of the job title, then the salaries of each currency
+func (j JobTitleSalaries) workbook() (*sheet.Workbook, error) {
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	err = book.AddSheet("Summary",
+		[]string{"Job title", "Employees", "Avg salary", "Reporting currency", "Rates as of"},
+		[][]any{{
+			j.JobTitle,
+			j.EmployeeCount,
+			sheet.OptionalMoney(j.AverageSalary, j.ReportingCurrency),
+			j.ReportingCurrency,
+			sheet.Date(j.AsOf),
+		}})
+	if err != nil {
+		return nil, err
+	}
+	return book, addCurrencySheet(book, j.ByCurrency, j.ReportingCurrency)
+}
+
+// addCurrencySheet lists the salaries of every currency in that currency, with the rate
+// converting them to the reporting currency
+func addCurrencySheet(book *sheet.Workbook, groups []CurrencySalaries, reportingCurrency string) error {
+	rows := make([][]any, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, []any{
+			g.Currency,
+			g.EmployeeCount,
+			sheet.OptionalMoney(g.MinSalary, g.Currency),
+			sheet.OptionalMoney(g.MaxSalary, g.Currency),
+			sheet.Money{Amount: g.AvgSalary, Currency: g.Currency},
+			sheet.Money{Amount: g.TotalSalary, Currency: g.Currency},
+			g.Rate,
+		})
+	}
+	return book.AddSheet("By currency",
+		[]string{"Currency", "Employees", "Min salary", "Max salary", "Avg salary", "Total salary", "Rate to " + reportingCurrency},
+		rows)
+}
+
+// employeesWorkbook has a sheet per salary currency, a sheet's salaries add up. Without
+// "salaries", a single sheet without the salaries.
+func employeesWorkbook(emps []*database.Employee, salaries bool) (*sheet.Workbook, error) {
+	if !salaries {
+		return employeesWorkbookWithoutSalaries(emps)
+	}
+
+	byCurrency := make(map[string][][]any)
+	for _, emp := range emps {
+		salary, err := money.AmountFromNumeric(emp.Salary)
+		if err != nil {
+			return nil, err
+		}
+		var createdAt any
+		if emp.CreatedAt.Valid {
+			createdAt = emp.CreatedAt.Time
+		}
+		byCurrency[emp.Currency] = append(byCurrency[emp.Currency], []any{
+			emp.ID,
+			emp.UserID,
+			emp.JobTitle,
+			emp.Country,
+			sheet.Money{Amount: salary, Currency: emp.Currency},
+			createdAt,
+		})
+	}
+
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	header := []string{"ID", "User ID", "Job title", "Country", "Salary", "Created at"}
+	if len(byCurrency) == 0 {
+		return book, book.AddSheet("Employees", header, nil)
+	}
+
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+	for _, currency := range currencies {
+		if err := book.AddSheet(fmt.Sprintf("Employees %s", currency), header, byCurrency[currency]); err != nil {
+			return nil, err
+		}
+	}
+	return book, nil
+}
+
+func employeesWorkbookWithoutSalaries(emps []*database.Employee) (*sheet.Workbook, error) {
+	rows := make([][]any, 0, len(emps))
+	for _, emp := range emps {
+		var createdAt any
+		if emp.CreatedAt.Valid {
+			createdAt = emp.CreatedAt.Time
+		}
+		rows = append(rows, []any{emp.ID, emp.UserID, emp.JobTitle, emp.Country, createdAt})
+	}
+
+	book, err := sheet.New()
+	if err != nil {
+		return nil, err
+	}
+	return book, book.AddSheet("Employees", []string{"ID", "User ID", "Job title", "Country", "Created at"}, rows)
+}
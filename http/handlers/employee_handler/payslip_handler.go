@@ -0,0 +1,158 @@
+package employeehandler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"server/http/middleware"
+	"server/http/response"
+	"server/payroll"
+	"server/sql/database"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListPayslips returns the payslips issued to the logged in user, latest period first.
+// A payslip of a reversed run has the status "reversed", the corrected one follows it.
+func (h *Handler) ListPayslips(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	rows, err := h.queries.ListUserPayslips(r.Context(), userInfo.ID)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch payslips")
+		return
+	}
+
+	statuses := make(map[int64]string)
+	payslips := make([]payroll.Payslip, 0, len(rows))
+	for _, row := range rows {
+		payslip, ok := h.issuedPayslip(w, r, row, statuses)
+		if !ok {
+			return
+		}
+		payslips = append(payslips, payslip)
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, payslips)
+}
+
+// GetPayslip returns the payslip "{id}" of the logged in user
+func (h *Handler) GetPayslip(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid payslip id")
+		return
+	}
+
+	// Someone else's payslip is just as not found as a missing one
+	row, err := h.queries.GetUserPayslip(r.Context(), database.GetUserPayslipParams{ID: id, UserID: userInfo.ID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "payslip not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch payslip")
+		return
+	}
+
+	payslip, ok := h.issuedPayslip(w, r, row, make(map[int64]string))
+	if !ok {
+		return
+	}
+
+	response.RespondeWithJSON(w, http.StatusOK, payslip)
+}
+
+// GetPayslipPDF returns the payslip "{id}" of the logged in user as a PDF document, with
+// the year to date totals of the locked payslips paid up to it
+func (h *Handler) GetPayslipPDF(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid payslip id")
+		return
+	}
+
+	row, err := h.queries.GetUserPayslip(r.Context(), database.GetUserPayslipParams{ID: id, UserID: userInfo.ID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "payslip not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch payslip")
+		return
+	}
+
+	payslip, ok := h.issuedPayslip(w, r, row, make(map[int64]string))
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.ListLockedPayslipsForYear(r.Context(), database.ListLockedPayslipsForYearParams{TaxYear: row.TaxYear, UserID: &userInfo.ID})
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch payslips")
+		return
+	}
+	paid := make([]payroll.Payslip, 0, len(rows))
+	for _, row := range rows {
+		p, err := payroll.PayslipFromDB(row)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "Couldnot read payslip")
+			return
+		}
+		paid = append(paid, p)
+	}
+
+	// Rendered before anything is written so a failure is still a JSON error
+	var buf bytes.Buffer
+	company := payroll.Company{Name: h.config.CompanyName, Address: h.config.CompanyAddress}
+	if err := payslip.WritePDF(&buf, company, payroll.YearToDateOf(payslip, paid)); err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot render payslip")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payslip-%d.pdf"`, payslip.ID))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// issuedPayslip decodes the payslip along with the status of its run, "statuses" caches them by run
+func (h *Handler) issuedPayslip(w http.ResponseWriter, r *http.Request, row *database.Payslip, statuses map[int64]string) (payroll.Payslip, bool) {
+	payslip, err := payroll.PayslipFromDB(row)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot read payslip")
+		return payroll.Payslip{}, false
+	}
+
+	status, ok := statuses[row.RunID]
+	if !ok {
+		run, err := h.queries.GetPayrollRun(r.Context(), row.RunID)
+		if err != nil {
+			response.RespondeWithFailure(w, err, "Couldnot fetch payroll run")
+			return payroll.Payslip{}, false
+		}
+		status = run.Status
+		statuses[row.RunID] = status
+	}
+	payslip.Status = status
+	return payslip, true
+}
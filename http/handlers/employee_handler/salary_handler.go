@@ -0,0 +1,227 @@
+package employeehandler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"server/http/helper"
+	"server/http/middleware"
+	"server/http/request"
+	"server/http/response"
+	"server/money"
+	"server/sql/database"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetSalaryHistory returns the compensation timeline of the logged in user
+func (h *Handler) GetSalaryHistory(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	emp, err := h.queries.GetEmployeByuserById(r.Context(), userInfo.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "employee not found")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+
+	h.respondWithSalaryTimeline(w, r, emp, http.StatusOK)
+}
+
+// Admin Route
+// GetSalaryHistoryByID returns the compensation timeline of the employee "{id}"
+func (h *Handler) GetSalaryHistoryByID(w http.ResponseWriter, r *http.Request) {
+	emp, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	h.respondWithSalaryTimeline(w, r, emp, http.StatusOK)
+}
+
+// Admin Route
+// CreateSalaryChange records a salary change of the employee "{id}" approved by the caller
+// and answers with the new timeline, with a future "effective_from" the change is scheduled
+// and becomes current on that day
+func (h *Handler) CreateSalaryChange(w http.ResponseWriter, r *http.Request) {
+	emp, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	var reqBody SalaryChangeBody
+	if !request.DecodeJSON(w, r, &reqBody) {
+		return
+	}
+
+	currency, err := salaryCurrency(reqBody.Currency, emp.Currency)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	salary := reqBody.Salary.Round(currency)
+	if salary.Sign() <= 0 {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity, "salary must be positive")
+		return
+	}
+	if !slices.Contains(helper.SalaryChangeReasons, reqBody.Reason) {
+		response.RespondeWithError(w, http.StatusUnprocessableEntity,
+			"reason must be one of "+strings.Join(helper.SalaryChangeReasons, ", "))
+		return
+	}
+	effectiveFrom := helper.Today()
+	if reqBody.EffectiveFrom != "" {
+		if effectiveFrom, err = helper.ParseDate(reqBody.EffectiveFrom); err != nil {
+			response.RespondeWithError(w, http.StatusUnprocessableEntity, "effective_from must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	change, err := recordSalaryChange(r, h.queries, emp.ID, salary.Numeric(), currency, effectiveFrom, reqBody.Reason, reqBody.Note)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot record salary change")
+		return
+	}
+	h.logSalaryChange(r, change)
+
+	// The current salary may have just changed
+	emp, err = h.queries.GetEmployeeById(r.Context(), emp.ID)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return
+	}
+
+	h.respondWithSalaryTimeline(w, r, emp, http.StatusCreated)
+}
+
+// Admin Route
+// CancelSalaryChange removes the scheduled salary change "{changeID}" of the employee "{id}",
+// changes already in effect are history and stay
+func (h *Handler) CancelSalaryChange(w http.ResponseWriter, r *http.Request) {
+	emp, ok := h.targetEmployee(w, r)
+	if !ok {
+		return
+	}
+
+	changeID, err := strconv.ParseInt(chi.URLParam(r, "changeID"), 10, 64)
+	if err != nil || changeID < 1 {
+		response.RespondeWithError(w, http.StatusBadRequest, "invalid salary change id")
+		return
+	}
+
+	change, err := h.queries.DeleteScheduledSalaryChange(r.Context(), database.DeleteScheduledSalaryChangeParams{
+		ID:         changeID,
+		EmployeeID: emp.ID,
+		AsOf:       helper.Today(),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "no scheduled salary change with that id")
+		return
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot cancel salary change")
+		return
+	}
+	h.auditLog.Log(r, "salary.cancel", "employee", strconv.Itoa(int(emp.ID)), dbSalaryChangeToJson(change), nil)
+
+	response.RespondeWithJSON(w, http.StatusOK, dbSalaryChangeToJson(change))
+}
+
+// recordSalaryChange adds to the salary history with the caller as approver, through "q" so it
+// joins the transaction of the change it comes with. Log it with logSalaryChange once committed.
+func recordSalaryChange(r *http.Request, q database.Querier, employeeID int32, salary pgtype.Numeric, currency string, effectiveFrom pgtype.Date, reason string, note string) (*database.SalaryHistory, error) {
+	arg := database.CreateSalaryChangeParams{
+		EmployeeID:    employeeID,
+		Salary:        salary,
+		Currency:      currency,
+		EffectiveFrom: effectiveFrom,
+		Reason:        reason,
+		Note:          note,
+	}
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		arg.ApprovedBy = &userInfo.ID
+	}
+
+	return helper.RecordSalaryChange(r.Context(), q, arg)
+}
+
+func (h *Handler) logSalaryChange(r *http.Request, change *database.SalaryHistory) {
+	h.auditLog.Log(r, "salary.create", "employee", strconv.Itoa(int(change.EmployeeID)), nil, dbSalaryChangeToJson(change))
+}
+
+func (h *Handler) respondWithSalaryTimeline(w http.ResponseWriter, r *http.Request, emp *database.Employee, status int) {
+	changes, err := h.queries.ListSalaryHistory(r.Context(), emp.ID)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch salary history")
+		return
+	}
+
+	timeline := SalaryTimeline{
+		EmployeeID:    emp.ID,
+		CurrentSalary: *dbEmployeeToEmpJson(emp).Salary,
+		Currency:      emp.Currency,
+		Changes:       make([]SalaryChange, 0, len(changes)),
+	}
+	today := helper.Today()
+	for i, change := range changes {
+		// Oldest first, the last one in effect is the current salary
+		isLast := i == len(changes)-1 || changes[i+1].EffectiveFrom.Time.After(today.Time)
+		c := dbSalaryChangeToJson(change)
+		c.Status = salaryChangeStatus(change, today, isLast)
+		timeline.Changes = append(timeline.Changes, c)
+	}
+
+	response.RespondeWithJSON(w, status, timeline)
+}
+
+// salaryChangeStatus tells a scheduled change from the current one and the past ones,
+// "latest" is whether no later change is in effect yet
+func salaryChangeStatus(change *database.SalaryHistory, today pgtype.Date, latest bool) string {
+	switch {
+	case change.EffectiveFrom.Time.After(today.Time):
+		return "scheduled"
+	case latest:
+		return "current"
+	default:
+		return "past"
+	}
+}
+
+// targetEmployee reads the "{id}" URL param and fetches the employee
+func (h *Handler) targetEmployee(w http.ResponseWriter, r *http.Request) (*database.Employee, bool) {
+	id, err := employeeIDParam(r)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	emp, err := h.queries.GetEmployeeById(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithError(w, http.StatusNotFound, "employee not found")
+		return nil, false
+	}
+	if err != nil {
+		response.RespondeWithFailure(w, err, "Couldnot fetch Employee")
+		return nil, false
+	}
+	return emp, true
+}
+
+// numericEqual compares two amounts by value, 1.5 equals 1.50
+func numericEqual(a, b pgtype.Numeric) bool {
+	amountA, errA := money.AmountFromNumeric(a)
+	amountB, errB := money.AmountFromNumeric(b)
+	return errA == nil && errB == nil && amountA.Cmp(amountB) == 0
+}
@@ -2,32 +2,28 @@ package userhandler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 
 	"server/http/helper"
 	"server/http/middleware"
+	"server/http/request"
 	"server/http/response"
 	"server/sql/database"
 
-	db "server/init"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 )
 
-func HandlerCreateUser(w http.ResponseWriter, r *http.Request) {
-	type userReqBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Username string `json:"username"`
-	}
+// errDefaultRole fails a registration whose user couldn't get the default role
+var errDefaultRole = errors.New("couldnot assign default role")
 
-	var reqBody userReqBody
+func (h *Handler) HandlerCreateUser(w http.ResponseWriter, r *http.Request) {
+	var reqBody SignUpBody
 
-	// Decode the request body into the struct
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&reqBody)
-	if err != nil {
-		response.RespondeWithError(w, 400, "invalid json")
+	if !request.DecodeJSON(w, r, &reqBody) {
 		return
 	}
 
@@ -37,76 +33,137 @@ func HandlerCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := db.Queries.CreateUser(r.Context(), database.CreateUserParams{
-		Email:        reqBody.Email,
-		PasswordHash: hashed,
-		Username:     reqBody.Username,
+	db, ok := h.queries.(database.Transactor)
+	if !ok {
+		response.RespondeWithError(w, http.StatusInternalServerError, "the database can't run transactions")
+		return
+	}
+
+	// A user without their default role is never left behind
+	var user *database.User
+	err = db.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			Email:        reqBody.Email,
+			PasswordHash: hashed,
+			Username:     reqBody.Username,
+		})
+		if err != nil {
+			return err
+		}
+		if err := helper.AssignRole(r.Context(), q, int64(user.ID), helper.DefaultRole); err != nil {
+			return fmt.Errorf("%w: %w", errDefaultRole, err)
+		}
+		return nil
 	})
+	if errors.Is(err, errDefaultRole) {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot assign default role")
+		return
+	}
 	if err != nil {
-		response.RespondeWithError(w, 400, fmt.Sprintf("Couldnot create user %v", err))
+		response.RespondeWithFailure(w, err, "Couldnot create user")
 		return
 	}
+	// Only the id, the audit log can't be anonymized once the user is gone
+	h.auditLog.Log(r, "user.register", "user", strconv.Itoa(int(user.ID)), nil, nil)
 
-	token, err := helper.CreateToken(int64(user.ID), user.Email, user.Username)
+	_, err = h.startSession(r.Context(), w, user)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, err.Error())
+		response.RespondeWithFailure(w, err, "couldnot start session")
 		return
 	}
 
-	helper.SetJWTToken(w, "jwt", token)
-
 	response.RespondeWithJSON(w, 200, dbuserToUser(user))
 }
 
-func HandlerLogin(w http.ResponseWriter, r *http.Request) {
-	type userReqBody struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
-
-	var reqBody userReqBody
+func (h *Handler) HandlerLogin(w http.ResponseWriter, r *http.Request) {
+	var reqBody LoginBody
 
-	// Decode the request body into the struct
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&reqBody)
-	if err != nil {
-		response.RespondeWithError(w, 400, "invalid json")
+	if !request.DecodeJSON(w, r, &reqBody) {
 		return
 	}
 
-	user, err := db.Queries.GetUserByName(r.Context(), reqBody.Username)
+	// An unknown username is answered like a wrong password
+	user, err := h.queries.GetUserByName(r.Context(), reqBody.Username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidCredentials, "invalid credentials")
+		return
+	}
 	if err != nil {
-		response.RespondeWithError(w, 400, err.Error())
+		response.RespondeWithFailure(w, err, "couldnot log in")
 		return
 	}
 
 	if !helper.CheckPasswordHash(reqBody.Password, user.PasswordHash) {
-		response.RespondeWithError(w, 400, "invalid credentials")
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidCredentials, "invalid credentials")
 		return
 	}
 
-	log.Println("user id", user.ID, "user email", user.Email, "user username", user.Username)
+	h.logger.Debug("user logged in", zap.Int32("id", user.ID), zap.String("email", user.Email), zap.String("username", user.Username))
 
-	token, err := helper.CreateToken(int64(user.ID), user.Email, user.Username)
+	tokens, err := h.startSession(r.Context(), w, user)
 	if err != nil {
-		response.RespondeWithError(w, http.StatusInternalServerError, err.Error())
+		response.RespondeWithFailure(w, err, "couldnot start session")
 		return
 	}
 
-	helper.SetJWTToken(w, "jwt", token)
+	resp := LoginResponse{User: dbuserToUser(user)}
+	if reqBody.ReturnToken || r.URL.Query().Get("return_token") == "true" {
+		tokenResp := h.tokenResponse(tokens)
+		resp.TokenResponse = &tokenResp
+	}
 
-	response.RespondeWithJSON(w, 200, dbuserToUser(user))
+	response.RespondeWithJSON(w, 200, resp)
 }
 
-func LogOut(w http.ResponseWriter, r *http.Request) {
-	helper.UnsetJWTToken(w, "jwt")
+func (h *Handler) LogOut(w http.ResponseWriter, r *http.Request) {
+	// The access token stays on the revocation list until it would have expired anyway
+	if userInfo, ok := middleware.GetUserFromContext(r.Context()); ok {
+		err := helper.RevokeToken(r.Context(), h.queries, userInfo.TokenID, userInfo.ID, userInfo.ExpiresAt)
+		if err != nil {
+			response.RespondeWithError(w, http.StatusInternalServerError, "couldnot log out")
+			return
+		}
+	}
+
+	// End the refresh token family of this login, if the cookie made it here
+	if cookie, err := r.Cookie(helper.RefreshTokenCookie); err == nil && cookie.Value != "" {
+		stored, err := h.queries.GetRefreshTokenByHash(r.Context(), helper.HashRefreshToken(cookie.Value))
+		if err == nil {
+			_ = h.queries.RevokeRefreshTokenFamily(r.Context(), stored.FamilyID)
+		}
+	}
+
+	h.clearSessionCookies(w)
 	response.RespondeWithJSON(w, 200, map[string]interface{}{
 		"Status":  true,
 		"message": "Logged out successfully",
 	})
 }
 
-func CheckStatus(w http.ResponseWriter, r *http.Request) {
+// LogOutEverywhere revokes every access and refresh token of the caller, on every device
+func (h *Handler) LogOutEverywhere(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
+		return
+	}
+
+	err := helper.RevokeAllTokens(r.Context(), h.queries, userInfo.ID, h.config.AccessTokenTTL)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot log out")
+		return
+	}
+	h.auditLog.Log(r, "user.logout_all", "user", strconv.FormatInt(userInfo.ID, 10), nil, nil)
+
+	h.clearSessionCookies(w)
+	response.RespondeWithJSON(w, 200, map[string]interface{}{
+		"Status":  true,
+		"message": "Logged out from every device",
+	})
+}
+
+func (h *Handler) CheckStatus(w http.ResponseWriter, r *http.Request) {
 	userInfo, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
 		response.RespondeWithError(w, http.StatusBadRequest, "user not found")
@@ -115,11 +172,13 @@ func CheckStatus(w http.ResponseWriter, r *http.Request) {
 
 	// response.RespondeWithJSON(w, 200, dbuserToUser(user))
 	response := map[string]interface{}{
-		"status":   true,
-		"message":  "User info retrieved successfully",
-		"email":    userInfo.Email,
-		"username": userInfo.Username,
-		"id":       userInfo.ID,
+		"status":      true,
+		"message":     "User info retrieved successfully",
+		"email":       userInfo.Email,
+		"username":    userInfo.Username,
+		"id":          userInfo.ID,
+		"roles":       userInfo.Roles,
+		"permissions": userInfo.Permissions,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -1,6 +1,65 @@
 package userhandler
 
-import "server/sql/database"
+import (
+	"strings"
+
+	"server/audit"
+	"server/config"
+	"server/http/helper"
+	"server/sql/database"
+
+	"go.uber.org/zap"
+)
+
+// Handler serves the user / auth routes
+type Handler struct {
+	queries  database.Querier
+	keys     *helper.KeyRing
+	auditLog *audit.Recorder
+	logger   *zap.Logger
+	config   *config.Config
+}
+
+func New(queries database.Querier, keys *helper.KeyRing, auditLog *audit.Recorder, logger *zap.Logger, cfg *config.Config) *Handler {
+	return &Handler{
+		queries:  queries,
+		keys:     keys,
+		auditLog: auditLog,
+		logger:   logger,
+		config:   cfg,
+	}
+}
+
+// SignUpBody registers a user
+type SignUpBody struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
+	Username string `json:"username" validate:"required,max=255"`
+}
+
+// Normalize trims the username and the email, spaces around a password are part of it
+func (b *SignUpBody) Normalize() {
+	b.Email = strings.TrimSpace(b.Email)
+	b.Username = strings.TrimSpace(b.Username)
+}
+
+// LoginBody logs a user in, with any password: the strength rules only apply to new ones
+type LoginBody struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	// Also return the tokens in the body, for CLIs / services without cookies
+	ReturnToken bool `json:"return_token"`
+}
+
+// Normalize trims the username as registered
+func (b *LoginBody) Normalize() {
+	b.Username = strings.TrimSpace(b.Username)
+}
+
+// RefreshBody carries the refresh token of a client without cookies
+type RefreshBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
 
 type User struct {
 	Email    string `json:"email"`
@@ -14,3 +73,16 @@ func dbuserToUser(dbUser *database.User) User {
 	}
 }
 
+// TokenResponse follows the OAuth2 token response shape (RFC 6749 §5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LoginResponse is the user, plus the tokens when the client asked for them
+type LoginResponse struct {
+	User
+	*TokenResponse
+}
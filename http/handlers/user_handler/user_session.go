@@ -0,0 +1,181 @@
+package userhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"server/http/helper"
+	"server/http/request"
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// sessionTokens is the access / refresh token pair of a session
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// startSession opens a new refresh token family for the user and sets both token cookies
+func (h *Handler) startSession(ctx context.Context, w http.ResponseWriter, user *database.User) (sessionTokens, error) {
+	familyID, err := helper.NewTokenFamily()
+	if err != nil {
+		return sessionTokens{}, err
+	}
+	return h.issueTokens(ctx, w, user, familyID)
+}
+
+// issueTokens sets a fresh access token and a refresh token belonging to "familyID"
+func (h *Handler) issueTokens(ctx context.Context, w http.ResponseWriter, user *database.User, familyID string) (sessionTokens, error) {
+	roles, permissions, err := helper.UserAccess(ctx, h.queries, int64(user.ID))
+	if err != nil {
+		return sessionTokens{}, err
+	}
+
+	accessToken, err := h.keys.CreateToken(int64(user.ID), user.Email, user.Username, roles, permissions, h.config.AccessTokenTTL)
+	if err != nil {
+		return sessionTokens{}, err
+	}
+
+	refreshToken, refreshHash, err := helper.NewRefreshToken()
+	if err != nil {
+		return sessionTokens{}, err
+	}
+
+	_, err = h.queries.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		UserID:    int64(user.ID),
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().UTC().Add(h.config.RefreshTokenTTL), Valid: true},
+	})
+	if err != nil {
+		return sessionTokens{}, err
+	}
+
+	helper.SetJWTToken(w, "jwt", accessToken, h.config.AccessTokenTTL, h.config.CookieDomain, h.config.CookieSecure)
+	helper.SetRefreshToken(w, refreshToken, h.config.RefreshTokenTTL, h.config.CookieDomain, h.config.CookieSecure)
+	return sessionTokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// tokenResponse puts the tokens in the body, for clients that don't carry cookies
+func (h *Handler) tokenResponse(tokens sessionTokens) TokenResponse {
+	return TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.config.AccessTokenTTL.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}
+}
+
+// readRefreshToken takes the refresh token from the cookie, or from a {"refresh_token": "..."} body.
+// A body that can't be read is answered by request.DecodeJSON, "ok" is false then.
+func readRefreshToken(w http.ResponseWriter, r *http.Request) (token string, fromBody bool, ok bool) {
+	if cookie, err := r.Cookie(helper.RefreshTokenCookie); err == nil && cookie.Value != "" {
+		return cookie.Value, false, true
+	}
+	// No cookie and no body, the token is missing
+	if r.ContentLength == 0 {
+		return "", false, true
+	}
+
+	var reqBody RefreshBody
+	if !request.DecodeJSON(w, r, &reqBody) {
+		return "", false, false
+	}
+	return reqBody.RefreshToken, true, true
+}
+
+// RefreshToken trades the refresh token (cookie or body) for a new access token and a new refresh token.
+// Every refresh token works once, presenting a used one again means it leaked,
+// so the whole family (the login it came from) is revoked.
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	refreshToken, fromBody, ok := readRefreshToken(w, r)
+	if !ok {
+		return
+	}
+	if refreshToken == "" {
+		response.RespondeWithError(w, http.StatusUnauthorized, "missing refresh token")
+		return
+	}
+
+	stored, err := h.queries.GetRefreshTokenByHash(r.Context(), helper.HashRefreshToken(refreshToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		response.RespondeWithCode(w, http.StatusUnauthorized, response.CodeInvalidToken, "invalid refresh token")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot refresh token")
+		return
+	}
+
+	// Already rotated (reuse) or logged out, kill the family
+	if stored.RevokedAt.Valid {
+		h.revokeFamily(r.Context(), w, stored)
+		response.RespondeWithCode(w, http.StatusUnauthorized, response.CodeInvalidToken, "refresh token revoked, please login again")
+		return
+	}
+
+	if !stored.ExpiresAt.Valid || time.Now().UTC().After(stored.ExpiresAt.Time) {
+		h.clearSessionCookies(w)
+		response.RespondeWithCode(w, http.StatusUnauthorized, response.CodeInvalidToken, "refresh token expired")
+		return
+	}
+
+	// Rotate, only one concurrent caller can revoke it, the loser is treated as a reuse
+	_, err = h.queries.RevokeRefreshToken(r.Context(), stored.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.revokeFamily(r.Context(), w, stored)
+		response.RespondeWithCode(w, http.StatusUnauthorized, response.CodeInvalidToken, "refresh token reuse detected, please login again")
+		return
+	}
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot refresh token")
+		return
+	}
+
+	user, err := h.queries.GetUserById(r.Context(), int32(stored.UserID))
+	if err != nil {
+		response.RespondeWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	tokens, err := h.issueTokens(r.Context(), w, user, stored.FamilyID)
+	if err != nil {
+		response.RespondeWithError(w, http.StatusInternalServerError, "couldnot refresh token")
+		return
+	}
+
+	// Cookie clients keep the tokens out of reach of JavaScript
+	if fromBody {
+		response.RespondeWithJSON(w, http.StatusOK, h.tokenResponse(tokens))
+		return
+	}
+	response.RespondeWithJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     true,
+		"message":    "Token refreshed successfully",
+		"expires_in": int64(h.config.AccessTokenTTL.Seconds()),
+	})
+}
+
+// revokeFamily ends every session descending from the same login
+func (h *Handler) revokeFamily(ctx context.Context, w http.ResponseWriter, token *database.RefreshToken) {
+	h.logger.Warn("revoked refresh token presented, revoking family",
+		zap.Int64("user_id", token.UserID),
+		zap.String("family_id", token.FamilyID),
+	)
+	if err := h.queries.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		h.logger.Error("couldnot revoke refresh token family", zap.Error(err))
+	}
+	h.clearSessionCookies(w)
+}
+
+func (h *Handler) clearSessionCookies(w http.ResponseWriter) {
+	helper.UnsetJWTToken(w, "jwt", h.config.CookieDomain, h.config.CookieSecure)
+	helper.UnsetRefreshToken(w, h.config.CookieDomain, h.config.CookieSecure)
+}
@@ -2,10 +2,11 @@ package util
 
 import (
 	"net/http"
+
+	"server/http/helper"
 	"server/http/response"
 )
 
-
 func HandlerReady(w http.ResponseWriter, r *http.Request) {
 	response.RespondeWithJSON(w, 200, struct{}{})
 }
@@ -13,3 +14,11 @@ func HandlerReady(w http.ResponseWriter, r *http.Request) {
 func HandleErr(w http.ResponseWriter, r *http.Request) {
 	response.RespondeWithError(w, 400, "something went wrong")
 }
+
+// JWKSHandler publishes the public keys verifying our access tokens
+func JWKSHandler(keys *helper.KeyRing) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		response.RespondeWithJSON(w, http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/sql/database"
+	"server/validate"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrAlreadyBootstrapped is returned once a superadmin exists, further ones are granted through the API
+var ErrAlreadyBootstrapped = errors.New("a superadmin already exists")
+
+// BootstrapSuperadmin makes "username" the first superadmin, creating the user when it doesn't exist yet.
+// It only works while nobody holds the superadmin role.
+func BootstrapSuperadmin(ctx context.Context, queries database.Querier, username string, email string, password string) (*database.User, error) {
+	count, err := queries.CountUsersWithRole(ctx, SuperadminRole)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyBootstrapped
+	}
+
+	user, err := queries.GetUserByName(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		user, err = createUser(ctx, queries, username, email, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := AssignRole(ctx, queries, int64(user.ID), SuperadminRole); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func createUser(ctx context.Context, queries database.Querier, username string, email string, password string) (*database.User, error) {
+	if username == "" || email == "" || password == "" {
+		return nil, fmt.Errorf("username, email and password are required to create the user")
+	}
+	// The same rules as a registration
+	if !validate.IsEmail(email) {
+		return nil, fmt.Errorf("email %q is not a valid address", email)
+	}
+	if err := validate.Password(password); err != nil {
+		return nil, fmt.Errorf("password %w", err)
+	}
+
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := queries.CreateUser(ctx, database.CreateUserParams{
+		Username:     username,
+		Email:        email,
+		PasswordHash: hashed,
+		CreatedAt:    pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := AssignRole(ctx, queries, int64(user.ID), DefaultRole); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
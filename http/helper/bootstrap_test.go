@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"server/sql/memdb"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The first superadmin is held to the password rules of a registration
+func TestBootstrapSuperadminPassword(t *testing.T) {
+	ctx := context.Background()
+	store := memdb.New()
+
+	for _, password := range []string{"short1A", "alllowercase", "12345678901"} {
+		if _, err := BootstrapSuperadmin(ctx, store, "root", "root@example.com", password); err == nil {
+			t.Errorf("bootstrapped with the password %q", password)
+		}
+	}
+	if _, err := store.GetUserByName(ctx, "root"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("user left by the refused bootstraps: %v", err)
+	}
+
+	if _, err := BootstrapSuperadmin(ctx, store, "root", "root@example.com", "Corr3ct-Horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BootstrapSuperadmin(ctx, store, "other", "other@example.com", "Corr3ct-Horse"); !errors.Is(err, ErrAlreadyBootstrapped) {
+		t.Errorf("second bootstrap: %v, want ErrAlreadyBootstrapped", err)
+	}
+}
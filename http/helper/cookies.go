@@ -5,34 +5,66 @@ import (
 	"time"
 )
 
+const (
+	// RefreshTokenCookie carries the refresh token, scoped to the API routes ("/v1/token/refresh", "/v1/logout")
+	RefreshTokenCookie = "refresh_token"
+	refreshTokenPath   = "/v1"
+)
 
 // Function to set the cookie in a Chi handler
-func SetJWTToken(w http.ResponseWriter, name string, token string) {
-    // Create the cookie with equivalent properties
-    cookie := &http.Cookie{
-        Name:     "jwt",
-        Value:    token,
-        // Expires is calculated as current time + 3600 seconds (1 hour)
-        Expires:  time.Now().Add(3600 * time.Second),
-        Path:     "/",
-        Domain:   "localhost",  // Optional, set if needed
-        HttpOnly: true,
-        Secure:   false,        // false means the cookie is sent over HTTP as well
-    }
+func SetJWTToken(w http.ResponseWriter, name string, token string, ttl time.Duration, domain string, secure bool) {
+	// Create the cookie with equivalent properties
+	cookie := &http.Cookie{
+		Name:  name,
+		Value: token,
+		// Expires together with the token it carries
+		Expires:  time.Now().Add(ttl),
+		Path:     "/",
+		Domain:   domain, // Optional, set if needed
+		HttpOnly: true,
+		Secure:   secure, // false means the cookie is sent over HTTP as well
+	}
 
-    // Set the cookie in the response
-    http.SetCookie(w, cookie)
+	// Set the cookie in the response
+	http.SetCookie(w, cookie)
 }
 
-func UnsetJWTToken(w http.ResponseWriter, name string) {
+func UnsetJWTToken(w http.ResponseWriter, name string, domain string, secure bool) {
 	cookie := &http.Cookie{
-		Name:     name,           // The name of the cookie to unset
-		Value:    "",             // Empty value
+		Name:     name,                           // The name of the cookie to unset
+		Value:    "",                             // Empty value
 		Expires:  time.Now().Add(-1 * time.Hour), // Expired date to remove the cookie
-		Path:     "/",            // The path should match the original cookie path
-		Domain:   "localhost",    // Domain restriction should match the original cookie domain
-		HttpOnly: true,           // HttpOnly to prevent JavaScript access
-		Secure:   false,          // Not restricted to HTTPS (set to true in production if using HTTPS)
+		Path:     "/",                            // The path should match the original cookie path
+		Domain:   domain,                         // Domain restriction should match the original cookie domain
+		HttpOnly: true,                           // HttpOnly to prevent JavaScript access
+		Secure:   secure,                         // Not restricted to HTTPS (set to true in production if using HTTPS)
 	}
-	http.SetCookie(w, cookie)    // Attach the expired cookie to the response to remove it
-}
\ No newline at end of file
+	http.SetCookie(w, cookie) // Attach the expired cookie to the response to remove it
+}
+
+// SetRefreshToken sets the refresh token cookie, never readable from JavaScript
+func SetRefreshToken(w http.ResponseWriter, token string, ttl time.Duration, domain string, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    token,
+		Expires:  time.Now().Add(ttl),
+		Path:     refreshTokenPath,
+		Domain:   domain,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func UnsetRefreshToken(w http.ResponseWriter, domain string, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    "",
+		Expires:  time.Now().Add(-1 * time.Hour),
+		Path:     refreshTokenPath,
+		Domain:   domain,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
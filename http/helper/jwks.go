@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"server/sql/database"
+)
+
+// JWK is a public key as published in the JWKS (RFC 7517 / RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// "OKP" (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+
+	// "RSA"
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKSet is the document served at "/.well-known/jwks.json"
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func publicJWK(kid string, alg string, public crypto.PublicKey) (JWK, error) {
+	switch pub := public.(type) {
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint, used as the "kid"
+func thumbprint(alg string, public crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK("", alg, public)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the required members, in lexicographic order
+	var canonical []byte
+	switch jwk.Kty {
+	case "OKP":
+		canonical, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X})
+	case "RSA":
+		canonical, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+func encodeKeyPair(private crypto.Signer) (privatePEM string, publicPEM string, err error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", "", fmt.Errorf("could not encode private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return "", "", fmt.Errorf("could not encode public key: %w", err)
+	}
+
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	return privatePEM, publicPEM, nil
+}
+
+func parseSigningKey(sealer cipher.AEAD, row *database.SigningKey) (*signingKey, error) {
+	method, err := signingMethod(row.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	privatePEM, err := openPrivateKey(sealer, row.Kid, row.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q: invalid private key PEM", row.Kid)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", row.Kid, err)
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %q: unsupported private key type %T", row.Kid, parsed)
+	}
+
+	return &signingKey{
+		kid:       row.Kid,
+		method:    method,
+		private:   private,
+		public:    private.Public(),
+		retiresAt: row.RetiresAt.Time,
+		expiresAt: row.ExpiresAt.Time,
+	}, nil
+}
@@ -1,34 +1,164 @@
 package helper
 
 import (
+	"context"
+	"crypto"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
 	"time"
 
+	"server/sql/database"
+
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Supported signing algorithms ("JWT_SIGNING_ALG")
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
 )
 
-var secretKey = []byte(os.Getenv("SECRET_KEY"))
+// How often an unknown "kid" may trigger a reload of the keys from the DB,
+// another instance might have rotated in the meantime
+const unknownKidReloadInterval = 30 * time.Second
+
+// signingKey is a parsed row of "signing_keys"
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	retiresAt time.Time
+	expiresAt time.Time
+}
+
+// KeyRing signs access tokens with the current key and verifies them with
+// every key that is still inside its grace window.
+// Keys live in the DB so every instance of the server shares them.
+type KeyRing struct {
+	queries   database.Querier
+	algorithm string
+	rotation  time.Duration
+	grace     time.Duration
+	sealer    cipher.AEAD // encrypts the private keys in the DB, nil keeps them as plain PEM
+
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	current    *signingKey
+	lastReload time.Time
+}
+
+// NewKeyRing creates an empty KeyRing, call Rotate before issuing tokens.
+// A key signs for "rotation" and keeps verifying for "grace" after that.
+func NewKeyRing(queries database.Querier, algorithm string, rotation, grace time.Duration) (*KeyRing, error) {
+	if _, err := signingMethod(algorithm); err != nil {
+		return nil, err
+	}
+	if rotation <= 0 || grace <= 0 {
+		return nil, fmt.Errorf("key rotation and grace period must be positive")
+	}
+
+	return &KeyRing{
+		queries:   queries,
+		algorithm: algorithm,
+		rotation:  rotation,
+		grace:     grace,
+		keys:      make(map[string]*signingKey),
+	}, nil
+}
+
+// SetEncryptionKey encrypts the private keys stored from now on with "secret"
+// (JWT_KEY_ENCRYPTION_KEY, 32 bytes in base64) and decrypts the ones already stored.
+// Call it before Rotate.
+func (k *KeyRing) SetEncryptionKey(secret string) error {
+	sealer, err := newKeySealer(secret)
+	if err != nil {
+		return err
+	}
+	k.sealer = sealer
+	return nil
+}
+
+// Rotate loads the live keys, creates a new signing key when the current one is due
+// for retirement and drops keys past their grace window
+func (k *KeyRing) Rotate(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	rows, err := k.queries.ListSigningKeys(ctx, pgtype.Timestamp{Time: now, Valid: true})
+	if err != nil {
+		return fmt.Errorf("could not load signing keys: %w", err)
+	}
+
+	// Newest key first, no key or a retired one → time for a new one
+	if len(rows) == 0 || !rows[0].RetiresAt.Time.After(now) {
+		row, err := k.generate(ctx, now)
+		if err != nil {
+			return err
+		}
+		rows = append([]*database.SigningKey{row}, rows...)
+	}
+
+	if _, err := k.queries.DeleteExpiredSigningKeys(ctx, pgtype.Timestamp{Time: now, Valid: true}); err != nil {
+		return fmt.Errorf("could not purge signing keys: %w", err)
+	}
+
+	return k.load(rows, now)
+}
+
+// CreateToken issues an access token valid for "ttl", "jti" makes it individually revocable.
+// The roles and their permissions are embedded, so checking access needs no DB lookup.
+func (k *KeyRing) CreateToken(id int64, email string, username string, roles []string, permissions []string, ttl time.Duration) (string, error) {
+	k.mu.RLock()
+	key := k.current
+	k.mu.RUnlock()
+	if key == nil {
+		return "", fmt.Errorf("no signing key available")
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
 
-func CreateToken(id int64, email string, username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+	now := time.Now()
+	token := jwt.NewWithClaims(key.method,
 		jwt.MapClaims{
-			"id":       id,
-			"email":    email,
-			"username": username,
-			"exp":      time.Now().Add(time.Hour * 24).Unix(),
+			"id":          id,
+			"email":       email,
+			"username":    username,
+			"roles":       nonNil(roles),
+			"permissions": nonNil(permissions),
+			"jti":         jti,
+			"iat":         jwt.NewNumericDate(now),
+			"exp":         now.Add(ttl).Unix(),
 		})
-	tokenString, err := token.SignedString(secretKey)
+	token.Header["kid"] = key.kid
+
+	tokenString, err := token.SignedString(key.private)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-func VerifyToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+// nonNil keeps empty claims as "[]" rather than "null"
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// VerifyToken checks the signature against the key named by the "kid" header
+func (k *KeyRing) VerifyToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, k.verificationKey, jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}))
 
 	if err != nil || !token.Valid {
 		return nil, fmt.Errorf("invalid or expired JWT token")
@@ -41,3 +171,159 @@ func VerifyToken(tokenString string) (jwt.MapClaims, error) {
 
 	return claims, nil
 }
+
+// JWKS returns the public half of every key that can still verify a token
+func (k *KeyRing) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk, err := publicJWK(key.kid, key.method.Alg(), key.public)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func (k *KeyRing) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid header")
+	}
+
+	key := k.lookup(kid)
+	if key == nil {
+		k.reloadOnUnknownKid()
+		key = k.lookup(kid)
+	}
+	if key == nil || time.Now().After(key.expiresAt) {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	// The key decides the algorithm, never the token
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+func (k *KeyRing) lookup(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[kid]
+}
+
+// reloadOnUnknownKid picks up keys created by other instances, at most every unknownKidReloadInterval
+func (k *KeyRing) reloadOnUnknownKid() {
+	k.mu.Lock()
+	if time.Since(k.lastReload) < unknownKidReloadInterval {
+		k.mu.Unlock()
+		return
+	}
+	k.lastReload = time.Now()
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	rows, err := k.queries.ListSigningKeys(ctx, pgtype.Timestamp{Time: now, Valid: true})
+	if err != nil {
+		return
+	}
+	_ = k.load(rows, now)
+}
+
+// load swaps in the parsed keys, rows are ordered newest first
+func (k *KeyRing) load(rows []*database.SigningKey, now time.Time) error {
+	keys := make(map[string]*signingKey, len(rows))
+	var current *signingKey
+
+	for _, row := range rows {
+		key, err := parseSigningKey(k.sealer, row)
+		if err != nil {
+			return err
+		}
+		keys[key.kid] = key
+		if current == nil && key.retiresAt.After(now) {
+			current = key
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.lastReload = time.Now()
+	if current != nil {
+		k.current = current
+	}
+	return nil
+}
+
+// generate creates and stores a new signing key
+func (k *KeyRing) generate(ctx context.Context, now time.Time) (*database.SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch k.algorithm {
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not generate signing key: %w", err)
+	}
+
+	privatePEM, publicPEM, err := encodeKeyPair(private)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := thumbprint(k.algorithm, private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	if k.sealer != nil {
+		if privatePEM, err = sealPrivateKey(k.sealer, kid, privatePEM); err != nil {
+			return nil, fmt.Errorf("could not encrypt signing key: %w", err)
+		}
+	}
+
+	retiresAt := now.Add(k.rotation)
+	row, err := k.queries.CreateSigningKey(ctx, database.CreateSigningKeyParams{
+		Kid:        kid,
+		Algorithm:  k.algorithm,
+		PrivateKey: privatePEM,
+		PublicKey:  publicPEM,
+		RetiresAt:  pgtype.Timestamp{Time: retiresAt, Valid: true},
+		ExpiresAt:  pgtype.Timestamp{Time: retiresAt.Add(k.grace), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not store signing key: %w", err)
+	}
+	return row, nil
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q, use %q or %q", algorithm, AlgEdDSA, AlgRS256)
+	}
+}
+
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks a private key encrypted with the key encryption key, a row without it
+// is a plain PEM written before JWT_KEY_ENCRYPTION_KEY was set
+const sealedPrefix = "aes-256-gcm:"
+
+// newKeySealer reads the key encryption key, 32 bytes in base64 ("openssl rand -base64 32")
+func newKeySealer(secret string) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+	if err != nil {
+		return nil, errors.New("the key encryption key must be base64")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("the key encryption key must be 32 bytes, not %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealPrivateKey encrypts the PEM of the key "kid", the kid is authenticated with it so a
+// sealed key can't be moved to another row
+func sealPrivateKey(sealer cipher.AEAD, kid string, privatePEM string) (string, error) {
+	nonce := make([]byte, sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := sealer.Seal(nonce, nonce, []byte(privatePEM), []byte(kid))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openPrivateKey is the PEM of a "signing_keys.private_key", decrypted when it was sealed
+func openPrivateKey(sealer cipher.AEAD, kid string, stored string) (string, error) {
+	encoded, sealed := strings.CutPrefix(stored, sealedPrefix)
+	if !sealed {
+		return stored, nil
+	}
+	if sealer == nil {
+		return "", fmt.Errorf("signing key %q is encrypted, JWT_KEY_ENCRYPTION_KEY is not set", kid)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < sealer.NonceSize() {
+		return "", fmt.Errorf("signing key %q: invalid encrypted private key", kid)
+	}
+	nonce, ciphertext := data[:sealer.NonceSize()], data[sealer.NonceSize():]
+	plain, err := sealer.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return "", fmt.Errorf("signing key %q: couldn't decrypt the private key, wrong JWT_KEY_ENCRYPTION_KEY?", kid)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"server/sql/memdb"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const testEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // "0123456789abcdef0123456789abcdef"
+
+// The private key is stored sealed, another KeyRing with the same key verifies its tokens
+func TestEncryptedSigningKeys(t *testing.T) {
+	ctx := context.Background()
+	store := memdb.New()
+
+	keys := newTestKeyRing(t, store, testEncryptionKey)
+	token, err := keys.CreateToken(1, "jane@example.com", "jane", nil, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := store.ListSigningKeys(ctx, pgtype.Timestamp{Time: time.Now().UTC(), Valid: true})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("%d signing keys, %v", len(rows), err)
+	}
+	if !strings.HasPrefix(rows[0].PrivateKey, sealedPrefix) || strings.Contains(rows[0].PrivateKey, "PRIVATE KEY") {
+		t.Fatalf("private key stored as %.40q", rows[0].PrivateKey)
+	}
+
+	if _, err := newTestKeyRing(t, store, testEncryptionKey).VerifyToken(token); err != nil {
+		t.Errorf("token of the sealed key: %v", err)
+	}
+
+	// A wrong key or none can't load it
+	other, _ := NewKeyRing(store, AlgEdDSA, time.Hour, time.Hour)
+	if err := other.Rotate(ctx); err == nil {
+		t.Error("sealed key loaded without the encryption key")
+	}
+	if err := other.SetEncryptionKey("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Rotate(ctx); err == nil {
+		t.Error("sealed key loaded with the wrong encryption key")
+	}
+}
+
+// Keys stored before the encryption key was set keep loading
+func TestPlainSigningKeys(t *testing.T) {
+	store := memdb.New()
+	token, err := newTestKeyRing(t, store, "").CreateToken(1, "jane@example.com", "jane", nil, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestKeyRing(t, store, testEncryptionKey).VerifyToken(token); err != nil {
+		t.Errorf("token of the plain key: %v", err)
+	}
+}
+
+func TestSetEncryptionKey(t *testing.T) {
+	keys, _ := NewKeyRing(memdb.New(), AlgEdDSA, time.Hour, time.Hour)
+	for _, secret := range []string{"not base64!", "c2hvcnQ="} {
+		if err := keys.SetEncryptionKey(secret); err == nil {
+			t.Errorf("SetEncryptionKey(%q) passed", secret)
+		}
+	}
+}
+
+func newTestKeyRing(t *testing.T, store *memdb.Store, secret string) *KeyRing {
+	t.Helper()
+	keys, err := NewKeyRing(store, AlgEdDSA, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "" {
+		if err := keys.SetEncryptionKey(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := keys.Rotate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
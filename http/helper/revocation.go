@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"context"
+	"time"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RevokeToken puts a single access token on the revocation list until it expires
+func RevokeToken(ctx context.Context, queries database.Querier, jti string, userID int64, expiresAt time.Time) error {
+	return queries.RevokeToken(ctx, database.RevokeTokenParams{
+		Jti:       jti,
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt.UTC(), Valid: true},
+	})
+}
+
+// ExpireAccessTokens revokes every access token of the user issued until now, the refresh tokens keep working.
+// Used when the roles change, the next refresh issues a token with the new claims.
+// "accessTTL" is how long the cutoff has to be kept.
+// "iat" has a one second precision, a token issued in the same second as the cutoff keeps
+// working so the refresh that follows the change isn't revoked with it.
+func ExpireAccessTokens(ctx context.Context, queries database.Querier, userID int64, accessTTL time.Duration) error {
+	return cutOffAccessTokens(ctx, queries, userID, time.Now().UTC().Truncate(time.Second), accessTTL)
+}
+
+// RevokeAllTokens logs a user out everywhere: every access token issued until now
+// and every refresh token stop working.
+// The cutoff is rounded up to the next second, a token issued in its second goes with the others.
+func RevokeAllTokens(ctx context.Context, queries database.Querier, userID int64, accessTTL time.Duration) error {
+	notBefore := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+	if err := cutOffAccessTokens(ctx, queries, userID, notBefore, accessTTL); err != nil {
+		return err
+	}
+
+	return queries.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// cutOffAccessTokens revokes the access tokens of the user issued before "notBefore"
+func cutOffAccessTokens(ctx context.Context, queries database.Querier, userID int64, notBefore time.Time, accessTTL time.Duration) error {
+	return queries.RevokeUserTokens(ctx, database.RevokeUserTokensParams{
+		UserID:    userID,
+		NotBefore: pgtype.Timestamp{Time: notBefore, Valid: true},
+		ExpiresAt: pgtype.Timestamp{Time: notBefore.Add(accessTTL), Valid: true},
+	})
+}
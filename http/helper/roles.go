@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"context"
+
+	"server/sql/database"
+)
+
+// Roles the code refers to by name
+const (
+	// DefaultRole is handed to every new user
+	DefaultRole    = "employee"
+	AdminRole      = "admin"
+	SuperadminRole = "superadmin"
+)
+
+// UserAccess loads the roles of the user and the permissions they grant, as embedded in the access token
+func UserAccess(ctx context.Context, queries database.Querier, userID int64) (roles []string, permissions []string, err error) {
+	roles, err = queries.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	permissions, err = queries.ListUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, permissions, nil
+}
+
+// AssignRole hands the role "name" to the user, a no-op if they already hold it
+func AssignRole(ctx context.Context, queries database.Querier, userID int64, name string) error {
+	role, err := queries.GetRoleByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = queries.AssignUserRole(ctx, database.AssignUserRoleParams{UserID: userID, RoleID: role.ID})
+	return err
+}
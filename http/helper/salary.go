@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"context"
+	"time"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DateLayout is how salary effective dates travel in JSON and query strings
+const DateLayout = "2006-01-02"
+
+// SalaryChangeReasons are the reason codes "chk_salary_history_reason" accepts
+var SalaryChangeReasons = []string{
+	"hire", "promotion", "merit", "market", "cost_of_living", "adjustment", "correction", "demotion",
+}
+
+// Today is the current UTC date, salary changes take effect at the start of their day
+func Today() pgtype.Date {
+	y, m, d := time.Now().UTC().Date()
+	return pgtype.Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+// ParseDate reads a "YYYY-MM-DD" date
+func ParseDate(value string) (pgtype.Date, error) {
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return pgtype.Date{}, err
+	}
+	return pgtype.Date{Time: t, Valid: true}, nil
+}
+
+// RecordSalaryChange appends to the salary history of the employee, a change already in effect
+// becomes their current salary right away, a later one is picked up by the scheduled job
+func RecordSalaryChange(ctx context.Context, queries database.Querier, arg database.CreateSalaryChangeParams) (*database.SalaryHistory, error) {
+	change, err := queries.CreateSalaryChange(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = queries.ApplyEffectiveSalaries(ctx, database.ApplyEffectiveSalariesParams{
+		AsOf:       Today(),
+		EmployeeID: &arg.EmployeeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
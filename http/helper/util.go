@@ -2,22 +2,19 @@ package helper
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Gracefull Teriminate Server
-func GracefulShutdown(server *http.Server, done chan bool, db *pgx.Conn) {
+func GracefulShutdown(server *http.Server, done chan bool, db *pgxpool.Pool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -33,60 +30,17 @@ func GracefulShutdown(server *http.Server, done chan bool, db *pgx.Conn) {
 	}
 	log.Println("Server Stopped 🔴")
 
-	// Close DB Connection
-	err := db.Close(ctx)
-	if err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+	// Close DB Pool, waits for in-flight queries to release their connections
+	// (nil when running on the in-memory store)
+	if db != nil {
+		db.Close()
+		log.Println("Database Closed 🔴")
 	}
-	log.Println("Database Closed 🔴")
 
 	// Notify the main goroutine that the shutdown is complete
 	done <- true
 }
 
-func FloatToNumeric(f float64, precision int) (pgtype.Numeric, error) {
-	var numericValue pgtype.Numeric
-
-	// Format the float to a string with the desired precision
-	// 'f' format specifier, precision specifies the number of digits after the decimal point
-	str := strconv.FormatFloat(f, 'f', precision, 64)
-
-	// Use Scan to parse the string into the pgtype.Numeric struct
-	if err := numericValue.Scan(str); err != nil {
-		return pgtype.Numeric{}, fmt.Errorf("failed to scan string to pgtype.Numeric: %w", err)
-	}
-
-	return numericValue, nil
-}
-
-// CalculateNetSalary computes the take-home pay after all deductions.
-// Formula: Net Salary = Gross Salary - Total Deductions
-func CalculateNetSalary(grossSalary, totalDeductions float64) float64 {
-	// Ensure net salary isn't negative in edge cases
-	if totalDeductions > grossSalary {
-		return 0
-	}
-	return grossSalary - totalDeductions
-}
-
-// CalculatePercentage computes what 'percent' of 'total' is.
-func CalculatePercentage(percent, total float64) float64 {
-	return (total * percent) / 100
-}
-
-func GetTaxRatePerCountry(country string) float64 {
-	// extract country tax from ".env"
-	countryCleaned := strings.ToLower(country)
-	taxRate := GetEnv(countryCleaned, "")
-
-	taxRateFloat, err := strconv.ParseFloat(taxRate, 64)
-	if err != nil {
-		return 0.0
-	}
-
-	return taxRateFloat
-}
-
 // getEnv retrieves environment variables or returns a default value
 func GetEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -105,3 +59,25 @@ func GetEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// GetEnvDuration retrieves duration environment variables (e.g. "30s", "5m") or returns a default value
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		durationValue, err := time.ParseDuration(value)
+		if err == nil {
+			return durationValue
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvBool retrieves boolean environment variables ("true", "1", ...) or returns a default value
+func GetEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
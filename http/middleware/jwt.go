@@ -2,9 +2,16 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"server/http/helper"
+	"server/http/response"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // Key type for context values
@@ -14,53 +21,155 @@ type (
 		ID       int64
 		Email    string
 		Username string
+
+		// Granted when the token was issued
+		Roles       []string
+		Permissions []string
+
+		// Token the request was authenticated with
+		TokenID   string
+		IssuedAt  time.Time
+		ExpiresAt time.Time
 	}
 )
 
 const (
 	// UserIDKey is the key for user ID in the request context
 	UserCtx contextUserKey = "user"
+
+	// realm advertised in "WWW-Authenticate"
+	bearerRealm = "employee-crud"
 )
 
-// JWTMiddleware is a middleware that checks for a valid JWT cookie,
-// extracts user info from the token, and stores it in the context.
-func JWTMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract the JWT token from the "jwt" cookie
-		cookie, err := r.Cookie("jwt")
-		if err != nil {
-			http.Error(w, "Missing or invalid JWT cookie", http.StatusUnauthorized)
-			return
-		}
+// JWTMiddleware is a middleware that checks for a valid JWT bearer token or cookie,
+// rejects tokens on the revocation list, extracts user info from the token,
+// and stores it in the context.
+func JWTMiddleware(queries database.Querier, keys *helper.KeyRing) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// "Authorization: Bearer <token>" wins over the "jwt" cookie
+			tokenString, err := extractToken(r)
+			if err != nil {
+				bearerError(w, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			if tokenString == "" {
+				bearerError(w, http.StatusUnauthorized, "", "")
+				return
+			}
 
-		// Verify the token and extract claims
-		tokenString := cookie.Value
-		claims, err := helper.VerifyToken(tokenString)
-		if err != nil {
-			http.Error(w, "Invalid or expired JWT token", http.StatusUnauthorized)
-			return
-		}
+			// Verify the token and extract claims
+			claims, err := keys.VerifyToken(tokenString)
+			if err != nil {
+				bearerError(w, http.StatusUnauthorized, "invalid_token", "The access token is invalid or expired")
+				return
+			}
 
-		// Extract user info from the claims
-		email, ok := claims["email"].(string)
-		if !ok {
-			http.Error(w, "Invalid token payload1", http.StatusUnauthorized)
-			return
+			// Extract user info from the claims
+			email, ok := claims["email"].(string)
+			if !ok {
+				bearerError(w, http.StatusUnauthorized, "invalid_token", "The access token payload is invalid")
+				return
+			}
+			username, _ := claims["username"].(string)
+			id, _ := claims["id"].(float64)
+			jti, _ := claims["jti"].(string)
+			issuedAt, errIat := claims.GetIssuedAt()
+			expiresAt, errExp := claims.GetExpirationTime()
+			if jti == "" || errIat != nil || issuedAt == nil || errExp != nil || expiresAt == nil {
+				bearerError(w, http.StatusUnauthorized, "invalid_token", "The access token payload is invalid")
+				return
+			}
+
+			userInfo := UserInfo{
+				Email:       email,
+				Username:    username,
+				ID:          int64(id),
+				Roles:       stringsClaim(claims, "roles"),
+				Permissions: stringsClaim(claims, "permissions"),
+				TokenID:     jti,
+				IssuedAt:    issuedAt.Time,
+				ExpiresAt:   expiresAt.Time,
+				// Add more fields as needed
+			}
+
+			// Logged out token, or issued before a "log out everywhere"
+			revoked, err := queries.IsTokenRevoked(r.Context(), database.IsTokenRevokedParams{
+				Jti:      userInfo.TokenID,
+				UserID:   userInfo.ID,
+				IssuedAt: pgtype.Timestamp{Time: userInfo.IssuedAt.UTC(), Valid: true},
+			})
+			if err != nil {
+				response.RespondeWithFailure(w, err, "Couldnot check JWT token")
+				return
+			}
+			if revoked {
+				bearerError(w, http.StatusUnauthorized, "invalid_token", "The access token has been revoked")
+				return
+			}
+
+			// Store the user info in the context
+			ctx := context.WithValue(r.Context(), UserCtx, userInfo)
+
+			// Call the next handler with the updated context
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// stringsClaim reads a JSON array of strings, tokens issued before it existed have none
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	raw, _ := claims[name].([]interface{})
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
 		}
+	}
+	return values
+}
 
-		userInfo := UserInfo{
-			Email:    email,
-			Username: claims["username"].(string),
-			ID:       int64(claims["id"].(float64)),
-			// Add more fields as needed
+// extractToken reads the token from the Authorization header, falling back to the "jwt" cookie.
+// A present but malformed Authorization header is an error rather than silently ignored.
+func extractToken(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", fmt.Errorf("the Authorization header must use the Bearer scheme")
 		}
+		return token, nil
+	}
 
-		// Store the user info in the context
-		ctx := context.WithValue(r.Context(), UserCtx, userInfo)
+	cookie, err := r.Cookie("jwt")
+	if err != nil {
+		return "", nil
+	}
+	return cookie.Value, nil
+}
 
-		// Call the next handler with the updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// bearerError answers with a RFC 6750 "WWW-Authenticate" challenge,
+// without an error code when no credentials were sent at all. The problem
+// in the body carries the same code.
+func bearerError(w http.ResponseWriter, status int, code string, description string) {
+	challenge := `Bearer realm="` + bearerRealm + `"`
+	if code != "" {
+		challenge += `, error="` + code + `"`
+	}
+	if description != "" {
+		challenge += `, error_description="` + description + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	message := description
+	if message == "" {
+		message = "Missing bearer token or JWT cookie"
+	}
+	problemCode := response.CodeFor(status)
+	if code != "" {
+		problemCode = response.Code(code)
+	}
+	response.RespondeWithCode(w, status, problemCode, message)
 }
 
 // GetUserFromContext retrieves the UserInfo stored in the context.
@@ -28,6 +28,7 @@ func ZapMiddleware(logger *zap.Logger) func(next http.Handler) http.Handler {
 				logger.Info("request completed",
 					zap.String("method", r.Method),
 					zap.String("path", r.URL.Path),
+					zap.String("request_id", GetRequestID(r.Context())),
 					zap.Duration("duration", time.Since(start)),
 				)
 			}()
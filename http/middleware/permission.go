@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"server/http/response"
+)
+
+// HasPermission reports whether the token carries "permission"
+func (u *UserInfo) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
+}
+
+// RequirePermission lets the request through only if the token carries "permission", e.g. "employees:write".
+// Runs after JWTMiddleware, the permissions come from the token claims so no DB lookup is needed.
+func RequirePermission(permission string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userInfo, ok := GetUserFromContext(r.Context())
+			if !ok {
+				response.RespondeWithError(w, http.StatusInternalServerError, "RequirePermission :- GetUserFromContext Issue ")
+				return
+			}
+
+			if !userInfo.HasPermission(permission) {
+				bearerError(w, http.StatusForbidden, "insufficient_scope", "The access token lacks the "+permission+" permission")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type contextRequestIDKey string
+
+const (
+	// RequestIDCtx is the key for the request ID in the request context
+	RequestIDCtx contextRequestIDKey = "request_id"
+
+	// RequestIDHeader is read from the client and echoed back
+	RequestIDHeader = "X-Request-Id"
+)
+
+// RequestID tags every request with an ID, the client's "X-Request-Id" when it looks sane,
+// so a log line or an audit entry can be traced back to the call
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDCtx, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID retrieves the request ID stored in the context, "" outside of a request
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDCtx).(string)
+	return id
+}
+
+// validRequestID accepts up to 64 characters of [A-Za-z0-9._-]
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
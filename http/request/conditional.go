@@ -0,0 +1,60 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server/http/response"
+)
+
+// ETag is the strong entity tag of a row at "version", `"3"`
+func ETag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+// CheckIfMatch tells whether the change may go ahead: without "If-Match" it always may, with it
+// only when one of its tags is "etag" (or it is "*"). Otherwise it answers 412 and returns false.
+func CheckIfMatch(w http.ResponseWriter, r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || matchesETag(header, etag, false) {
+		return true
+	}
+	w.Header().Set("ETag", etag)
+	response.RespondeWithCode(w, http.StatusPreconditionFailed, response.CodePreconditionFailed,
+		"changed since it was read, fetch it again: its ETag is now "+etag)
+	return false
+}
+
+// NotModified sets the "ETag" of the response and, when "If-None-Match" names it, answers 304
+// without a body and returns true
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !matchesETag(header, etag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// matchesETag looks "etag" up in a list of tags (RFC 9110 13.1). "If-Match" compares strongly,
+// a weak W/ tag never matches, "If-None-Match" weakly, the W/ is ignored.
+func matchesETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
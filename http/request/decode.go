@@ -0,0 +1,164 @@
+// Package request reads request bodies the same way for every handler.
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"server/http/response"
+	"server/patch"
+	"server/validate"
+)
+
+// MaxBodySize bounds a JSON body, the file imports have their own limits
+const MaxBodySize = 1 << 20
+
+var errTrailingData = errors.New("json: only one JSON value is allowed")
+
+// Normalizer brings a body to the form it is checked and stored in (trimmed, codes upper cased)
+type Normalizer interface {
+	Normalize()
+}
+
+// DecodeJSON reads the JSON body into "v", normalizes it and checks its "validate" tags. When
+// it can't, it answers the problem itself and returns false: 413 over MaxBodySize, 400 for
+// anything but a single JSON value or for an unknown field, 422 for a value of the wrong type
+// and listing every field at fault.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeStrict(w, http.MaxBytesReader(w, r.Body, MaxBodySize), v)
+}
+
+// DecodePatch applies the patch in the body to "v", which holds the current state, and checks
+// the result as DecodeJSON does. The Content-Type tells the kind of patch: a JSON Merge Patch
+// ("application/merge-patch+json", or plain "application/json") or a JSON Patch
+// ("application/json-patch+json"); anything else is 415. A malformed patch is 400, a "test"
+// that didn't hold 409 and a path that doesn't exist 422.
+func DecodePatch(w http.ResponseWriter, r *http.Request, v any) bool {
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case patch.MergePatchType, "application/json":
+		apply = patch.Merge
+	case patch.JSONPatchType:
+		apply = patch.Apply
+	default:
+		w.Header().Set("Accept-Patch", AcceptPatch)
+		response.RespondeWithError(w, http.StatusUnsupportedMediaType, "the body must be a "+patch.MergePatchType+" or a "+patch.JSONPatchType)
+		return false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err == nil && len(bytes.TrimSpace(body)) == 0 {
+		err = io.EOF
+	}
+	if err != nil {
+		decodeError(w, err)
+		return false
+	}
+
+	current, err := json.Marshal(v)
+	if err != nil {
+		response.RespondeWithFailure(w, err, "couldnot read the current state")
+		return false
+	}
+	patched, err := apply(current, body)
+	switch {
+	case errors.Is(err, patch.ErrMalformed):
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidJSON, err.Error())
+		return false
+	case errors.Is(err, patch.ErrTestFailed):
+		response.RespondeWithCode(w, http.StatusConflict, response.CodeConflict, err.Error())
+		return false
+	case err != nil:
+		response.RespondeWithCode(w, http.StatusUnprocessableEntity, response.CodeValidation, "couldnot apply the patch: "+err.Error())
+		return false
+	}
+
+	// What the patch left out is gone, not kept from the current state
+	reflect.ValueOf(v).Elem().SetZero()
+	return decodeStrict(w, bytes.NewReader(patched), v)
+}
+
+// AcceptPatch lists the patches DecodePatch understands, for the "Accept-Patch" header
+const AcceptPatch = patch.MergePatchType + ", " + patch.JSONPatchType
+
+// decodeStrict reads a single JSON value without unknown fields into "v" and checks it
+func decodeStrict(w http.ResponseWriter, body io.Reader, v any) bool {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil {
+		// Anything after the value, JSON or not
+		if _, extra := decoder.Token(); extra != io.EOF {
+			err = errTrailingData
+		}
+	}
+	if err != nil {
+		decodeError(w, err)
+		return false
+	}
+
+	if normalizer, ok := v.(Normalizer); ok {
+		normalizer.Normalize()
+	}
+	if errs := validate.Struct(v); len(errs) > 0 {
+		response.RespondeWithValidationErrors(w, errs)
+		return false
+	}
+	return true
+}
+
+// decodeError says what's wrong with the JSON, naming the field when there is one
+func decodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &tooLarge):
+		response.RespondeWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the body is larger than %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF):
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidJSON, "the body is empty, a JSON object is expected")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidJSON, "invalid json: the body ends too early")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		response.RespondeWithFieldErrors(w, "invalid json", []response.FieldError{
+			{Field: typeErr.Field, Code: "type", Message: "must be " + jsonType(typeErr.Type.Kind().String())},
+		})
+	case errors.As(err, &syntaxErr):
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidJSON, "invalid json at byte "+strconv.FormatInt(syntaxErr.Offset, 10))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		p := response.NewProblem(http.StatusBadRequest, response.CodeInvalidJSON, "unknown field "+strconv.Quote(field))
+		p.Errors = []response.FieldError{{Field: field, Code: "unknown", Message: "not a field of this body"}}
+		response.RespondeWithProblem(w, p)
+	case strings.HasPrefix(err.Error(), "json: "):
+		response.RespondeWithCode(w, http.StatusBadRequest, response.CodeInvalidJSON, "invalid json: "+strings.TrimPrefix(err.Error(), "json: "))
+	default:
+		// A value its type refused, e.g. an amount that isn't a decimal number
+		response.RespondeWithCode(w, http.StatusUnprocessableEntity, response.CodeValidation, "invalid value: "+err.Error())
+	}
+}
+
+// jsonType names a Go kind the way JSON does
+func jsonType(kind string) string {
+	switch {
+	case kind == "string":
+		return "a string"
+	case kind == "bool":
+		return "true or false"
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "slice", kind == "array":
+		return "an array"
+	}
+	return "an object"
+}
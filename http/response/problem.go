@@ -0,0 +1,200 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"server/validate"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ProblemContentType is the media type of every error body, RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes the code of a failure into the "type" of its problem
+const ProblemTypeBase = "urn:employee-crud:problem:"
+
+// requestIDHeader is set on the response by middleware.RequestID before any handler runs
+const requestIDHeader = "X-Request-Id"
+
+// Code is the stable, machine-readable reason of a failure. Messages may be reworded,
+// codes don't change: clients match on them.
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeInvalidJSON          Code = "invalid_json"
+	CodeValidation           Code = "validation_failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodeConflict             Code = "conflict"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodeAlreadyExists        Code = "already_exists"
+	CodeInvalidReference     Code = "invalid_reference"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeRateLimited          Code = "rate_limited"
+	CodeInternal             Code = "internal"
+	CodeUnavailable          Code = "unavailable"
+
+	// The error codes of a RFC 6750 bearer token challenge
+	CodeInvalidRequest    Code = "invalid_request"
+	CodeInvalidToken      Code = "invalid_token"
+	CodeInsufficientScope Code = "insufficient_scope"
+)
+
+// CodeFor is the code of a failure nothing more specific is known about than its status
+func CodeFor(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Problem is a RFC 7807 problem detail, extended with the code, the request ID and the fields at fault
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Error repeats the detail for clients still reading {"error": "..."}
+	Error string `json:"error"`
+}
+
+// FieldError blames a single field of the request, "field" is its JSON name ("salary", "address.city")
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewProblem is a problem of "status", titled after it
+func NewProblem(status int, code Code, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeBase + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// RespondeWithProblem sends "p" as "application/problem+json", tagged with the request ID
+func RespondeWithProblem(w http.ResponseWriter, p *Problem) {
+	if p.Status > 499 {
+		log.Println("Reading with 5xx error: ", p.Detail)
+	}
+	p.RequestID = w.Header().Get(requestIDHeader)
+	p.Error = p.Detail
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("Failed to marshell problem : %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
+
+// RespondeWithCode answers with a problem of a specific code
+func RespondeWithCode(w http.ResponseWriter, status int, code Code, message string) {
+	RespondeWithProblem(w, NewProblem(status, code, message))
+}
+
+// RespondeWithFieldErrors answers 422 listing every field at fault at once
+func RespondeWithFieldErrors(w http.ResponseWriter, message string, errs []FieldError) {
+	p := NewProblem(http.StatusUnprocessableEntity, CodeValidation, message)
+	p.Errors = errs
+	RespondeWithProblem(w, p)
+}
+
+// RespondeWithValidationErrors answers 422 with the fields breaking their "validate" tags
+func RespondeWithValidationErrors(w http.ResponseWriter, errs validate.Errors) {
+	fields := make([]FieldError, len(errs))
+	for i, err := range errs {
+		fields[i] = FieldError{Field: err.Field, Code: err.Rule, Message: err.Message}
+	}
+	RespondeWithFieldErrors(w, errs.Error(), fields)
+}
+
+// RespondeWithFailure answers for "err", which "message" describes ("couldnot create employee").
+// A database error maps to what it means for the client: no rows is 404, a unique violation 409,
+// a foreign key or check violation 422 and a trigger refusing the change 409. Anything else is a
+// 500. "err" itself is only logged, never sent.
+func RespondeWithFailure(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		RespondeWithCode(w, http.StatusNotFound, CodeNotFound, message+": not found")
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			RespondeWithCode(w, http.StatusConflict, CodeAlreadyExists, message+": "+constraintDetail(pgErr, "already exists"))
+			return
+		case pgErr.Code == "23503":
+			RespondeWithCode(w, http.StatusUnprocessableEntity, CodeInvalidReference, message+": "+constraintDetail(pgErr, "references a missing record"))
+			return
+		// The rest of class 23 (check, not null) and class 22 are values the database refused
+		case strings.HasPrefix(pgErr.Code, "23"), strings.HasPrefix(pgErr.Code, "22"):
+			RespondeWithCode(w, http.StatusUnprocessableEntity, CodeValidation, message+": "+constraintDetail(pgErr, "invalid value"))
+			return
+		// RAISE EXCEPTION of our triggers, written for people
+		case pgErr.Code == "P0001":
+			RespondeWithCode(w, http.StatusConflict, CodeConflict, message+": "+pgErr.Message)
+			return
+		}
+	}
+
+	log.Printf("%s: %v", message, err)
+	RespondeWithCode(w, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// constraintDetail names the constraint at fault, not the SQL that broke it
+func constraintDetail(pgErr *pgconn.PgError, what string) string {
+	if pgErr.ConstraintName == "" {
+		return what
+	}
+	return what + " (" + pgErr.ConstraintName + ")"
+}
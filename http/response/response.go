@@ -6,25 +6,16 @@ import (
 	"net/http"
 )
 
-type errResponse struct{
-	Error string 	`json:"error"`
-}
-
+// RespondeWithError answers with a problem coded after the status, see RespondeWithCode for a
+// more specific code
 func RespondeWithError(w http.ResponseWriter, code int, message string) {
-	if code > 499 {
-		log.Println("Reading with 5xx error: ", message)
-	}
-
-	RespondeWithJSON(w, code, errResponse{
-		Error: message,
-	})
-	 
+	RespondeWithCode(w, code, CodeFor(code), message)
 }
 
 func RespondeWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Println("Failed to marshell Json response : %v", err)
+		log.Printf("Failed to marshell Json response : %v", err)
 		w.WriteHeader(500)
 		return
 	}
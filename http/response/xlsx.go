@@ -0,0 +1,61 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server/sheet"
+)
+
+// WantsXLSX tells whether the request asks for a spreadsheet instead of JSON: "format=xlsx",
+// or an Accept header preferring the XLSX type. "format=json" always gets JSON.
+func WantsXLSX(w http.ResponseWriter, r *http.Request) bool {
+	// The same URL answers in two formats, caches have to key on Accept
+	w.Header().Add("Vary", "Accept")
+
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "xlsx":
+		return true
+	case "json":
+		return false
+	}
+
+	var xlsx, other float64
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accepted)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if mediaType == sheet.ContentType {
+			xlsx = max(xlsx, q)
+		} else {
+			other = max(other, q)
+		}
+	}
+	return xlsx > 0 && xlsx >= other
+}
+
+// RespondeWithXLSX sends the workbook as the attachment "filename"
+func RespondeWithXLSX(w http.ResponseWriter, filename string, book *sheet.Workbook) {
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		RespondeWithError(w, http.StatusInternalServerError, fmt.Sprintf("couldnot write spreadsheet %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", sheet.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,460 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"server/audit"
+	"server/config"
+	"server/http/helper"
+	"server/money"
+	"server/sql/database"
+	"server/sql/memdb"
+
+	"go.uber.org/zap"
+)
+
+// testClient keeps the session cookies of one user across requests
+type testClient struct {
+	t       *testing.T
+	router  http.Handler
+	cookies map[string]*http.Cookie
+}
+
+// requestSeq gives each request an IP of its own, the API allows 10 requests a minute per IP
+var requestSeq int
+
+func newTestClient(t *testing.T, router http.Handler) *testClient {
+	return &testClient{t: t, router: router, cookies: map[string]*http.Cookie{}}
+}
+
+func (c *testClient) do(method, path, body string) (int, []byte) {
+	return c.send(method, path, "application/json", body)
+}
+
+// doPatch sends a JSON Patch
+func (c *testClient) doPatch(path, patch string) (int, []byte) {
+	return c.send("PATCH", path, "application/json-patch+json", patch)
+}
+
+func (c *testClient) send(method, path, contentType, body string) (int, []byte) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	requestSeq++
+	req.RemoteAddr = fmt.Sprintf("10.%d.%d.%d:1234", requestSeq/62500, requestSeq/250%250, requestSeq%250)
+	if body != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	c.router.ServeHTTP(rec, req)
+	for _, cookie := range rec.Result().Cookies() {
+		c.cookies[cookie.Name] = cookie
+	}
+	return rec.Code, rec.Body.Bytes()
+}
+
+// expect sends the request and fails the test unless it is answered "want"
+func (c *testClient) expect(want int, method, path, body string) []byte {
+	c.t.Helper()
+	got, b := c.do(method, path, body)
+	if got != want {
+		c.t.Fatalf("%s %s: %d %s, want %d", method, path, got, b, want)
+	}
+	return b
+}
+
+// newTestServer is the router on an empty in-memory store, with a superadmin logged in
+func newTestServer(t *testing.T) (http.Handler, *memdb.Store, *testClient) {
+	t.Helper()
+	ctx := context.Background()
+	store := memdb.New()
+	keys, err := helper.NewKeyRing(store, "EdDSA", time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	router := InitRouter(zap.NewNop(), store, audit.New(store, zap.NewNop()), keys, &config.Config{
+		ReportingCurrency: "USD",
+		AccessTokenTTL:    time.Minute,
+		RefreshTokenTTL:   time.Hour,
+	})
+
+	if _, err := helper.BootstrapSuperadmin(ctx, store, "root", "root@example.com", "Corr3ct-Horse"); err != nil {
+		t.Fatal(err)
+	}
+	root := newTestClient(t, router)
+	root.expect(http.StatusOK, "POST", "/v1/login", `{"username":"root","password":"Corr3ct-Horse"}`)
+	return router, store, root
+}
+
+type testEmployee struct {
+	jobTitle string
+	salary   int64
+}
+
+// seedEmployees creates a user and an employee for each, the ids follow the order given
+func seedEmployees(t *testing.T, store *memdb.Store, employees ...testEmployee) {
+	t.Helper()
+	ctx := context.Background()
+	for i, e := range employees {
+		user, err := store.CreateUser(ctx, database.CreateUserParams{
+			Username: fmt.Sprintf("user%d", i),
+			Email:    fmt.Sprintf("user%d@example.com", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.CreateEmployee(ctx, database.CreateEmployeeParams{
+			UserID:   int64(user.ID),
+			JobTitle: e.jobTitle,
+			Country:  "US",
+			Salary:   money.NewAmount(e.salary).Numeric(),
+			Currency: "USD",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type employeePage struct {
+	Employees []struct {
+		ID        int32      `json:"id"`
+		DeletedAt *time.Time `json:"deleted_at"`
+	} `json:"employees"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// listEmployeeIDs follows the cursors from the first page to the last
+func listEmployeeIDs(c *testClient, query url.Values) []int32 {
+	c.t.Helper()
+	var ids []int32
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			c.t.Fatalf("%s: the cursors never end", query.Encode())
+		}
+		var page employeePage
+		if err := json.Unmarshal(c.expect(http.StatusOK, "GET", "/v1/admin/employees?"+query.Encode(), ""), &page); err != nil {
+			c.t.Fatal(err)
+		}
+		for _, e := range page.Employees {
+			ids = append(ids, e.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+var keysetEmployees = []testEmployee{
+	{"dev", 300}, // 1
+	{"ops", 100}, // 2
+	{"dev", 100}, // 3
+	{"qa", 200},  // 4
+	{"ops", 300}, // 5
+	{"dev", 50},  // 6
+	{"qa", 100},  // 7
+}
+
+// Pages of every sort add up to the whole list in order, ties broken by id in the same direction
+func TestListEmployeesKeyset(t *testing.T) {
+	_, store, root := newTestServer(t)
+	seedEmployees(t, store, keysetEmployees...)
+
+	tests := []struct {
+		sort string
+		want []int32
+	}{
+		{"", []int32{1, 2, 3, 4, 5, 6, 7}},
+		{"-salary", []int32{5, 1, 4, 7, 3, 2, 6}},
+		{"salary", []int32{6, 2, 3, 7, 4, 1, 5}},
+		{"job_title", []int32{1, 3, 6, 2, 5, 4, 7}},
+		{"-job_title", []int32{7, 4, 5, 2, 6, 3, 1}},
+	}
+	for _, tt := range tests {
+		for _, limit := range []string{"1", "2", "3", "7", "100"} {
+			query := url.Values{"limit": {limit}}
+			if tt.sort != "" {
+				query.Set("sort", tt.sort)
+			}
+			if got := listEmployeeIDs(root, query); !slices.Equal(got, tt.want) {
+				t.Errorf("sort=%q limit=%s: %v, want %v", tt.sort, limit, got, tt.want)
+			}
+		}
+	}
+
+	// Filters apply to every page
+	query := url.Values{"limit": {"2"}, "sort": {"-salary"}, "min_salary": {"100"}, "max_salary": {"250"}}
+	if got, want := listEmployeeIDs(root, query), []int32{4, 7, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("100 <= salary <= 250: %v, want %v", got, want)
+	}
+
+	root.expect(http.StatusBadRequest, "GET", "/v1/admin/employees?sort=password", "")
+	root.expect(http.StatusBadRequest, "GET", "/v1/admin/employees?cursor=not-a-cursor", "")
+}
+
+// A cursor keeps its place when the rows around it are deleted
+func TestListEmployeesKeysetAcrossDelete(t *testing.T) {
+	_, store, root := newTestServer(t)
+	seedEmployees(t, store, keysetEmployees...)
+
+	var first employeePage
+	if err := json.Unmarshal(root.expect(http.StatusOK, "GET", "/v1/admin/employees?sort=-salary&limit=3", ""), &first); err != nil {
+		t.Fatal(err)
+	}
+	// 5, 1, 4 on the first page, 7 would open the next one
+	root.expect(http.StatusOK, "DELETE", "/v1/admin/employees/7", "")
+	root.expect(http.StatusOK, "DELETE", "/v1/admin/employees/1", "")
+
+	query := url.Values{"sort": {"-salary"}, "limit": {"3"}, "cursor": {first.NextCursor}}
+	if got, want := listEmployeeIDs(root, query), []int32{3, 2, 6}; !slices.Equal(got, want) {
+		t.Errorf("after the first page: %v, want %v", got, want)
+	}
+}
+
+// Deleted employees are only listed, and restored, through their own filter
+func TestSoftDeleteFilters(t *testing.T) {
+	_, store, root := newTestServer(t)
+	seedEmployees(t, store, keysetEmployees[:3]...)
+
+	root.expect(http.StatusOK, "DELETE", "/v1/admin/employees/2", "")
+	root.expect(http.StatusNotFound, "GET", "/v1/admin/employees/2", "")
+	root.expect(http.StatusNotFound, "DELETE", "/v1/admin/employees/2", "")
+
+	if got, want := listEmployeeIDs(root, url.Values{}), []int32{1, 3}; !slices.Equal(got, want) {
+		t.Errorf("live employees %v, want %v", got, want)
+	}
+	var deleted employeePage
+	if err := json.Unmarshal(root.expect(http.StatusOK, "GET", "/v1/admin/employees?deleted=true", ""), &deleted); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted.Employees) != 1 || deleted.Employees[0].ID != 2 || deleted.Employees[0].DeletedAt == nil {
+		t.Errorf("deleted employees %+v, want only 2 with its deleted_at", deleted.Employees)
+	}
+	root.expect(http.StatusBadRequest, "GET", "/v1/admin/employees?deleted=maybe", "")
+
+	// Only a deleted employee is restored
+	root.expect(http.StatusNotFound, "POST", "/v1/admin/employees/1/restore", "")
+	root.expect(http.StatusOK, "POST", "/v1/admin/employees/2/restore", "")
+	root.expect(http.StatusOK, "GET", "/v1/admin/employees/2", "")
+	if got, want := listEmployeeIDs(root, url.Values{"deleted": {"true"}}), []int32(nil); !slices.Equal(got, want) {
+		t.Errorf("deleted employees %v after the restore, want none", got)
+	}
+}
+
+// Deleting a user hides them and their employee profile, restoring brings both back
+func TestSoftDeleteUser(t *testing.T) {
+	router, _, root := newTestServer(t)
+
+	user := newTestClient(t, router)
+	user.expect(http.StatusOK, "POST", "/v1/register", `{"username":"jane","email":"jane@example.com","password":"Corr3ct-Horse"}`)
+	user.expect(http.StatusCreated, "POST", "/v1/emp/new", `{"job_title":"dev","country":"US","currency":"USD","salary":100}`)
+
+	b := root.expect(http.StatusOK, "DELETE", "/v1/admin/users/2", "")
+	if !bytes.Contains(b, []byte(`"employee_id":1`)) {
+		t.Errorf("deleted user %s, want their employee 1 with them", b)
+	}
+	user.expect(http.StatusUnauthorized, "GET", "/v1/status", "")
+	newTestClient(t, router).expect(http.StatusBadRequest, "POST", "/v1/login", `{"username":"jane","password":"Corr3ct-Horse"}`)
+	root.expect(http.StatusNotFound, "GET", "/v1/admin/employees/1", "")
+	root.expect(http.StatusNotFound, "DELETE", "/v1/admin/users/2", "")
+
+	// The deleted profile went with the user, it comes back with them only
+	root.expect(http.StatusConflict, "POST", "/v1/admin/employees/1/restore", "")
+	root.expect(http.StatusOK, "POST", "/v1/admin/users/2/restore", "")
+	root.expect(http.StatusOK, "GET", "/v1/admin/employees/1", "")
+	user.expect(http.StatusOK, "POST", "/v1/login", `{"username":"jane","password":"Corr3ct-Horse"}`)
+	user.expect(http.StatusOK, "GET", "/v1/emp/details", "")
+}
+
+// The refresh token comes from the cookie or from a body read like every other one
+func TestRefreshTokenBody(t *testing.T) {
+	router, _, _ := newTestServer(t)
+
+	client := newTestClient(t, router)
+	b := client.expect(http.StatusOK, "POST", "/v1/login", `{"username":"root","password":"Corr3ct-Horse","return_token":true}`)
+	var login struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(b, &login); err != nil || login.RefreshToken == "" {
+		t.Fatalf("login %s: %v", b, err)
+	}
+
+	bare := newTestClient(t, router)
+	bare.expect(http.StatusUnauthorized, "POST", "/v1/token/refresh", "")
+	bare.expect(http.StatusBadRequest, "POST", "/v1/token/refresh", `{"refresh_token":"`+login.RefreshToken+`","extra":1}`)
+	bare.expect(http.StatusBadRequest, "POST", "/v1/token/refresh", `{"refresh_token":`)
+	bare.expect(http.StatusOK, "POST", "/v1/token/refresh", `{"refresh_token":"`+login.RefreshToken+`"}`)
+}
+
+// Replaying a rotated refresh token revokes every token descending from the same login
+func TestRefreshTokenReuse(t *testing.T) {
+	router, store, _ := newTestServer(t)
+
+	// A client of its own for every request, the tokens only go through the bodies
+	send := func(want int, path, body string) string {
+		t.Helper()
+		b := newTestClient(t, router).expect(want, "POST", path, body)
+		var tokens struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if want == http.StatusOK && (json.Unmarshal(b, &tokens) != nil || tokens.RefreshToken == "") {
+			t.Fatalf("POST %s: %s, want the tokens", path, b)
+		}
+		return tokens.RefreshToken
+	}
+	login := `{"username":"root","password":"Corr3ct-Horse","return_token":true}`
+	refresh := func(token string) string {
+		return `{"refresh_token":"` + token + `"}`
+	}
+
+	family := []string{send(http.StatusOK, "/v1/login", login)}
+	for range 2 {
+		family = append(family, send(http.StatusOK, "/v1/token/refresh", refresh(family[len(family)-1])))
+	}
+	// Another login of the same user, a family of its own
+	other := send(http.StatusOK, "/v1/login", login)
+
+	send(http.StatusUnauthorized, "/v1/token/refresh", refresh(family[0]))
+	for i, token := range family {
+		stored, err := store.GetRefreshTokenByHash(context.Background(), helper.HashRefreshToken(token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stored.RevokedAt.Valid {
+			t.Errorf("refresh token %d of the family still valid after the reuse", i)
+		}
+	}
+	send(http.StatusUnauthorized, "/v1/token/refresh", refresh(family[len(family)-1]))
+
+	send(http.StatusOK, "/v1/token/refresh", refresh(other))
+}
+
+// A role with "employees:read" but not "salaries:read" sees the records without the salaries
+func TestEmployeesWithoutSalaries(t *testing.T) {
+	router, store, root := newTestServer(t)
+
+	hr := newTestClient(t, router)
+	hr.expect(http.StatusOK, "POST", "/v1/register", `{"username":"hr","email":"hr@example.com","password":"Corr3ct-Horse"}`)
+	root.expect(http.StatusOK, "PUT", "/v1/admin/users/2/roles/hr", "")
+	hr.expect(http.StatusOK, "POST", "/v1/login", `{"username":"hr","password":"Corr3ct-Horse"}`)
+	seedEmployees(t, store, keysetEmployees[:2]...)
+
+	for _, path := range []string{"/v1/admin/employees", "/v1/admin/employees/1", "/v1/admin/employees/export.csv"} {
+		if b := hr.expect(http.StatusOK, "GET", path, ""); bytes.Contains(b, []byte("salary")) || bytes.Contains(b, []byte("USD")) {
+			t.Errorf("GET %s shows the salaries: %s", path, b)
+		}
+		if b := root.expect(http.StatusOK, "GET", path, ""); !bytes.Contains(b, []byte("salary")) {
+			t.Errorf("GET %s hides the salaries from a superadmin: %s", path, b)
+		}
+	}
+
+	// Nothing that orders or narrows the list by salary
+	for _, query := range []string{"sort=salary", "sort=-salary", "min_salary=100", "max_salary=100", "currency=USD"} {
+		hr.expect(http.StatusForbidden, "GET", "/v1/admin/employees?"+query, "")
+		hr.expect(http.StatusForbidden, "GET", "/v1/admin/employees/export.csv?"+query, "")
+	}
+
+	// A change keeps the stored salary, a "test" can't probe it
+	if b := hr.expect(http.StatusOK, "PUT", "/v1/admin/employees/1", `{"job_title":"lead","country":"US"}`); bytes.Contains(b, []byte("salary")) {
+		t.Errorf("PUT answered the salary: %s", b)
+	}
+	patch := `[{"op":"test","path":"/salary","value":"300"},{"op":"replace","path":"/job_title","value":"cto"}]`
+	got, b := hr.doPatch("/v1/admin/employees/1", patch)
+	if got != http.StatusConflict {
+		t.Errorf("testing the salary: %d %s, want 409", got, b)
+	}
+	var emp struct {
+		JobTitle string `json:"job_title"`
+		Salary   string `json:"salary"`
+	}
+	if err := json.Unmarshal(root.expect(http.StatusOK, "GET", "/v1/admin/employees/1", ""), &emp); err != nil {
+		t.Fatal(err)
+	}
+	if emp.JobTitle != "lead" || emp.Salary != "300" {
+		t.Errorf("employee after the changes %+v, want the lead still paid 300", emp)
+	}
+}
+
+// An employee changes their own profile but not their salary, an admin with salaries:write does
+func TestSelfServiceSalary(t *testing.T) {
+	router, _, root := newTestServer(t)
+
+	user := newTestClient(t, router)
+	user.expect(http.StatusOK, "POST", "/v1/register", `{"username":"jane","email":"jane@example.com","password":"Corr3ct-Horse"}`)
+	user.expect(http.StatusCreated, "POST", "/v1/emp/new", `{"job_title":"dev","country":"US","currency":"USD","salary":100}`)
+
+	user.expect(http.StatusForbidden, "POST", "/v1/emp/update", `{"job_title":"dev","country":"US","salary":1000000}`)
+	user.expect(http.StatusForbidden, "POST", "/v1/emp/update", `{"job_title":"dev","country":"US","salary":100,"currency":"EUR"}`)
+	user.expect(http.StatusForbidden, "PATCH", "/v1/emp/details", `{"salary":1000000}`)
+	if got, b := user.doPatch("/v1/emp/details", `[{"op":"replace","path":"/salary","value":"1000000"}]`); got != http.StatusForbidden {
+		t.Errorf("JSON Patch of the salary: %d %s, want 403", got, b)
+	}
+
+	// The rest of the profile still changes
+	user.expect(http.StatusOK, "PATCH", "/v1/emp/details", `{"job_title":"lead"}`)
+	user.expect(http.StatusOK, "POST", "/v1/emp/update", `{"job_title":"cto","country":"US","salary":100}`)
+
+	root.expect(http.StatusOK, "PATCH", "/v1/admin/employees/1", `{"salary":200}`)
+	var history struct {
+		CurrentSalary string `json:"current_salary"`
+		Changes       []struct {
+			Reason     string `json:"reason"`
+			ApprovedBy *int64 `json:"approved_by"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(root.expect(http.StatusOK, "GET", "/v1/admin/employees/1/salary-history", ""), &history); err != nil {
+		t.Fatal(err)
+	}
+	if history.CurrentSalary != "200" || len(history.Changes) != 2 || history.Changes[1].ApprovedBy == nil || *history.Changes[1].ApprovedBy != 1 {
+		t.Errorf("salary history %+v, want the hire then the raise approved by root", history)
+	}
+}
+
+// Whatever their frequency, the runs not reversed never pay a day twice
+func TestPayrollRunsDontOverlap(t *testing.T) {
+	_, _, root := newTestServer(t)
+	run := func(frequency, start string) string {
+		return fmt.Sprintf(`{"frequency":%q,"period_start":%q}`, frequency, start)
+	}
+
+	root.expect(http.StatusCreated, "POST", "/v1/admin/payroll-runs", run("monthly", "2026-01-01")) // 1
+	tests := []struct {
+		frequency, start string
+		want             int
+	}{
+		{"monthly", "2026-01-01", http.StatusConflict},
+		{"biweekly", "2026-01-01", http.StatusConflict},
+		{"biweekly", "2026-01-20", http.StatusConflict}, // to 2026-02-02
+		{"biweekly", "2025-12-19", http.StatusConflict}, // to 2026-01-01, both ends count
+		{"biweekly", "2025-12-18", http.StatusCreated},  // to 2025-12-31
+		{"biweekly", "2025-12-20", http.StatusConflict}, // over the run just created
+		{"monthly", "2026-02-01", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if got, b := root.do("POST", "/v1/admin/payroll-runs", run(tt.frequency, tt.start)); got != tt.want {
+			t.Errorf("%s run from %s: %d %s, want %d", tt.frequency, tt.start, got, b, tt.want)
+		}
+	}
+
+	// A reversed run frees its period
+	root.expect(http.StatusOK, "POST", "/v1/admin/payroll-runs/1/lock", "")
+	root.expect(http.StatusOK, "POST", "/v1/admin/payroll-runs/1/reverse", `{"reason":"paid monthly by mistake"}`)
+	root.expect(http.StatusCreated, "POST", "/v1/admin/payroll-runs", run("biweekly", "2026-01-01"))
+	root.expect(http.StatusCreated, "POST", "/v1/admin/payroll-runs", run("biweekly", "2026-01-15"))
+}
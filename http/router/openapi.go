@@ -0,0 +1,376 @@
+package router
+
+import (
+	"net/http"
+
+	"server/bank"
+	"server/fx"
+	adminhandler "server/http/handlers/admin_handler"
+	employeehandler "server/http/handlers/employee_handler"
+	userhandler "server/http/handlers/user_handler"
+	"server/http/helper"
+	"server/money"
+	"server/openapi"
+	"server/patch"
+	"server/payroll"
+	"server/staff"
+	"server/tax"
+)
+
+var apiInfo = openapi.Info{
+	Title:   "employee-crud",
+	Version: "1",
+	Description: "Every route under /v1 is limited to 10 requests a minute per IP (429).\n" +
+		"Failures answer with a RFC 7807 problem (application/problem+json): a stable `code` to match on, the `request_id` " +
+		"of the call and, for invalid input, the fields at fault in `errors`.\n" +
+		"Amounts and rates are exact decimals, answered as strings.",
+}
+
+// Routes needing an access token take it as a bearer token or as the cookie set by the login
+var apiSecurity = map[string]*openapi.SecurityScheme{
+	"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+	"cookieAuth": {Type: "apiKey", In: "cookie", Name: "jwt"},
+}
+
+func query(name, description string) openapi.Param {
+	return openapi.Param{Name: name, Description: description}
+}
+
+func queryOf(schema *openapi.Schema, name, description string) openapi.Param {
+	return openapi.Param{Name: name, Description: description, Schema: schema}
+}
+
+var (
+	integerParam = openapi.Scalar("integer", "", "")
+	booleanParam = openapi.Scalar("boolean", "", "")
+	dateParam    = openapi.Scalar("string", "date", "")
+)
+
+// Query params of the employee list and its exports
+var employeeListParams = []openapi.Param{
+	query("country", "Only the employees of the country"),
+	query("job_title", "Only the employees with the job title"),
+	query("currency", "Only the salaries in the ISO 4217 currency"),
+	query("min_salary", "Lowest salary, in the employee's own currency"),
+	query("max_salary", "Highest salary, in the employee's own currency"),
+	queryOf(openapi.Enum("Prefixed with `-` for descending, e.g. `-salary`",
+		"id", "user_id", "job_title", "country", "salary", "created_at",
+		"-id", "-user_id", "-job_title", "-country", "-salary", "-created_at"), "sort", ""),
+	queryOf(integerParam, "limit", "Page size, 1..100 (20 by default)"),
+	query("cursor", "`next_cursor` of the previous page, with the same `sort`"),
+	queryOf(booleanParam, "deleted", "The deleted employees instead, restorable until the retention job"),
+}
+
+// Query params converting salaries for the analytics
+var reportingParams = []openapi.Param{
+	query("reporting_currency", "ISO 4217 currency the salaries are converted to, `REPORTING_CURRENCY` by default"),
+	queryOf(dateParam, "as_of", "Day of the exchange rates, today by default"),
+}
+
+var statusMessage = openapi.Fields{"status": true, "message": ""}
+
+// An employee is patched with either kind of patch, the Content-Type tells which
+var employeePatches = map[string]any{
+	patch.MergePatchType: openapi.Partial(employeehandler.EmpBody{}),
+	patch.JSONPatchType:  []patch.Operation{},
+	"application/json":   openapi.Partial(employeehandler.EmpBody{}),
+}
+
+const patchDescription = "A JSON Merge Patch (RFC 7396, `application/merge-patch+json` or `application/json`) " +
+	"or a JSON Patch (RFC 6902, `application/json-patch+json`) of the body of a full update, " +
+	"`job_title`, `country`, `salary` and `currency`. The result is checked as a full update is, " +
+	"a `test` that doesn't hold is 409."
+
+// A patch that isn't one is 400, a failed "test" 409, another Content-Type 415 and a path
+// missing from the employee or an invalid result 422
+var patchErrors = []int{400, 404, 409, 413, 415, 422}
+
+// apiRoutes describes every route of InitRouter, by method and path
+var apiRoutes = []openapi.Route{
+	// Meta
+	{Method: "GET", Pattern: "/openapi.json", ID: "OpenAPI", Tag: "meta", Public: true,
+		Summary: "This OpenAPI document", Response: openapi.Fields{}},
+	{Method: "GET", Pattern: "/docs", ID: "Docs", Tag: "meta", Public: true,
+		Summary: "A page browsing and trying out this document", ResponseType: "text/html"},
+	{Method: "GET", Pattern: "/.well-known/jwks.json", ID: "JWKSHandler", Tag: "meta", Public: true,
+		Summary: "Public keys verifying the access tokens", Response: helper.JWKSet{}},
+	{Method: "GET", Pattern: "/v1/health", ID: "HandlerReady", Tag: "meta", Public: true,
+		Summary: "Ready", Response: struct{}{}},
+	{Method: "GET", Pattern: "/v1/err", ID: "HandleErr", Tag: "meta", Public: true,
+		Summary: "Always fails", Status: http.StatusBadRequest, Errors: []int{400}},
+
+	// Auth
+	{Method: "POST", Pattern: "/v1/register", ID: "HandlerCreateUser", Tag: "auth", Public: true,
+		Summary:     "Register a user",
+		Description: "The user gets the `employee` role and is logged in, with the `jwt` and refresh token cookies.",
+		Body:        userhandler.SignUpBody{}, Response: userhandler.User{}, Errors: []int{400, 409}},
+	{Method: "POST", Pattern: "/v1/login", ID: "HandlerLogin", Tag: "auth", Public: true,
+		Summary:     "Log in",
+		Description: "Sets the `jwt` and refresh token cookies, `return_token` also puts the tokens in the body.",
+		Query:       []openapi.Param{queryOf(booleanParam, "return_token", "Same as `return_token` in the body")},
+		Body:        userhandler.LoginBody{}, Response: userhandler.LoginResponse{}, Errors: []int{400}},
+	{Method: "POST", Pattern: "/v1/token/refresh", ID: "RefreshToken", Tag: "auth", Public: true,
+		Summary: "Trade the refresh token for a new pair",
+		Description: "The refresh token comes from its cookie, or from the body (which answers with the tokens). " +
+			"Every refresh token works once, replaying a used one revokes every token of its login.",
+		Body:     userhandler.RefreshBody{},
+		Response: openapi.OneOf(openapi.Fields{"status": true, "message": "", "expires_in": 0}, userhandler.TokenResponse{}),
+		Errors:   []int{400, 401}},
+	{Method: "GET", Pattern: "/v1/status", ID: "CheckStatus", Tag: "auth",
+		Summary: "Who the access token belongs to",
+		Response: openapi.Fields{"status": true, "message": "", "email": "", "username": "", "id": 0,
+			"roles": []string{}, "permissions": []string{}},
+		Errors: []int{400}},
+	{Method: "GET", Pattern: "/v1/logout", ID: "LogOut", Tag: "auth",
+		Summary:  "Revoke the access token and the refresh tokens of this login",
+		Response: openapi.Fields{"Status": true, "message": ""}},
+	{Method: "POST", Pattern: "/v1/logout-all", ID: "LogOutEverywhere", Tag: "auth",
+		Summary:  "Revoke every token of the user, on every device",
+		Response: openapi.Fields{"Status": true, "message": ""}, Errors: []int{400}},
+
+	// Employee, the logged in user
+	{Method: "POST", Pattern: "/v1/emp/new", ID: "CreateEmp", Tag: "employee",
+		Summary: "Create own employee profile", Body: employeehandler.EmpBody{}, ETag: true,
+		Status: http.StatusCreated, Response: employeehandler.Employee{}, Errors: []int{400, 409, 422}},
+	{Method: "POST", Pattern: "/v1/emp/update", ID: "UpdateEmp", Tag: "employee",
+		Summary:     "Update own employee profile",
+		Description: "The currency stays when left out. The salary and currency can't change here (403), an admin changes them.",
+		Body:        employeehandler.EmpBody{}, ETag: true, Response: employeehandler.Employee{}, Errors: []int{400, 403, 404, 422}},
+	{Method: "GET", Pattern: "/v1/emp/details", ID: "GetEmployee", Tag: "employee",
+		Summary: "Own employee profile", ETag: true, Response: employeehandler.Employee{}, Errors: []int{400, 404}},
+	{Method: "PATCH", Pattern: "/v1/emp/details", ID: "PatchEmp", Tag: "employee",
+		Summary:     "Change some fields of own employee profile",
+		Description: patchDescription + " The salary and currency can't change here (403), an admin changes them.",
+		Bodies:      employeePatches, ETag: true, Response: employeehandler.Employee{}, Errors: []int{400, 403, 404, 409, 413, 415, 422}},
+	{Method: "DELETE", Pattern: "/v1/emp/delete", ID: "DeleteEmployee", Tag: "employee",
+		Summary: "Delete own employee profile", ETag: true, Response: employeehandler.Employee{}, Errors: []int{400, 404}},
+	{Method: "GET", Pattern: "/v1/emp/net-sal", ID: "NetSalary", Tag: "employee",
+		Summary:     "Gross to net breakdown of own salary",
+		Description: "With the tax regime of the employee's country in force in `tax_year`, a salary in another currency is converted at today's rate.",
+		Query:       []openapi.Param{queryOf(integerParam, "tax_year", "The current year by default")},
+		Response:    tax.Breakdown{}, Errors: []int{400, 404, 422}},
+	{Method: "GET", Pattern: "/v1/emp/salary-history", ID: "GetSalaryHistory", Tag: "employee",
+		Summary: "Own compensation timeline, oldest first", Response: employeehandler.SalaryTimeline{}, Errors: []int{400, 404}},
+	{Method: "GET", Pattern: "/v1/emp/payslips", ID: "ListPayslips", Tag: "employee",
+		Summary: "Own payslips, latest period first", Response: []payroll.Payslip{}, Errors: []int{400}},
+	{Method: "GET", Pattern: "/v1/emp/payslips/{id}", ID: "GetPayslip", Tag: "employee",
+		Summary: "One own payslip", Response: payroll.Payslip{}, Errors: []int{400, 404}},
+	{Method: "GET", Pattern: "/v1/emp/payslips/{id}/pdf", ID: "GetPayslipPDF", Tag: "employee",
+		Summary: "One own payslip as a PDF", ResponseType: "application/pdf", Errors: []int{400, 404}},
+	{Method: "GET", Pattern: "/v1/emp/bank-account", ID: "GetBankAccount", Tag: "employee",
+		Summary: "Own bank account", Response: employeehandler.BankAccount{}, Errors: []int{400, 404}},
+	{Method: "PUT", Pattern: "/v1/emp/bank-account", ID: "PutBankAccount", Tag: "employee",
+		Summary:     "Register own bank account",
+		Description: "An `iban` (and optional `bic`) is a SEPA account, a `routing_number` and `account_number` an ACH one.",
+		Body:        bank.Account{}, Response: employeehandler.BankAccount{}, Errors: []int{400, 422}},
+	{Method: "DELETE", Pattern: "/v1/emp/bank-account", ID: "DeleteBankAccount", Tag: "employee",
+		Summary: "Remove own bank account", Response: employeehandler.BankAccount{}, Errors: []int{400, 404}},
+
+	// Salary analytics
+	{Method: "GET", Pattern: "/v1/admin/sal-metrics", ID: "GetSalaryMetricsByCountry", Tag: "analytics",
+		Permission: "salaries:read", Summary: "Salary statistics of a country",
+		Query:    append([]openapi.Param{query("country", "")}, reportingParams...),
+		Response: employeehandler.SalaryMetrics{}, XLSX: true, Errors: []int{400, 422}},
+	{Method: "GET", Pattern: "/v1/admin/sal-avg", ID: "GetAvgSalaryPerJobTitle", Tag: "analytics",
+		Permission: "salaries:read", Summary: "Average salary of a job title",
+		Query:    append([]openapi.Param{query("job_title", "")}, reportingParams...),
+		Response: employeehandler.JobTitleSalaries{}, XLSX: true, Errors: []int{400, 422}},
+
+	// Any employee
+	{Method: "GET", Pattern: "/v1/admin/employees", ID: "ListEmployees", Tag: "employees",
+		Permission: "employees:read", Summary: "List employees",
+		Description: "Keyset paginated, a spreadsheet has every match and ignores `limit`. " +
+			"Without `salaries:read` the salaries and currencies are left out, and filtering or sorting on them is 403.",
+		Query: employeeListParams, Response: employeehandler.EmployeePage{}, XLSX: true, Errors: []int{400}},
+	{Method: "GET", Pattern: "/v1/admin/employees/export.csv", ID: "ExportEmployees", Tag: "employees",
+		Permission: "employees:read", Summary: "Stream the filtered employees as CSV",
+		Description: "Every match in the order of `sort`, `limit` is ignored. The file imports again as is. " +
+			"Without `salaries:read` it has no `salary` and `currency` columns, and filtering or sorting on them is 403.",
+		Query: employeeListParams, ResponseType: "text/csv", Errors: []int{400}},
+	{Method: "POST", Pattern: "/v1/admin/employees/import", ID: "ImportEmployees", Tag: "employees",
+		Permission: "employees:write", Summary: "Create users and employees from a CSV",
+		Description: "A `username,email,job_title,country,salary,currency` header and an optional `password` column. " +
+			"Every line is imported in one transaction or none is, a user without password gets a generated one.",
+		Query:     []openapi.Param{queryOf(booleanParam, "dry_run", "Check and write every line, then roll back")},
+		Body:      openapi.Scalar("string", "", "CSV, one employee per line"),
+		BodyTypes: []string{"text/csv"},
+		Status:    http.StatusCreated, Response: staff.Result{},
+		Errors: []int{400, 413, 415},
+		Others: map[int]any{
+			http.StatusOK:                  staff.Result{},
+			http.StatusUnprocessableEntity: openapi.OneOf(staff.Result{}, openapi.Fields{"error": ""}),
+		}},
+	{Method: "GET", Pattern: "/v1/admin/employees/{id}", ID: "GetEmployeeByID", Tag: "employees",
+		Permission: "employees:read", Summary: "Any employee by `employees.id`",
+		Description: "Without `salaries:read` the salary and currency are left out.",
+		ETag:        true, Response: employeehandler.Employee{}, Errors: []int{400, 404}},
+	{Method: "PUT", Pattern: "/v1/admin/employees/{id}", ID: "UpdateEmployeeByID", Tag: "employees",
+		Permission: "employees:write", Summary: "Update any employee by `employees.id`",
+		Description: "Changing the salary needs `salaries:write` as well. " +
+			"Without `salaries:read` the salary and currency of the body are ignored and left out of the answer.",
+		Body: employeehandler.EmpBody{}, ETag: true, Response: employeehandler.Employee{}, Errors: []int{400, 404, 422}},
+	{Method: "PATCH", Pattern: "/v1/admin/employees/{id}", ID: "PatchEmployeeByID", Tag: "employees",
+		Permission: "employees:write", Summary: "Change some fields of any employee by `employees.id`",
+		Description: patchDescription + " Changing the salary needs `salaries:write` as well. " +
+			"Without `salaries:read` the patch applies to the employee without its salary and currency, which are kept.",
+		Bodies: employeePatches, ETag: true, Response: employeehandler.Employee{}, Errors: patchErrors},
+	{Method: "DELETE", Pattern: "/v1/admin/employees/{id}", ID: "DeleteEmployeeByID", Tag: "employees",
+		Permission: "employees:write", Summary: "Delete any employee by `employees.id`",
+		Description: "A soft delete, restorable until the retention job purges it.",
+		ETag:        true, Response: employeehandler.Employee{}, Errors: []int{400, 404}},
+	{Method: "POST", Pattern: "/v1/admin/employees/{id}/restore", ID: "RestoreEmployeeByID", Tag: "employees",
+		Permission: "employees:write", Summary: "Restore a deleted employee",
+		Description: "Not while its user is deleted or has another employee profile (409).",
+		Response:    employeehandler.Employee{}, Errors: []int{400, 404, 409}},
+	{Method: "GET", Pattern: "/v1/admin/employees/{id}/salary-history", ID: "GetSalaryHistoryByID", Tag: "employees",
+		Permission: "salaries:read", Summary: "Compensation timeline of any employee",
+		Response: employeehandler.SalaryTimeline{}, Errors: []int{400, 404}},
+	{Method: "POST", Pattern: "/v1/admin/employees/{id}/salary-history", ID: "CreateSalaryChange", Tag: "employees",
+		Permission: "salaries:write", Summary: "Record or schedule a salary change",
+		Description: "A future `effective_from` schedules the change.",
+		Body:        employeehandler.SalaryChangeBody{}, Status: http.StatusCreated,
+		Response: employeehandler.SalaryTimeline{}, Errors: []int{400, 404, 422}},
+	{Method: "DELETE", Pattern: "/v1/admin/employees/{id}/salary-history/{changeID}", ID: "CancelSalaryChange", Tag: "employees",
+		Permission: "salaries:write", Summary: "Cancel a scheduled salary change",
+		Response: employeehandler.SalaryChange{}, Errors: []int{400, 404}},
+
+	// Tax regimes
+	{Method: "GET", Pattern: "/v1/admin/tax-regimes", ID: "ListTaxRegimes", Tag: "tax regimes",
+		Permission: "taxes:read", Summary: "Tax regimes",
+		Query: []openapi.Param{query("country", "")}, Response: []tax.Regime{}},
+	{Method: "GET", Pattern: "/v1/admin/tax-regimes/{id}", ID: "GetTaxRegime", Tag: "tax regimes",
+		Permission: "taxes:read", Summary: "One tax regime", Response: tax.Regime{}, Errors: []int{400, 404}},
+	{Method: "GET", Pattern: "/v1/admin/tax-regimes/{id}/calculate", ID: "CalculateTax", Tag: "tax regimes",
+		Permission: "taxes:read", Summary: "Preview a regime on a yearly salary",
+		Query:    []openapi.Param{{Name: "salary", Description: "Yearly gross, in the currency of the regime", Required: true}},
+		Response: tax.Breakdown{}, Errors: []int{400, 404}},
+	{Method: "POST", Pattern: "/v1/admin/tax-regimes", ID: "CreateTaxRegime", Tag: "tax regimes",
+		Permission: "taxes:write", Summary: "Add the rules of a country for a tax year",
+		Body: tax.Regime{}, Status: http.StatusCreated, Response: tax.Regime{}, Errors: []int{409, 422}},
+	{Method: "PUT", Pattern: "/v1/admin/tax-regimes/{id}", ID: "UpdateTaxRegime", Tag: "tax regimes",
+		Permission: "taxes:write", Summary: "Replace the allowance, brackets and contributions",
+		Description: "The country, tax year and currency stay.",
+		Body:        tax.Regime{}, Response: tax.Regime{}, Errors: []int{400, 404, 422}},
+	{Method: "DELETE", Pattern: "/v1/admin/tax-regimes/{id}", ID: "DeleteTaxRegime", Tag: "tax regimes",
+		Permission: "taxes:write", Summary: "Delete a tax regime", Response: tax.Regime{}, Errors: []int{400, 404}},
+
+	// Exchange rates
+	{Method: "GET", Pattern: "/v1/admin/fx-rates", ID: "ListFxRates", Tag: "exchange rates",
+		Permission: "fx:read", Summary: "Exchange rates, newest first",
+		Query: []openapi.Param{
+			query("currency", "Either side of the pair"),
+			queryOf(dateParam, "since", "Inclusive"),
+			queryOf(dateParam, "until", "Inclusive"),
+			queryOf(integerParam, "limit", "1..1000 (100 by default)"),
+		},
+		Response: []fx.ExchangeRate{}, XLSX: true, Errors: []int{400}},
+	{Method: "GET", Pattern: "/v1/admin/fx-rates/convert", ID: "ConvertAmount", Tag: "exchange rates",
+		Permission: "fx:read", Summary: "Convert an amount",
+		Query: []openapi.Param{
+			{Name: "amount", Required: true},
+			{Name: "from", Description: "ISO 4217", Required: true},
+			{Name: "to", Description: "ISO 4217", Required: true},
+			queryOf(dateParam, "as_of", "Today by default"),
+		},
+		Response: openapi.Fields{"from": "", "to": "", "as_of": "", "rate": money.Rate{},
+			"amount": money.Amount{}, "converted": money.Amount{}},
+		Errors: []int{400, 422}},
+	{Method: "POST", Pattern: "/v1/admin/fx-rates", ID: "CreateFxRate", Tag: "exchange rates",
+		Permission: "fx:write", Summary: "Set the rate of a pair on a day",
+		Description: "Replaces the rate of the same pair and day.",
+		Body:        fx.ExchangeRate{}, Status: http.StatusCreated, Response: fx.ExchangeRate{}, Errors: []int{422}},
+	{Method: "POST", Pattern: "/v1/admin/fx-rates/import", ID: "ImportFxRates", Tag: "exchange rates",
+		Permission: "fx:write", Summary: "Import a CSV or the ECB reference rates",
+		Description: "A `date,base,quote,rate` CSV or the ECB euro reference rates XML. Nothing is saved unless the whole file is valid.",
+		Query:       []openapi.Param{queryOf(openapi.Enum("Overrides the Content-Type", "csv", "ecb"), "format", "")},
+		Body:        openapi.Scalar("string", "", "The file"), BodyTypes: []string{"text/csv", "application/xml"},
+		Response: adminhandler.FxImport{}, Errors: []int{413, 415, 422}},
+	{Method: "DELETE", Pattern: "/v1/admin/fx-rates/{id}", ID: "DeleteFxRate", Tag: "exchange rates",
+		Permission: "fx:write", Summary: "Delete an exchange rate", Response: fx.ExchangeRate{}, Errors: []int{400, 404}},
+
+	// Payroll
+	{Method: "GET", Pattern: "/v1/admin/payroll-runs", ID: "ListPayrollRuns", Tag: "payroll",
+		Permission: "payroll:read", Summary: "Payroll runs, latest period first",
+		Query: []openapi.Param{
+			queryOf(openapi.Enum("", "draft", "locked", "reversed"), "status", ""),
+			queryOf(integerParam, "limit", "1..500 (50 by default)"),
+		},
+		Response: []payroll.Run{}, XLSX: true, Errors: []int{400}},
+	{Method: "GET", Pattern: "/v1/admin/payroll-runs/{id}", ID: "GetPayrollRun", Tag: "payroll",
+		Permission: "payroll:read", Summary: "A run with its payslips and totals",
+		Description: "A spreadsheet has a sheet of payslips per currency.",
+		Response:    adminhandler.PayrollRun{}, XLSX: true, Errors: []int{404}},
+	{Method: "GET", Pattern: "/v1/admin/payroll-runs/{id}/payslips.zip", ID: "DownloadPayrollRunPayslips", Tag: "payroll",
+		Permission: "payroll:read", Summary: "The payslips of a run as PDFs in a ZIP",
+		ResponseType: "application/zip", Errors: []int{404}},
+	{Method: "POST", Pattern: "/v1/admin/payroll-runs", ID: "CreatePayrollRun", Tag: "payroll",
+		Permission: "payroll:write", Summary: "Calculate a period into a draft run",
+		Description: "Employees without a tax regime or an exchange rate are listed in `skipped`.",
+		Body:        adminhandler.PayrollRunBody{}, Status: http.StatusCreated,
+		Response: adminhandler.PayrollRun{}, Errors: []int{409, 422}},
+	{Method: "POST", Pattern: "/v1/admin/payroll-runs/{id}/recalculate", ID: "RecalculatePayrollRun", Tag: "payroll",
+		Permission: "payroll:write", Summary: "Calculate a draft again",
+		Response: adminhandler.PayrollRun{}, Errors: []int{404, 409}},
+	{Method: "POST", Pattern: "/v1/admin/payroll-runs/{id}/lock", ID: "LockPayrollRun", Tag: "payroll",
+		Permission: "payroll:write", Summary: "Lock a draft, issuing its payslips",
+		Response: adminhandler.PayrollRun{}, Errors: []int{404, 409}},
+	{Method: "POST", Pattern: "/v1/admin/payroll-runs/{id}/reverse", ID: "ReversePayrollRun", Tag: "payroll",
+		Permission: "payroll:write", Summary: "Reverse a locked run",
+		Description: "The run and its payslips stay, the period can be run again.",
+		Body:        adminhandler.ReversalBody{}, Response: adminhandler.PayrollRun{}, Errors: []int{404, 409, 422}},
+	{Method: "DELETE", Pattern: "/v1/admin/payroll-runs/{id}", ID: "DeletePayrollRun", Tag: "payroll",
+		Permission: "payroll:write", Summary: "Discard a draft", Response: payroll.Run{}, Errors: []int{404, 409}},
+	{Method: "GET", Pattern: "/v1/admin/payroll-runs/{id}/sepa.xml", ID: "ExportPayrollRunSEPA", Tag: "payroll",
+		Permission: "payroll:write", Summary: "SEPA credit transfers (pain.001) of the EUR payslips of a locked run",
+		ResponseType: "application/xml", Errors: []int{404, 409}},
+	{Method: "GET", Pattern: "/v1/admin/payroll-runs/{id}/nacha.ach", ID: "ExportPayrollRunNACHA", Tag: "payroll",
+		Permission: "payroll:write", Summary: "NACHA ACH file of the USD payslips of a locked run",
+		ResponseType: "text/plain", Errors: []int{404, 409}},
+
+	// Roles and tokens
+	{Method: "GET", Pattern: "/v1/admin/roles", ID: "ListRoles", Tag: "roles",
+		Permission: "roles:read", Summary: "Every role and its permissions", Response: []adminhandler.Role{}},
+	{Method: "GET", Pattern: "/v1/admin/users/{id}/roles", ID: "GetUserRoles", Tag: "roles",
+		Permission: "roles:read", Summary: "Roles of a user", Response: adminhandler.UserRoles{}, Errors: []int{400, 404}},
+	{Method: "PUT", Pattern: "/v1/admin/users/{id}/roles/{role}", ID: "AssignUserRole", Tag: "roles",
+		Permission: "roles:write", Summary: "Assign a role",
+		Description: "Nobody hands out a permission they don't hold, `admin` and `superadmin` need `admins:write`.",
+		Response:    adminhandler.UserRoles{}, Errors: []int{400, 404}},
+	{Method: "DELETE", Pattern: "/v1/admin/users/{id}/roles/{role}", ID: "RemoveUserRole", Tag: "roles",
+		Permission: "roles:write", Summary: "Remove a role", Description: "The last superadmin stays (409).",
+		Response: adminhandler.UserRoles{}, Errors: []int{400, 404, 409}},
+	{Method: "POST", Pattern: "/v1/admin/users/{id}/revoke-tokens", ID: "RevokeUserTokens", Tag: "roles",
+		Permission: "tokens:revoke", Summary: "Log a user out everywhere", Response: statusMessage, Errors: []int{400, 404}},
+	{Method: "DELETE", Pattern: "/v1/admin/users/{id}", ID: "DeleteUser", Tag: "roles",
+		Permission: "users:write", Summary: "Delete a user and their employee profile",
+		Description: "A soft delete that logs the user out everywhere, restorable until the retention job purges or anonymizes it. " +
+			"Nobody deletes themselves (409) or a user holding a permission they don't (403).",
+		Response: adminhandler.DeletedUser{}, Errors: []int{400, 403, 404, 409}},
+	{Method: "POST", Pattern: "/v1/admin/users/{id}/restore", ID: "RestoreUser", Tag: "roles",
+		Permission: "users:write", Summary: "Restore a deleted user",
+		Description: "Along with the employee profile deleted with them. An anonymized user can't be restored (409).",
+		Response:    adminhandler.DeletedUser{}, Errors: []int{400, 403, 404, 409}},
+
+	// Audit
+	{Method: "GET", Pattern: "/v1/admin/audit", ID: "ListAuditEntries", Tag: "audit",
+		Permission: "audit:read", Summary: "Audit log, newest first",
+		Query: []openapi.Param{
+			queryOf(integerParam, "actor_id", ""),
+			query("action", "e.g. `employee.update`"),
+			query("target_type", ""),
+			query("target_id", ""),
+			queryOf(openapi.Scalar("string", "date-time", ""), "since", ""),
+			queryOf(openapi.Scalar("string", "date-time", ""), "until", "Exclusive"),
+			queryOf(integerParam, "limit", "1..100 (50 by default)"),
+			query("cursor", "`next_cursor` of the previous page"),
+		},
+		Response: adminhandler.AuditPage{}, Errors: []int{400}},
+
+	// Superadmin
+	{Method: "POST", Pattern: "/v1/superadmin/users/{id}/grant-admin", ID: "GrantAdmin", Tag: "superadmin",
+		Permission: "admins:write", Summary: "Give the admin role to a user",
+		Response: adminhandler.UserRoles{}, Errors: []int{400, 404}},
+	{Method: "POST", Pattern: "/v1/superadmin/users/{id}/revoke-admin", ID: "RevokeAdmin", Tag: "superadmin",
+		Permission: "admins:write", Summary: "Take the admin role from a user",
+		Response: adminhandler.UserRoles{}, Errors: []int{400, 404}},
+}
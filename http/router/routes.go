@@ -4,12 +4,18 @@ import (
 	"net/http"
 	"time"
 
+	"server/audit"
+	"server/config"
 	adminhandler "server/http/handlers/admin_handler"
 	employeehandler "server/http/handlers/employee_handler"
 	userhandler "server/http/handlers/user_handler"
 	"server/http/handlers/util"
+	"server/http/helper"
 	"server/http/middleware"
 	md "server/http/middleware"
+	"server/http/response"
+	"server/openapi"
+	"server/sql/database"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
@@ -17,78 +23,197 @@ import (
 	"go.uber.org/zap"
 )
 
-func InitRouter(logger *zap.Logger) http.Handler {
+// handlers groups every route handler, built once from the injected dependencies
+type handlers struct {
+	user     *userhandler.Handler
+	employee *employeehandler.Handler
+	admin    *adminhandler.Handler
+}
+
+// InitRouter builds the server routes on top of the given Querier, audit log, signing keys, logger and config
+func InitRouter(logger *zap.Logger, queries database.Querier, auditLog *audit.Recorder, keys *helper.KeyRing, cfg *config.Config) http.Handler {
+	h := handlers{
+		user:     userhandler.New(queries, keys, auditLog, logger, cfg),
+		employee: employeehandler.New(queries, auditLog, logger, cfg),
+		admin:    adminhandler.New(queries, auditLog, logger, cfg),
+	}
+
 	router := chi.NewRouter()
+	router.NotFound(notFound)
+	router.MethodNotAllowed(methodNotAllowed)
+	router.Use(middleware.RequestID)
 	router.Use(middleware.ZapMiddleware(logger))
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"ETag", "Accept-Patch", "Link", "WWW-Authenticate", "X-Request-Id"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	// Public keys for services verifying our tokens
+	router.Get("/.well-known/jwks.json", util.JWKSHandler(keys))
+
+	// The API described, once every route is registered
+	docs := &openapi.Handler{}
+	router.Get("/openapi.json", docs.ServeJSON)
+	router.Get("/docs", docs.ServeDocs)
+
 	v1Router := chi.NewRouter()
 
 	// Register Rate Limitter for "/v1"
-	v1Router.Use(httprate.LimitByIP(10, time.Minute))
+	v1Router.Use(httprate.Limit(10, time.Minute, httprate.WithKeyByIP(), httprate.WithLimitHandler(rateLimited)))
 
 	registerUtilRoutes(v1Router)
-	registerUserRoutes(v1Router)
+	registerUserRoutes(v1Router, h, queries, keys)
 
 	router.Mount("/v1", v1Router)
 
+	var undocumented []string
+	docs.Document, undocumented = openapi.Build(apiInfo, apiSecurity, router, apiRoutes)
+	for _, route := range undocumented {
+		logger.Warn("route missing from the OpenAPI document", zap.String("route", route))
+	}
+
 	return router
 }
 
+// Failures of the router itself are problems like any other
+func notFound(w http.ResponseWriter, r *http.Request) {
+	response.RespondeWithError(w, http.StatusNotFound, "no route for "+r.URL.Path)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	response.RespondeWithError(w, http.StatusMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+}
+
+func rateLimited(w http.ResponseWriter, r *http.Request) {
+	response.RespondeWithError(w, http.StatusTooManyRequests, "too many requests, retry after the Retry-After delay")
+}
+
 func registerUtilRoutes(r chi.Router) {
 	r.Get("/health", util.HandlerReady)
 	r.Get("/err", util.HandleErr)
 }
 
-func registerUserRoutes(r chi.Router) {
-	r.Post("/register", userhandler.HandlerCreateUser)
-	r.Post("/login", userhandler.HandlerLogin)
+func registerUserRoutes(r chi.Router, h handlers, queries database.Querier, keys *helper.KeyRing) {
+	r.Post("/register", h.user.HandlerCreateUser)
+	r.Post("/login", h.user.HandlerLogin)
+	r.Post("/token/refresh", h.user.RefreshToken) // Access token may already be expired, no JWT check
 
 	// Protected Routes "/v1"
 	r.Route("/", func(r chi.Router) {
 		// ✚ Auth Middleware
-		r.Use(md.JWTMiddleware)
+		r.Use(md.JWTMiddleware(queries, keys))
 
 		// User 😊
-		r.Get("/status", userhandler.CheckStatus)
-		r.Get("/logout", userhandler.LogOut)
+		r.Get("/status", h.user.CheckStatus)
+		r.Get("/logout", h.user.LogOut)
+		r.Post("/logout-all", h.user.LogOutEverywhere)
 
 		// Employee 🤵
 		r.Route("/emp", func(r chi.Router) {
-			r.Post("/new", employeehandler.CreateEmp)
-			r.Post("/update", employeehandler.UpdateEmp)
-			r.Get("/details", employeehandler.GetEmployee)
-			r.Delete("/delete", employeehandler.DeleteEmployee)
-			r.Get("/net-sal", employeehandler.NetSalary)
+			r.Post("/new", h.employee.CreateEmp)
+			r.Post("/update", h.employee.UpdateEmp)
+			r.Get("/details", h.employee.GetEmployee)
+			r.Patch("/details", h.employee.PatchEmp)
+			r.Delete("/delete", h.employee.DeleteEmployee)
+			r.Get("/net-sal", h.employee.NetSalary)
+			r.Get("/salary-history", h.employee.GetSalaryHistory)
+			r.Get("/payslips", h.employee.ListPayslips)
+			r.Get("/payslips/{id}", h.employee.GetPayslip)
+			r.Get("/payslips/{id}/pdf", h.employee.GetPayslipPDF)
+			r.Get("/bank-account", h.employee.GetBankAccount)
+			r.Put("/bank-account", h.employee.PutBankAccount)
+			r.Delete("/bank-account", h.employee.DeleteBankAccount)
 		})
 	})
 
-	// Admin Routes
+	// Admin Routes, every route asks for its own permission
 	r.Route("/admin", func(r chi.Router) {
 		// Middleware
-		r.Use(md.JWTMiddleware)        // Has to be a legit User
-		r.Use(md.CheckAdminMiddleware) // Has to be Admin user
+		r.Use(md.JWTMiddleware(queries, keys)) // Has to be a legit User
+
+		// Salary analytics
+		r.With(md.RequirePermission("salaries:read")).Get("/sal-metrics", h.employee.GetSalaryMetricsByCountry) // Get Salary Metrics
+		r.With(md.RequirePermission("salaries:read")).Get("/sal-avg", h.employee.GetAvgSalaryPerJobTitle)
+
+		// Kill every session of a user
+		r.With(md.RequirePermission("tokens:revoke")).Post("/users/{id}/revoke-tokens", h.admin.RevokeUserTokens)
+
+		// Soft delete, restorable until the retention job purges or anonymizes the user
+		r.With(md.RequirePermission("users:write")).Delete("/users/{id}", h.admin.DeleteUser)
+		r.With(md.RequirePermission("users:write")).Post("/users/{id}/restore", h.admin.RestoreUser)
+
+		// Income tax and contribution rules per country and tax year
+		r.Route("/tax-regimes", func(r chi.Router) {
+			r.With(md.RequirePermission("taxes:read")).Get("/", h.admin.ListTaxRegimes)
+			r.With(md.RequirePermission("taxes:read")).Get("/{id}", h.admin.GetTaxRegime)
+			r.With(md.RequirePermission("taxes:read")).Get("/{id}/calculate", h.admin.CalculateTax)
+			r.With(md.RequirePermission("taxes:write")).Post("/", h.admin.CreateTaxRegime)
+			r.With(md.RequirePermission("taxes:write")).Put("/{id}", h.admin.UpdateTaxRegime)
+			r.With(md.RequirePermission("taxes:write")).Delete("/{id}", h.admin.DeleteTaxRegime)
+		})
 
-		// Admin Routes
-		r.Get("/sal-metrics", employeehandler.GetSalaryMetricsByCountry) // Get Salary Metrics
-		r.Get("/sal-avg", employeehandler.GetAvgSalaryPerJobTitle)
+		// Exchange rates the salary analytics convert with
+		r.Route("/fx-rates", func(r chi.Router) {
+			r.With(md.RequirePermission("fx:read")).Get("/", h.admin.ListFxRates)
+			r.With(md.RequirePermission("fx:read")).Get("/convert", h.admin.ConvertAmount)
+			r.With(md.RequirePermission("fx:write")).Post("/", h.admin.CreateFxRate)
+			r.With(md.RequirePermission("fx:write")).Post("/import", h.admin.ImportFxRates)
+			r.With(md.RequirePermission("fx:write")).Delete("/{id}", h.admin.DeleteFxRate)
+		})
+
+		// Payroll runs, a draft previews the payslips of a period until it is locked
+		r.Route("/payroll-runs", func(r chi.Router) {
+			r.With(md.RequirePermission("payroll:read")).Get("/", h.admin.ListPayrollRuns)
+			r.With(md.RequirePermission("payroll:read")).Get("/{id}", h.admin.GetPayrollRun)
+			r.With(md.RequirePermission("payroll:read")).Get("/{id}/payslips.zip", h.admin.DownloadPayrollRunPayslips)
+			r.With(md.RequirePermission("payroll:write")).Post("/", h.admin.CreatePayrollRun)
+			r.With(md.RequirePermission("payroll:write")).Post("/{id}/recalculate", h.admin.RecalculatePayrollRun)
+			r.With(md.RequirePermission("payroll:write")).Post("/{id}/lock", h.admin.LockPayrollRun)
+			r.With(md.RequirePermission("payroll:write")).Post("/{id}/reverse", h.admin.ReversePayrollRun)
+			r.With(md.RequirePermission("payroll:write")).Delete("/{id}", h.admin.DeletePayrollRun)
+			r.With(md.RequirePermission("payroll:write")).Get("/{id}/sepa.xml", h.admin.ExportPayrollRunSEPA)
+			r.With(md.RequirePermission("payroll:write")).Get("/{id}/nacha.ach", h.admin.ExportPayrollRunNACHA)
+		})
+
+		// Who changed what
+		r.With(md.RequirePermission("audit:read")).Get("/audit", h.admin.ListAuditEntries)
+
+		// Roles and who holds them
+		r.With(md.RequirePermission("roles:read")).Get("/roles", h.admin.ListRoles)
+		r.With(md.RequirePermission("roles:read")).Get("/users/{id}/roles", h.admin.GetUserRoles)
+		r.With(md.RequirePermission("roles:write")).Put("/users/{id}/roles/{role}", h.admin.AssignUserRole)
+		r.With(md.RequirePermission("roles:write")).Delete("/users/{id}/roles/{role}", h.admin.RemoveUserRole)
+
+		// Manage any Employee by "employees.id"
+		r.Route("/employees", func(r chi.Router) {
+			r.With(md.RequirePermission("employees:read")).Get("/", h.employee.ListEmployees)
+			r.With(md.RequirePermission("employees:read")).Get("/export.csv", h.employee.ExportEmployees)
+			r.With(md.RequirePermission("employees:write")).Post("/import", h.employee.ImportEmployees)
+			r.With(md.RequirePermission("employees:read")).Get("/{id}", h.employee.GetEmployeeByID)
+			r.With(md.RequirePermission("employees:write")).Put("/{id}", h.employee.UpdateEmployeeByID)
+			r.With(md.RequirePermission("employees:write")).Patch("/{id}", h.employee.PatchEmployeeByID)
+			r.With(md.RequirePermission("employees:write")).Delete("/{id}", h.employee.DeleteEmployeeByID)
+			r.With(md.RequirePermission("employees:write")).Post("/{id}/restore", h.employee.RestoreEmployeeByID)
+
+			// Compensation timeline, changes dated in the future are scheduled
+			r.With(md.RequirePermission("salaries:read")).Get("/{id}/salary-history", h.employee.GetSalaryHistoryByID)
+			r.With(md.RequirePermission("salaries:write")).Post("/{id}/salary-history", h.employee.CreateSalaryChange)
+			r.With(md.RequirePermission("salaries:write")).Delete("/{id}/salary-history/{changeID}", h.employee.CancelSalaryChange)
+		})
 	})
 
-	// Supreme Leader Route ⚡️⚡️
-	r.Route("/supreme-leader", func(r chi.Router) {
+	// Superadmin Routes ⚡️⚡️, hand out the admin role
+	r.Route("/superadmin", func(r chi.Router) {
 		// Middleware
-		r.Use(md.JWTMiddleware)           // Has to a legit User
-		r.Use(md.SupremeLeaderMiddleware) // Check for Supreme Leader
+		r.Use(md.JWTMiddleware(queries, keys))      // Has to a legit User
+		r.Use(md.RequirePermission("admins:write")) // Has to be a superadmin
 
-		// Supreme Leader only can make or break an Admin ⚡️⚡️
-		r.Post("/make-break", adminhandler.MakeBreak)
+		r.Post("/users/{id}/grant-admin", h.admin.GrantAdmin)
+		r.Post("/users/{id}/revoke-admin", h.admin.RevokeAdmin)
 	})
 }
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"server/audit"
+	"server/config"
+	"server/http/helper"
+	"server/openapi"
+	"server/sql/memdb"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	store := memdb.New()
+	keys, err := helper.NewKeyRing(store, "EdDSA", time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return InitRouter(zap.NewNop(), store, audit.New(store, zap.NewNop()), keys, &config.Config{ReportingCurrency: "USD"})
+}
+
+// The document at /openapi.json describes every route of the router, and nothing else
+func TestEveryRouteIsDocumented(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: %d %s", rec.Code, rec.Body)
+	}
+	var doc struct {
+		OpenAPI string                                  `json:"openapi"`
+		Paths   map[string]map[string]openapi.Operation `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		t.Errorf("openapi %q, want 3.1", doc.OpenAPI)
+	}
+
+	routed := make(map[string]bool)
+	err := chi.Walk(router.(chi.Routes), func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := openapi.CleanPattern(pattern)
+		route := method + " " + path
+		routed[route] = true
+
+		op, ok := doc.Paths[path][strings.ToLower(method)]
+		switch {
+		case !ok:
+			t.Errorf("%s is missing from /openapi.json", route)
+		case op.OperationID == "" || op.Summary == "":
+			t.Errorf("%s has no description in apiRoutes", route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	described := make(map[string]bool)
+	for _, route := range apiRoutes {
+		key := route.Method + " " + route.Pattern
+		if described[key] {
+			t.Errorf("%s is described twice in apiRoutes", key)
+		}
+		described[key] = true
+		if !routed[key] {
+			t.Errorf("%s is described in apiRoutes but not routed", key)
+		}
+	}
+}
+
+// Every schema a document references is one of its components
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Errorf("%s doesn't resolve", ref)
+				}
+			}
+			for _, value := range v {
+				walk(value)
+			}
+		case []any:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(doc)
+}
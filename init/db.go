@@ -1,7 +1,6 @@
 package init
 
 import (
-	// "context"
 	"context"
 	"fmt"
 	"log"
@@ -11,16 +10,10 @@ import (
 	"server/http/helper"
 	sqlc "server/sql/database" // Adjust this import path as per your project structure
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq" // Import the PostgreSQL driver
 )
 
-// Global variables to hold the database and queries
-var (
-	DB      *pgx.Conn
-	Queries *sqlc.Queries
-)
-
 // Config holds the configuration for database connection
 type Config struct {
 	Driver   string
@@ -30,6 +23,14 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Pool settings
+	MinConns          int
+	MaxConns          int
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
 }
 
 // LoadConfig loads database configuration from environment variables or another source
@@ -42,6 +43,13 @@ func LoadConfig() Config {
 		Password: helper.GetEnv("DB_PASSWORD", "root"),
 		DBName:   helper.GetEnv("DB_NAME", "attempt2"),
 		SSLMode:  helper.GetEnv("DB_SSLMODE", "disable"),
+
+		MinConns:          helper.GetEnvInt("DB_MIN_CONNS", 2),
+		MaxConns:          helper.GetEnvInt("DB_MAX_CONNS", 10),
+		MaxConnLifetime:   helper.GetEnvDuration("DB_MAX_CONN_LIFETIME", time.Hour),
+		MaxConnIdleTime:   helper.GetEnvDuration("DB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+		HealthCheckPeriod: helper.GetEnvDuration("DB_HEALTH_CHECK_PERIOD", time.Minute),
+		ConnectTimeout:    helper.GetEnvDuration("DB_CONNECT_TIMEOUT", 5*time.Second),
 	}
 }
 
@@ -54,50 +62,70 @@ func DSN(c *Config) string {
 		" sslmode=" + c.SSLMode
 }
 
-func Connect(c *Config) *pgx.Conn {
-	// Use conf.Database to constrct the connection string and connect to the database
-	connectionDsn := DSN(c)
-
-	// Example using pgx to connect to PostgreSQL
-	conn, err := pgx.Connect(context.Background(), connectionDsn)
+// PoolConfig turns Config into a pgxpool.Config
+func PoolConfig(c *Config) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(DSN(c))
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		return nil, fmt.Errorf("could not parse db config: %w", err)
 	}
 
-	// Test the connection
-	err = conn.Ping(context.Background())
-	if err != nil {
-		log.Fatalf("Unable to ping the database: %v\n", err)
+	if c.MaxConns < 1 {
+		return nil, fmt.Errorf("DB_MAX_CONNS must be at least 1, got %d", c.MaxConns)
+	}
+	if c.MinConns < 0 || c.MinConns > c.MaxConns {
+		return nil, fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS (%d), got %d", c.MaxConns, c.MinConns)
 	}
 
-	return conn
+	poolConfig.MinConns = int32(c.MinConns)
+	poolConfig.MaxConns = int32(c.MaxConns)
+	poolConfig.MaxConnLifetime = c.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = c.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = c.HealthCheckPeriod
+	poolConfig.ConnConfig.ConnectTimeout = c.ConnectTimeout
+
+	return poolConfig, nil
 }
 
-// ConnectDB initializes the database connection and sqlc Queries
-func ConnectDB() error {
-	config := LoadConfig()
+// Connect creates a connection pool and makes sure the database is reachable
+func Connect(ctx context.Context, c *Config) (*pgxpool.Pool, error) {
+	poolConfig, err := PoolConfig(c)
+	if err != nil {
+		return nil, err
+	}
 
-	conn := Connect(&config)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
 
-	ctx, cancelFn := context.WithTimeout(context.Background(), 1*time.Second)
+	// Test the connection
+	pingCtx, cancelFn := context.WithTimeout(ctx, c.ConnectTimeout)
 	defer cancelFn()
 
-	if err := conn.Ping(ctx); err != nil {
-		return fmt.Errorf("could not connect to db: %w", err)
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("could not connect to db: %w", err)
 	}
 
-	DB = conn
-	Queries = sqlc.New(conn) // Initialize sqlc Queries with the database connection
+	return pool, nil
+}
+
+// ConnectDB initializes the database pool and the sqlc Queries built on top of it
+func ConnectDB(ctx context.Context) (*pgxpool.Pool, *sqlc.TxQueries, error) {
+	config := LoadConfig()
+
+	pool, err := Connect(ctx, &config)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	log.Println("Successfully connected to the database")
-	return nil
+	log.Printf("Successfully connected to the database (min_conns=%d, max_conns=%d)", config.MinConns, config.MaxConns)
+	return pool, sqlc.NewTxQueries(pool), nil
 }
 
-// DisconnectDB closes the database connection
-func DisconnectDB() {
-	if DB != nil {
-		ctx, cancelFn := context.WithTimeout(context.Background(), 1*time.Second)
-		defer cancelFn()
-		DB.Close(ctx)
+// DisconnectDB closes every connection in the pool
+func DisconnectDB(pool *pgxpool.Pool) {
+	if pool != nil {
+		pool.Close()
 	}
 }
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"server/audit"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// What the retention job does to users deleted for longer than the retention period
+const (
+	RetentionPurge     = "purge"     // delete them along with everything that is theirs
+	RetentionAnonymize = "anonymize" // keep their rows for the figures, without anything telling who they were
+)
+
+// EnforceRetention purges the employees and users deleted more than "days" days ago, or with
+// RetentionAnonymize anonymizes the users and keeps their employees. Either way they can't be
+// restored any more, the audit entries name nothing but their ids.
+func EnforceRetention(queries database.Querier, auditLog *audit.Recorder, logger *zap.Logger, days int, mode string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		cutoff := pgtype.Timestamp{Time: time.Now().UTC().AddDate(0, 0, -days), Valid: true}
+		anonymize := mode == RetentionAnonymize
+
+		employees, err := queries.PurgeDeletedEmployees(ctx, database.PurgeDeletedEmployeesParams{
+			DeletedBefore:      cutoff,
+			KeepOfDeletedUsers: anonymize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, emp := range employees {
+			recordRetention(ctx, auditLog, logger, "employee.purge", "employee", strconv.Itoa(int(emp.ID)))
+		}
+
+		var users []*database.User
+		action := "user.purge"
+		if anonymize {
+			action = "user.anonymize"
+			users, err = anonymizeDeletedUsers(ctx, queries, cutoff)
+		} else {
+			users, err = queries.PurgeDeletedUsers(ctx, cutoff)
+		}
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			recordRetention(ctx, auditLog, logger, action, "user", strconv.Itoa(int(user.ID)))
+		}
+
+		if len(employees)+len(users) > 0 {
+			logger.Info("enforced retention of deleted records",
+				zap.String("mode", mode),
+				zap.Int("employees", len(employees)),
+				zap.Int("users", len(users)),
+			)
+		}
+		return nil
+	}
+}
+
+// anonymizeDeletedUsers anonymizes the users and deletes their bank accounts in one transaction,
+// an account number tells who it was as much as a name. A user anonymized is never picked
+// again, so one left with their account would keep it for good.
+func anonymizeDeletedUsers(ctx context.Context, queries database.Querier, cutoff pgtype.Timestamp) ([]*database.User, error) {
+	db, ok := queries.(database.Transactor)
+	if !ok {
+		return nil, errors.New("the database can't run transactions")
+	}
+
+	var users []*database.User
+	err := db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		if users, err = q.AnonymizeDeletedUsers(ctx, cutoff); err != nil {
+			return err
+		}
+		for _, user := range users {
+			_, err := q.DeleteUserBankAccount(ctx, int64(user.ID))
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		return nil
+	})
+	return users, err
+}
+
+func recordRetention(ctx context.Context, auditLog *audit.Recorder, logger *zap.Logger, action, targetType, targetID string) {
+	_, err := auditLog.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  "enforce-retention",
+	})
+	if err != nil {
+		logger.Error("couldnot write audit log", zap.Error(err), zap.String("action", action), zap.String("target_id", targetID))
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// PurgeRevocations deletes revocation entries and refresh tokens that can no longer match a live token
+func PurgeRevocations(queries database.Querier, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+
+		tokens, err := queries.DeleteExpiredRevokedTokens(ctx, now)
+		if err != nil {
+			return err
+		}
+		cutoffs, err := queries.DeleteExpiredUserTokenCutoffs(ctx, now)
+		if err != nil {
+			return err
+		}
+		refreshTokens, err := queries.DeleteExpiredRefreshTokens(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		if tokens+cutoffs+refreshTokens > 0 {
+			logger.Info("purged expired revocations",
+				zap.Int64("revoked_tokens", tokens),
+				zap.Int64("user_token_cutoffs", cutoffs),
+				zap.Int64("refresh_tokens", refreshTokens),
+			)
+		}
+		return nil
+	}
+}
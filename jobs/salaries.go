@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"strconv"
+
+	"server/audit"
+	"server/http/helper"
+	"server/money"
+	"server/sql/database"
+
+	"go.uber.org/zap"
+)
+
+// ApplyScheduledSalaries makes the salary changes whose effective date has come the current salary
+func ApplyScheduledSalaries(queries database.Querier, auditLog *audit.Recorder, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		employees, err := queries.ApplyEffectiveSalaries(ctx, database.ApplyEffectiveSalariesParams{AsOf: helper.Today()})
+		if err != nil {
+			return err
+		}
+
+		for _, emp := range employees {
+			salary, _ := money.AmountFromNumeric(emp.Salary)
+			_, err := auditLog.Record(ctx, audit.Entry{
+				Action:     "salary.apply",
+				TargetType: "employee",
+				TargetID:   strconv.Itoa(int(emp.ID)),
+				After:      map[string]any{"salary": salary, "currency": emp.Currency},
+				RequestID:  "apply-scheduled-salaries",
+			})
+			if err != nil {
+				logger.Error("couldnot write audit log", zap.Error(err), zap.Int32("employee_id", emp.ID))
+			}
+		}
+
+		if len(employees) > 0 {
+			logger.Info("applied scheduled salary changes", zap.Int("employees", len(employees)))
+		}
+		return nil
+	}
+}
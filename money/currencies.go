@@ -0,0 +1,44 @@
+package money
+
+import "strings"
+
+// minorUnits is the number of decimals of every active ISO 4217 currency
+var minorUnits = map[string]int32{}
+
+func init() {
+	byUnits := map[int32]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN BZD " +
+			"CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS " +
+			"GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL " +
+			"MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK " +
+			"PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS " +
+			"TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VES WST XCD YER ZAR ZMW ZWL",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+		4: "CLF UYW",
+	}
+	for units, codes := range byUnits {
+		for _, code := range strings.Fields(codes) {
+			minorUnits[code] = units
+		}
+	}
+}
+
+// NormalizeCurrency is how currencies are keyed, " usd" and "USD" are the same currency
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsCurrency tells whether code is an active ISO 4217 currency code
+func IsCurrency(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// MinorUnits is the number of decimals amounts of the currency are written with, 2 when unknown
+func MinorUnits(code string) int32 {
+	if units, ok := minorUnits[code]; ok {
+		return units
+	}
+	return 2
+}
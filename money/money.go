@@ -0,0 +1,265 @@
+// Package money is the exact decimal arithmetic of salaries, taxes and exchange rates.
+//
+// Amounts never go through float64: they are read from JSON strings or numbers digit by digit,
+// stored as NUMERIC, written back to JSON as strings ("1234.5") and rounded to the minor unit
+// of their currency with the RoundingMode of the currency.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// Bounds of a parsed number, far beyond any salary or rate, they keep "1e999999999" out
+const (
+	maxDigits = 30 // before the decimal point
+	maxScale  = 20 // after it
+)
+
+func parse(s string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+	if d.Exponent() < -maxScale || int64(d.NumDigits())+int64(d.Exponent()) > maxDigits {
+		return decimal.Decimal{}, fmt.Errorf("%q is out of range", s)
+	}
+	return d, nil
+}
+
+// unmarshal reads a JSON string ("12.5") or number (12.5), null leaves the value 0
+func unmarshal(data []byte) (decimal.Decimal, error) {
+	if string(data) == "null" {
+		return decimal.Decimal{}, nil
+	}
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return decimal.Decimal{}, err
+		}
+	}
+	return parse(s)
+}
+
+func fromNumeric(n pgtype.Numeric) (decimal.Decimal, error) {
+	if !n.Valid {
+		return decimal.Decimal{}, errors.New("numeric is NULL")
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return decimal.Decimal{}, errors.New("numeric is not a finite number")
+	}
+	if n.Int == nil {
+		return decimal.Decimal{}, nil
+	}
+	return decimal.NewFromBigInt(n.Int, n.Exp), nil
+}
+
+func toNumeric(d decimal.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+}
+
+// Amount is an exact amount of money, the zero value is 0
+type Amount struct {
+	d decimal.Decimal
+}
+
+// NewAmount is a whole amount
+func NewAmount(value int64) Amount {
+	return Amount{decimal.NewFromInt(value)}
+}
+
+// ParseAmount reads a decimal amount ("1234.56")
+func ParseAmount(s string) (Amount, error) {
+	d, err := parse(s)
+	return Amount{d}, err
+}
+
+// AmountFromNumeric reads a NUMERIC column, NULL is an error
+func AmountFromNumeric(n pgtype.Numeric) (Amount, error) {
+	d, err := fromNumeric(n)
+	return Amount{d}, err
+}
+
+// Numeric is the amount as a NUMERIC parameter
+func (a Amount) Numeric() pgtype.Numeric {
+	return toNumeric(a.d)
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{a.d.Add(b.d)}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{a.d.Sub(b.d)}
+}
+
+// Mul is the amount times a rate, unrounded
+func (a Amount) Mul(r Rate) Amount {
+	return Amount{a.d.Mul(r.d)}
+}
+
+// Percent is "r" percent of the amount, unrounded
+func (a Amount) Percent(r Rate) Amount {
+	return Amount{a.d.Mul(r.d).Shift(-2)}
+}
+
+// Div splits the amount in n, to 20 decimals
+func (a Amount) Div(n int64) Amount {
+	return Amount{a.d.DivRound(decimal.NewFromInt(n), maxScale)}
+}
+
+// Round brings the amount to the minor unit of the currency with its rounding mode
+func (a Amount) Round(currency string) Amount {
+	return Amount{Rounding(currency).round(a.d, MinorUnits(currency))}
+}
+
+// Cmp is -1, 0 or +1 as a is below, equal to or above b
+func (a Amount) Cmp(b Amount) int {
+	return a.d.Cmp(b.d)
+}
+
+func (a Amount) Sign() int {
+	return a.d.Sign()
+}
+
+func (a Amount) IsZero() bool {
+	return a.d.IsZero()
+}
+
+// String is the amount without trailing zeros, "1234.5"
+func (a Amount) String() string {
+	return a.d.String()
+}
+
+// Fixed is the amount rounded to the minor unit of the currency with every decimal of it,
+// "1234.5" in USD is "1234.50"
+func (a Amount) Fixed(currency string) string {
+	return a.Round(currency).d.StringFixed(MinorUnits(currency))
+}
+
+// Format is how documents print the amount, Fixed with the thousands grouped, "1,234.50"
+func (a Amount) Format(currency string) string {
+	s := a.Fixed(currency)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString("." + fraction)
+	}
+	return sign + b.String()
+}
+
+// Max is the larger of a and b
+func Max(a, b Amount) Amount {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// Min is the smaller of a and b
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// MarshalJSON writes the amount as a string, "1234.5"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON reads a string or a number, exactly as written
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	d, err := unmarshal(data)
+	if err != nil {
+		return err
+	}
+	a.d = d
+	return nil
+}
+
+// Rate is an exact factor, an exchange rate or a percentage, the zero value is 0
+type Rate struct {
+	d decimal.Decimal
+}
+
+// NewRate is a whole rate
+func NewRate(value int64) Rate {
+	return Rate{decimal.NewFromInt(value)}
+}
+
+// ParseRate reads a decimal rate ("1.0825")
+func ParseRate(s string) (Rate, error) {
+	d, err := parse(s)
+	return Rate{d}, err
+}
+
+// RateFromNumeric reads a NUMERIC column, NULL is an error
+func RateFromNumeric(n pgtype.Numeric) (Rate, error) {
+	d, err := fromNumeric(n)
+	return Rate{d}, err
+}
+
+// Numeric is the rate as a NUMERIC parameter
+func (r Rate) Numeric() pgtype.Numeric {
+	return toNumeric(r.d)
+}
+
+func (r Rate) Mul(o Rate) Rate {
+	return Rate{r.d.Mul(o.d)}
+}
+
+// Inverse is 1 / r to 20 decimals, r must not be 0
+func (r Rate) Inverse() Rate {
+	return Rate{decimal.NewFromInt(1).DivRound(r.d, maxScale)}
+}
+
+// Cmp is -1, 0 or +1 as r is below, equal to or above o
+func (r Rate) Cmp(o Rate) int {
+	return r.d.Cmp(o.d)
+}
+
+func (r Rate) Sign() int {
+	return r.d.Sign()
+}
+
+func (r Rate) IsZero() bool {
+	return r.d.IsZero()
+}
+
+// String is the rate without trailing zeros, "1.0825"
+func (r Rate) String() string {
+	return r.d.String()
+}
+
+// MarshalJSON writes the rate as a string, "1.0825"
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON reads a string or a number, exactly as written
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	d, err := unmarshal(data)
+	if err != nil {
+		return err
+	}
+	r.d = d
+	return nil
+}
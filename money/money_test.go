@@ -0,0 +1,173 @@
+package money
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func amount(t *testing.T, s string) Amount {
+	t.Helper()
+	a, err := ParseAmount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "1234.56", want: "1234.56"},
+		{in: " 1234.50 ", want: "1234.5"},
+		{in: "-0.01", want: "-0.01"},
+		{in: "1e3", want: "1000"},
+		{in: "0.00000000000000000001", want: "0.00000000000000000001"},
+		{in: "0.000000000000000000001", wantErr: "out of range"},
+		{in: strings.Repeat("9", 30), want: strings.Repeat("9", 30)},
+		{in: strings.Repeat("9", 31), wantErr: "out of range"},
+		{in: "1e999999999", wantErr: "out of range"},
+		{in: "12,5", wantErr: "is not a decimal number"},
+		{in: "", wantErr: "is not a decimal number"},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseAmount(%q) = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseAmount(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFixed(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		{"1234.5", "USD", "1234.50"},
+		{"1234", "EUR", "1234.00"},
+		{"0.005", "USD", "0.01"},
+		{"-0.005", "USD", "-0.01"},
+		{"1234.5", "JPY", "1235"},
+		{"1.5", "KWD", "1.500"},
+		{"0", "USD", "0.00"},
+	}
+	for _, tt := range tests {
+		if got := amount(t, tt.amount).Fixed(tt.currency); got != tt.want {
+			t.Errorf("%s %s is %s, want %s", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		{"1234.5", "USD", "1,234.50"},
+		{"1234567.891", "EUR", "1,234,567.89"},
+		{"123456", "USD", "123,456.00"},
+		{"999.999", "USD", "1,000.00"},
+		{"12", "USD", "12.00"},
+		{"-1234567", "USD", "-1,234,567.00"},
+		{"-123", "USD", "-123.00"},
+		{"1234567.5", "JPY", "1,234,568"},
+		{"999", "JPY", "999"},
+		{"1234.5678", "BHD", "1,234.568"},
+	}
+	for _, tt := range tests {
+		if got := amount(t, tt.amount).Format(tt.currency); got != tt.want {
+			t.Errorf("%s %s is formatted %s, want %s", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+// Arithmetic stays exact until the amount is rounded, 0.1 + 0.2 is 0.3
+func TestArithmetic(t *testing.T) {
+	sum := amount(t, "0.1").Add(amount(t, "0.2"))
+	if sum.Cmp(amount(t, "0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if got := amount(t, "100").Sub(amount(t, "100.01")); got.String() != "-0.01" || got.Sign() != -1 {
+		t.Errorf("100 - 100.01 = %s", got)
+	}
+
+	rate, err := ParseRate("1.0825")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := amount(t, "1000").Mul(rate).String(); got != "1082.5" {
+		t.Errorf("1000 × 1.0825 = %s", got)
+	}
+	if got := amount(t, "52000").Percent(NewRate(22)).String(); got != "11440" {
+		t.Errorf("22%% of 52000 = %s", got)
+	}
+	if got := amount(t, "100").Div(3).Round("USD").String(); got != "33.33" {
+		t.Errorf("100 / 3 = %s", got)
+	}
+	if got := NewRate(8).Inverse().String(); got != "0.125" {
+		t.Errorf("1 / 8 = %s", got)
+	}
+	if got := Max(NewAmount(1), NewAmount(2)); got.Cmp(NewAmount(2)) != 0 {
+		t.Errorf("Max(1, 2) = %s", got)
+	}
+	if got := Min(NewAmount(1), NewAmount(2)); got.Cmp(NewAmount(1)) != 0 {
+		t.Errorf("Min(1, 2) = %s", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var body struct {
+		Salary Amount `json:"salary"`
+		Bonus  Amount `json:"bonus"`
+		Rate   Rate   `json:"rate"`
+		Cap    Amount `json:"cap"`
+	}
+	err := json.Unmarshal([]byte(`{"salary":"1234.50","bonus":0.1,"rate":1.0825,"cap":null}`), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A number is read as written, not through float64
+	if body.Bonus.String() != "0.1" || body.Rate.String() != "1.0825" || !body.Cap.IsZero() {
+		t.Errorf("read %+v", body)
+	}
+
+	out, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"salary":"1234.5","bonus":"0.1","rate":"1.0825","cap":"0"}`; string(out) != want {
+		t.Errorf("wrote %s, want %s", out, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"salary":"ten"}`), &body); err == nil {
+		t.Error(`"ten" read as an amount`)
+	}
+}
+
+func TestNumeric(t *testing.T) {
+	a := amount(t, "1234.56")
+	back, err := AmountFromNumeric(a.Numeric())
+	if err != nil || back.Cmp(a) != 0 {
+		t.Errorf("1234.56 came back from NUMERIC as %s, %v", back, err)
+	}
+
+	if _, err := AmountFromNumeric(pgtype.Numeric{}); err == nil {
+		t.Error("NULL read as an amount")
+	}
+	if _, err := AmountFromNumeric(pgtype.Numeric{NaN: true, Valid: true}); err == nil {
+		t.Error("NaN read as an amount")
+	}
+}
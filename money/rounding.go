@@ -0,0 +1,93 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// RoundingMode is how an amount is brought to the minor unit of its currency
+type RoundingMode int
+
+const (
+	HalfUp   RoundingMode = iota // half away from zero, 2.345 → 2.35, like Postgres ROUND()
+	HalfEven                     // half to the even digit (banker's), 2.345 → 2.34
+	Down                         // toward zero, 2.349 → 2.34
+	Up                           // away from zero, 2.341 → 2.35
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	HalfUp:   "half_up",
+	HalfEven: "half_even",
+	Down:     "down",
+	Up:       "up",
+}
+
+func (m RoundingMode) String() string {
+	if name, ok := roundingModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// ParseRoundingMode reads "half_up", "half_even", "down" or "up"
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for mode, modeName := range roundingModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q", name)
+}
+
+// roundingModes are the currencies not rounded HalfUp, set once at startup
+var roundingModes = map[string]RoundingMode{}
+
+// SetRoundingModes reads the rounding of every currency from "CHF=half_even,JPY=down",
+// the ones left out round HalfUp
+func SetRoundingModes(spec string) error {
+	modes := map[string]RoundingMode{}
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		code, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("%q: want <currency>=<mode>", entry)
+		}
+		code = NormalizeCurrency(code)
+		if !IsCurrency(code) {
+			return fmt.Errorf("%q: %q is not an ISO 4217 code", entry, code)
+		}
+		mode, err := ParseRoundingMode(name)
+		if err != nil {
+			return fmt.Errorf("%q: %w", entry, err)
+		}
+		modes[code] = mode
+	}
+	roundingModes = modes
+	return nil
+}
+
+// Rounding is the rounding mode of the currency
+func Rounding(currency string) RoundingMode {
+	if mode, ok := roundingModes[currency]; ok {
+		return mode
+	}
+	return HalfUp
+}
+
+func (m RoundingMode) round(d decimal.Decimal, places int32) decimal.Decimal {
+	switch m {
+	case HalfEven:
+		return d.RoundBank(places)
+	case Down:
+		return d.RoundDown(places)
+	case Up:
+		return d.RoundUp(places)
+	default:
+		return d.Round(places)
+	}
+}
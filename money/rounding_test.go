@@ -0,0 +1,119 @@
+package money
+
+import (
+	"strings"
+	"testing"
+)
+
+// setRoundingModes sets the modes for the test and puts the default back after it
+func setRoundingModes(t *testing.T, spec string) {
+	t.Helper()
+	if err := SetRoundingModes(spec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { roundingModes = map[string]RoundingMode{} })
+}
+
+func TestRound(t *testing.T) {
+	setRoundingModes(t, "EUR=half_even,CHF=down,SEK=up,JPY=half_even,KRW=down")
+
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		// USD is left out, half away from zero
+		{"2.345", "USD", "2.35"},
+		{"2.344", "USD", "2.34"},
+		{"-2.345", "USD", "-2.35"},
+		{"2.3", "USD", "2.3"},
+		// EUR half to even
+		{"2.345", "EUR", "2.34"},
+		{"2.355", "EUR", "2.36"},
+		{"2.3451", "EUR", "2.35"}, // above the half, whatever the digit
+		{"-2.345", "EUR", "-2.34"},
+		// CHF toward zero
+		{"2.349", "CHF", "2.34"},
+		{"-2.349", "CHF", "-2.34"},
+		// SEK away from zero
+		{"2.341", "SEK", "2.35"},
+		{"-2.341", "SEK", "-2.35"},
+		{"2.34", "SEK", "2.34"},
+		// No minor unit
+		{"1234.5", "JPY", "1234"},
+		{"1235.5", "JPY", "1236"},
+		{"1234.99", "KRW", "1234"},
+		{"1234.5", "ISK", "1235"},
+		// 3 decimals
+		{"1.2345", "KWD", "1.235"},
+		{"1.2344", "BHD", "1.234"},
+		// Unknown currencies have 2 decimals
+		{"1.005", "XXX", "1.01"},
+	}
+	for _, tt := range tests {
+		got := amount(t, tt.amount).Round(tt.currency).String()
+		if got != tt.want {
+			t.Errorf("%s %s rounded to %s, want %s (%s)", tt.amount, tt.currency, got, tt.want, Rounding(tt.currency))
+		}
+	}
+}
+
+func TestSetRoundingModes(t *testing.T) {
+	setRoundingModes(t, " chf = HALF_EVEN , jpy=down,, ")
+	if got := Rounding("CHF"); got != HalfEven {
+		t.Errorf("CHF rounds %s, want half_even", got)
+	}
+	if got := Rounding("JPY"); got != Down {
+		t.Errorf("JPY rounds %s, want down", got)
+	}
+	if got := Rounding("USD"); got != HalfUp {
+		t.Errorf("USD rounds %s, want the default half_up", got)
+	}
+
+	setRoundingModes(t, "")
+	if got := Rounding("CHF"); got != HalfUp {
+		t.Errorf("CHF rounds %s after an empty spec, want half_up", got)
+	}
+}
+
+func TestSetRoundingModesErrors(t *testing.T) {
+	setRoundingModes(t, "CHF=down")
+
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"CHF", "want <currency>=<mode>"},
+		{"CHF=half_odd", `unknown rounding mode "half_odd"`},
+		{"ABC=down", `"ABC" is not an ISO 4217 code`},
+		{"EUR=up,CHF", "want <currency>=<mode>"},
+	}
+	for _, tt := range tests {
+		err := SetRoundingModes(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("SetRoundingModes(%q) = %v, want %q", tt.spec, err, tt.wantErr)
+		}
+	}
+	// A spec with an error changes nothing
+	if got := Rounding("CHF"); got != Down {
+		t.Errorf("CHF rounds %s, want the down set before", got)
+	}
+	if got := Rounding("EUR"); got != HalfUp {
+		t.Errorf("EUR rounds %s, want half_up", got)
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for mode, name := range roundingModeNames {
+		got, err := ParseRoundingMode(name)
+		if err != nil || got != mode {
+			t.Errorf("ParseRoundingMode(%q) = %v, %v", name, got, err)
+		}
+		if mode.String() != name {
+			t.Errorf("%d is named %s, want %s", int(mode), mode, name)
+		}
+	}
+	if got := RoundingMode(42).String(); got != "RoundingMode(42)" {
+		t.Errorf("unknown mode is named %s", got)
+	}
+}
@@ -0,0 +1,257 @@
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"server/http/response"
+	"server/sheet"
+
+	"github.com/go-chi/chi"
+)
+
+// Route describes what a route takes and answers, the router has the rest
+type Route struct {
+	Method      string
+	Pattern     string // as routed, without trailing slash: "/v1/emp/payslips/{id}"
+	ID          string // the handler, "ListPayslips"
+	Tag         string
+	Summary     string
+	Description string
+	Public      bool   // no access token needed
+	Permission  string // asked for by RequirePermission
+
+	Query     []Param
+	Body      any            // a value of the Go type of the body, or a *Schema
+	BodyTypes []string       // "application/json" when empty
+	Bodies    map[string]any // a body per media type instead, e.g. the kinds of patches
+
+	Status       int    // of a success, 200 when 0
+	Response     any    // like Body, nil without JSON
+	ResponseType string // of a success which is a file rather than JSON
+	XLSX         bool   // a spreadsheet for "format=xlsx" or an Accept header preferring it
+	// The success answers the ETag of the row: a GET honours "If-None-Match" (304), any other
+	// method "If-Match" (412)
+	ETag bool
+
+	// Answered with a problem like any other failure, 401 and 403 follow from Public and
+	// Permission
+	Errors []int
+	// Answers besides the success and the errors, e.g. the 200 of a dry run
+	Others map[int]any
+}
+
+// Param is a query parameter
+type Param struct {
+	Name        string
+	Description string
+	Schema      *Schema // a string when nil
+	Required    bool
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Build documents every route of "router": an operation per method and path, described by
+// the Route of the same method and pattern. A route without one is still there, with its
+// path parameters only, and listed in "undocumented". Routes needing an access token accept
+// any of "security".
+func Build(info Info, security map[string]*SecurityScheme, router chi.Routes, routes []Route) (*Document, []string) {
+	b := &builder{schemas: newSchemas(), security: security}
+	b.problem = b.schemas.value(response.Problem{})
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas:         b.schemas.components,
+			SecuritySchemes: security,
+		},
+	}
+
+	described := make(map[string]Route, len(routes))
+	for _, route := range routes {
+		described[route.Method+" "+route.Pattern] = route
+		if route.Tag != "" && !slices.ContainsFunc(doc.Tags, func(t Tag) bool { return t.Name == route.Tag }) {
+			doc.Tags = append(doc.Tags, Tag{Name: route.Tag})
+		}
+	}
+
+	var undocumented []string
+	chi.Walk(router, func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pattern = CleanPattern(pattern)
+		route, ok := described[method+" "+pattern]
+		if !ok {
+			undocumented = append(undocumented, method+" "+pattern)
+			route = Route{Method: method, Pattern: pattern, Summary: "Undocumented"}
+		}
+
+		if doc.Paths[pattern] == nil {
+			doc.Paths[pattern] = make(map[string]*Operation)
+		}
+		doc.Paths[pattern][strings.ToLower(method)] = b.operation(route)
+		return nil
+	})
+	slices.Sort(undocumented)
+	return doc, undocumented
+}
+
+// CleanPattern is the path of a routing pattern: chi walks a group mounted on "/" as "/*/"
+// and the root of a sub-router with a trailing slash
+func CleanPattern(pattern string) string {
+	for strings.Contains(pattern, "/*/") {
+		pattern = strings.ReplaceAll(pattern, "/*/", "/")
+	}
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+type builder struct {
+	schemas  *schemas
+	security map[string]*SecurityScheme
+	problem  *Schema // the reference of every failure
+}
+
+func (b *builder) operation(route Route) *Operation {
+	op := &Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   make(map[string]*Response),
+		Permission:  route.Permission,
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Permission != "" {
+		op.Description = strings.TrimSpace(op.Description + "\n\nRequires the `" + route.Permission + "` permission.")
+	}
+	if !route.Public {
+		for _, name := range slices.Sorted(maps.Keys(b.security)) {
+			op.Security = append(op.Security, map[string][]string{name: {}})
+		}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Pattern, -1) {
+		schema := Scalar("integer", "int64", "")
+		if !strings.HasSuffix(strings.ToLower(match[1]), "id") {
+			schema = Scalar("string", "", "")
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, param := range route.Query {
+		schema := param.Schema
+		if schema == nil {
+			schema = Scalar("string", "", "")
+		}
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      schema,
+		})
+	}
+	if route.XLSX {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        "format",
+			In:          "query",
+			Description: "`xlsx` answers with a spreadsheet, as does an `Accept` header preferring it; `json` by default",
+			Schema:      Enum("", "json", "xlsx"),
+		})
+	}
+
+	if route.ETag {
+		condition := &Parameter{
+			Name:        "If-Match",
+			In:          "header",
+			Description: "The change only goes through while the ETag is still one of those listed, `*` for any",
+			Schema:      Scalar("string", "", ""),
+		}
+		if route.Method == http.MethodGet {
+			condition.Name, condition.Description = "If-None-Match", "Answered 304 without a body while the ETag is still one of those listed"
+		}
+		op.Parameters = append(op.Parameters, condition)
+	}
+
+	bodies := route.Bodies
+	if route.Body != nil {
+		types := route.BodyTypes
+		if len(types) == 0 {
+			types = []string{"application/json"}
+		}
+		bodies = make(map[string]any, len(types))
+		for _, typ := range types {
+			bodies[typ] = route.Body
+		}
+	}
+	if len(bodies) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+		for _, typ := range slices.Sorted(maps.Keys(bodies)) {
+			op.RequestBody.Content[typ] = &MediaType{Schema: b.schemas.value(bodies[typ])}
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status), Content: make(map[string]*MediaType)}
+	switch {
+	case route.ResponseType != "":
+		success.Content[route.ResponseType] = &MediaType{Schema: Scalar("string", "binary", "")}
+	case route.Response != nil:
+		success.Content["application/json"] = &MediaType{Schema: b.schemas.value(route.Response)}
+	}
+	if route.XLSX {
+		success.Content[sheet.ContentType] = &MediaType{Schema: Scalar("string", "binary", "")}
+	}
+	if route.ETag {
+		success.Headers = map[string]*Header{"ETag": {
+			Description: "Version of the row, for `If-Match` and `If-None-Match`",
+			Schema:      Scalar("string", "", ""),
+		}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	if route.ETag && route.Method == http.MethodGet {
+		op.Responses[strconv.Itoa(http.StatusNotModified)] = &Response{Description: http.StatusText(http.StatusNotModified)}
+	}
+
+	errors := slices.Clone(route.Errors)
+	// A JSON body is refused when it doesn't decode (400), is too large (413) or fails validation (422)
+	if op.RequestBody != nil && op.RequestBody.Content["application/json"] != nil {
+		errors = append(errors, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)
+	}
+	if !route.Public {
+		errors = append(errors, http.StatusUnauthorized)
+	}
+	if route.Permission != "" {
+		errors = append(errors, http.StatusForbidden)
+	}
+	if route.ETag && route.Method != http.MethodGet {
+		errors = append(errors, http.StatusPreconditionFailed)
+	}
+	for _, code := range errors {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{response.ProblemContentType: {Schema: b.problem}},
+		}
+	}
+	op.Responses["default"] = &Response{
+		Description: "Any other error",
+		Content:     map[string]*MediaType{response.ProblemContentType: {Schema: b.problem}},
+	}
+	for code, body := range route.Others {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{"application/json": {Schema: b.schemas.value(body)}},
+		}
+	}
+	return op
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"server/http/response"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Handler serves the document, built once every route is registered, and a page browsing it
+type Handler struct {
+	Document *Document
+}
+
+// ServeJSON answers with the document
+func (h *Handler) ServeJSON(w http.ResponseWriter, r *http.Request) {
+	if h.Document == nil {
+		response.RespondeWithError(w, http.StatusServiceUnavailable, "the API description isn't built yet")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	response.RespondeWithJSON(w, http.StatusOK, h.Document)
+}
+
+// ServeDocs answers with a page listing the operations of the document, and trying them out
+func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsPage)
+}
@@ -0,0 +1,260 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/money"
+	"server/validate"
+)
+
+const componentsPath = "#/components/schemas/"
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	rawType    = reflect.TypeFor[json.RawMessage]()
+	amountType = reflect.TypeFor[money.Amount]()
+	rateType   = reflect.TypeFor[money.Rate]()
+)
+
+// Amounts and rates are answered as strings and read from strings or numbers, always exactly
+var decimals = map[reflect.Type]*Schema{
+	amountType: {
+		Type:        []string{"string", "number"},
+		Format:      "decimal",
+		Description: "An exact amount, answered as a string; a number is read exactly as written too",
+		Examples:    []any{"1234.56"},
+	},
+	rateType: {
+		Type:        []string{"string", "number"},
+		Format:      "decimal",
+		Description: "An exact factor, an exchange rate or a percentage, answered as a string",
+		Examples:    []any{"1.08"},
+	},
+}
+
+// schemas derives the schemas of Go types the way encoding/json encodes them, a named
+// struct is a component referenced by its name
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	taken      map[string]bool
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+		taken:      make(map[string]bool),
+	}
+}
+
+// value is the schema of the type of "v", or "v" itself when it is a *Schema, of Fields, OneOf or Partial
+func (s *schemas) value(v any) *Schema {
+	switch v := v.(type) {
+	case *Schema:
+		return v
+	case Fields:
+		obj := &Schema{Type: "object", Properties: make(map[string]*Schema, len(v))}
+		for name, field := range v {
+			obj.Properties[name] = s.value(field)
+			obj.Required = append(obj.Required, name)
+		}
+		slices.Sort(obj.Required)
+		return obj
+	case oneOf:
+		alternatives := &Schema{}
+		for _, value := range v {
+			alternatives.OneOf = append(alternatives.OneOf, s.value(value))
+		}
+		return alternatives
+	case partial:
+		schema := s.value(v.value)
+		if name, ok := strings.CutPrefix(schema.Ref, componentsPath); ok {
+			schema = s.components[name]
+		}
+		patch := *schema
+		patch.Required = nil
+		patch.Description = strings.TrimSpace(patch.Description + " Only the fields to change, null resets one.")
+		return &patch
+	}
+	return s.of(reflect.TypeOf(v))
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return Scalar("string", "date-time", "")
+	case rawType:
+		return &Schema{Description: "Any JSON value"}
+	}
+	if decimal, ok := decimals[t]; ok {
+		return s.component(t, func() *Schema { return decimal })
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.String:
+		return Scalar("string", "", "")
+	case reflect.Bool:
+		return Scalar("boolean", "", "")
+	case reflect.Int32, reflect.Uint32:
+		return Scalar("integer", "int32", "")
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return Scalar("integer", "int64", "")
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return Scalar("integer", "", "")
+	case reflect.Float32, reflect.Float64:
+		return Scalar("number", "", "")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Scalar("string", "byte", "")
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.component(t, func() *Schema { return s.object(t) })
+	}
+	return &Schema{}
+}
+
+// component adds the schema of a named type once and references it
+func (s *schemas) component(t reflect.Type, schema func() *Schema) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = s.name(t)
+		s.names[t] = name
+		s.components[name] = schema()
+	}
+	return &Schema{Ref: componentsPath + name}
+}
+
+// name is the type name, prefixed with its package when another package took it first
+func (s *schemas) name(t reflect.Type) string {
+	name := t.Name()
+	if s.taken[name] {
+		pkg := strings.ReplaceAll(path.Base(t.PkgPath()), "_", "")
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.taken[name] = true
+	return name
+}
+
+// object lists the fields of a struct as encoding/json does: the fields of an embedded struct
+// are inlined, omitted ones are optional and a pointer that isn't omitted may be null
+func (s *schemas) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(obj, t, false)
+	return obj
+}
+
+func (s *schemas) fields(obj *Schema, t reflect.Type, optional bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded, pointer := field.Type, false
+			if embedded.Kind() == reflect.Pointer {
+				embedded, pointer = embedded.Elem(), true
+			}
+			if embedded.Kind() == reflect.Struct {
+				// A nil embedded pointer leaves its fields out
+				s.fields(obj, embedded, optional || pointer)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		omitted := false
+		for _, option := range strings.Split(options, ",") {
+			omitted = omitted || option == "omitempty" || option == "omitzero"
+		}
+		schema := s.of(field.Type)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			constrain(schema, rules)
+		}
+		if field.Type.Kind() == reflect.Pointer && !omitted {
+			schema = nullable(schema)
+		}
+		obj.Properties[name] = schema
+		if !omitted && !optional {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+}
+
+// constrain adds to "schema" what the "validate" tag of its field checks, see package validate
+func constrain(schema *Schema, rules string) {
+	str := schema.Type == "string"
+	for _, rule := range strings.Split(rules, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch rule {
+		case "required":
+			if str && schema.MinLength == nil {
+				schema.MinLength = length(1)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			switch {
+			case str && err == nil && rule == "min":
+				schema.MinLength = length(n)
+			case str && err == nil:
+				schema.MaxLength = length(n)
+			case rule == "min":
+				schema.Minimum = json.Number(param)
+			default:
+				schema.Maximum = json.Number(param)
+			}
+		case "email":
+			schema.Format = "email"
+		case "date":
+			schema.Format = "date"
+		case "country":
+			schema.Pattern, schema.Description = "^[A-Z]{2}$", "ISO 3166-1 alpha-2"
+		case "currency":
+			schema.Pattern, schema.Description = "^[A-Z]{3}$", "ISO 4217"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "password":
+			schema.MinLength = length(validate.MinPasswordLength)
+			schema.Description = "Mixes three of lower case, upper case, digits and symbols"
+		}
+	}
+}
+
+func length(n int) *int {
+	return &n
+}
+
+// nullable also takes null
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+	}
+	if typ, ok := schema.Type.(string); ok {
+		copied := *schema
+		copied.Type = []string{typ, "null"}
+		return &copied
+	}
+	return schema
+}
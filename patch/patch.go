@@ -0,0 +1,383 @@
+// Package patch changes a JSON document with a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902). Numbers are kept as written, an amount goes through a patch exactly.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The media types of the two kinds of patches
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Operation is one step of a JSON Patch, "value" is there for add, replace and test, "from" for
+// move and copy. Paths are JSON Pointers (RFC 6901): "/salary", "/-" appends to an array.
+type Operation struct {
+	Op    string          `json:"op" validate:"required,oneof=add remove replace move copy test"`
+	Path  string          `json:"path" validate:"required"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ErrMalformed is a patch that isn't one: not JSON, an unknown "op", a missing "path"...
+var ErrMalformed = errors.New("malformed patch")
+
+// ErrTestFailed is a JSON Patch whose "test" operation didn't hold, nothing was changed
+var ErrTestFailed = errors.New("test failed")
+
+// Merge applies the merge patch "patch" to "doc": the members of an object replace those of the
+// document, recursively, and a null removes one.
+func Merge(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any)
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = merge(object[name], value)
+		}
+	}
+	return object
+}
+
+// Apply applies the operations of the JSON Patch "patch" to "doc", in order. One that fails
+// fails the whole patch.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: an array of operations is expected", ErrMalformed)
+	}
+
+	for i, op := range ops {
+		if target, err = apply(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func apply(doc any, op map[string]json.RawMessage) (any, error) {
+	name, err := member(op, "op")
+	if err != nil {
+		return nil, err
+	}
+	path, err := member(op, "path")
+	if err != nil {
+		return nil, err
+	}
+	at, err := pointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		raw, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q needs a \"value\"", ErrMalformed, name)
+		}
+		value, err := decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		switch name {
+		case "add":
+			return add(doc, at, value)
+		case "replace":
+			if doc, _, err = remove(doc, at); err != nil {
+				return nil, err
+			}
+			return add(doc, at, value)
+		}
+		current, err := get(doc, at)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrTestFailed, path)
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := remove(doc, at)
+		return doc, err
+	case "move", "copy":
+		fromPath, err := member(op, "from")
+		if err != nil {
+			return nil, err
+		}
+		from, err := pointer(fromPath)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if name == "move" {
+			if strings.HasPrefix(path+"/", fromPath+"/") && path != fromPath {
+				return nil, fmt.Errorf("can't move %s into itself", fromPath)
+			}
+			doc, value, err = remove(doc, from)
+		} else {
+			value, err = get(doc, from)
+			value = clone(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, at, value)
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrMalformed, name)
+}
+
+// member is the string member "name" of an operation
+func member(op map[string]json.RawMessage, name string) (string, error) {
+	var value string
+	raw, ok := op[name]
+	if !ok || json.Unmarshal(raw, &value) != nil {
+		return "", fmt.Errorf("%w: a string %q is required", ErrMalformed, name)
+	}
+	return value, nil
+}
+
+// pointer splits a JSON Pointer (RFC 6901), "/a~1b/0" is ["a/b", "0"] and "" the whole document
+func pointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: %q doesn't start with /", ErrMalformed, path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, at []string) (any, error) {
+	for i, token := range at {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, missing(at[:i+1])
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, missing(at[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// add sets the member "at" or inserts the item "at", its parent has to exist
+func add(doc any, at []string, value any) (any, error) {
+	if len(at) == 0 {
+		return value, nil
+	}
+	parent, last := at[:len(at)-1], at[len(at)-1]
+	container, err := get(doc, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := container.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		index := len(node)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		// A new array, the old one may be shared with a value moved or read before
+		return set(doc, parent, slices.Concat(node[:index], []any{value}, node[index:]))
+	}
+	return nil, missing(parent)
+}
+
+// set replaces the member or item "at", which exists
+func set(doc any, at []string, value any) (any, error) {
+	if len(at) == 0 {
+		return value, nil
+	}
+	parent, last := at[:len(at)-1], at[len(at)-1]
+	container, err := get(doc, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := container.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+		return doc, nil
+	}
+	return nil, missing(parent)
+}
+
+// remove takes the member or item "at" out and returns it
+func remove(doc any, at []string) (any, any, error) {
+	if len(at) == 0 {
+		return nil, doc, nil
+	}
+	parent, last := at[:len(at)-1], at[len(at)-1]
+	container, err := get(doc, parent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch node := container.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, missing(at)
+		}
+		delete(node, last)
+		return doc, value, nil
+	case []any:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[index]
+		items := make([]any, 0, len(node)-1) // [] rather than null once the last item is out
+		doc, err = set(doc, parent, append(append(items, node[:index]...), node[index+1:]...))
+		return doc, value, err
+	}
+	return nil, nil, missing(at)
+}
+
+// arrayIndex reads an array index of at most "max", without leading zeros
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("index %d is out of range", index)
+	}
+	return index, nil
+}
+
+func missing(at []string) error {
+	escaped := make([]string, len(at))
+	for i, token := range at {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return fmt.Errorf("/%s doesn't exist", strings.Join(escaped, "/"))
+}
+
+// decode reads a single JSON value, numbers as json.Number
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("only one JSON value is allowed")
+	}
+	return value, nil
+}
+
+// equal compares JSON values, numbers by value: 1 equals 1.0
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okA := new(big.Rat).SetString(a.String())
+		y, okB := new(big.Rat).SetString(b.String())
+		return okA && okB && x.Cmp(y) == 0
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// clone copies the objects and arrays of a value, a copied value is changed on its own
+func clone(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for name, v := range value {
+			copied[name] = clone(v)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, v := range value {
+			copied[i] = clone(v)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,340 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+)
+
+// sameJSON compares two documents as JSON values, numbers by value
+func sameJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	a, err := decode(got)
+	if err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	b, err := decode([]byte(want))
+	if err != nil {
+		t.Fatalf("invalid want %s: %v", want, err)
+	}
+	return equal(a, b)
+}
+
+// The examples of RFC 6902 appendix A, then the cases around them
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		// The error, nil with "want" empty is any error
+		wantErr error
+	}{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name:  "A.8 testing a value, success",
+			doc:   `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:    "A.9 testing a value, error",
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:  "A.12 adding to a nonexistent target",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+		},
+		{
+			name:  "A.13 invalid JSON patch document",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "op": "remove"}]`,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:    "A.15 comparing strings and numbers",
+			doc:     `{"/": 9, "~1": 10}`,
+			patch:   `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+
+		{
+			name:  "escaped / and ~ in paths",
+			doc:   `{"a/b": 1, "m~n": 2}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 3}, {"op": "remove", "path": "/m~0n"}, {"op": "add", "path": "/~0~1", "value": 4}]`,
+			want:  `{"a/b": 3, "~/": 4}`,
+		},
+		{
+			name:  "test numbers by value",
+			doc:   `{"salary": 1500, "rate": 0.1}`,
+			patch: `[{"op": "test", "path": "/salary", "value": 1500.00}, {"op": "test", "path": "/salary", "value": 1.5e3}, {"op": "test", "path": "/rate", "value": 1e-1}]`,
+			want:  `{"salary": 1500, "rate": 0.1}`,
+		},
+		{
+			name:    "test a different number",
+			doc:     `{"salary": 1500}`,
+			patch:   `[{"op": "test", "path": "/salary", "value": 1500.01}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "test a number against a bool",
+			doc:     `{"active": 1}`,
+			patch:   `[{"op": "test", "path": "/active", "value": true}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "test objects and arrays",
+			doc:   `{"a": {"b": [1, {"c": 2}]}}`,
+			patch: `[{"op": "test", "path": "/a", "value": {"b": [1.0, {"c": 2}]}}]`,
+			want:  `{"a": {"b": [1, {"c": 2}]}}`,
+		},
+		{
+			name:    "test an array of another length",
+			doc:     `{"a": [1, 2]}`,
+			patch:   `[{"op": "test", "path": "/a", "value": [1, 2, 3]}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "copy an array then add to the copy",
+			doc:   `{"a": [1, 2, 3]}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/b"}, {"op": "add", "path": "/b/1", "value": 9}, {"op": "add", "path": "/b/-", "value": 8}]`,
+			want:  `{"a": [1, 2, 3], "b": [1, 9, 2, 3, 8]}`,
+		},
+		{
+			name:  "copy an array then remove from the original",
+			doc:   `{"a": [1, 2, 3]}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/b"}, {"op": "remove", "path": "/a/0"}, {"op": "add", "path": "/a/0", "value": 7}]`,
+			want:  `{"a": [7, 2, 3], "b": [1, 2, 3]}`,
+		},
+		{
+			name:  "copy an object then change the copy",
+			doc:   `{"a": {"b": {"c": 1}}}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/d"}, {"op": "replace", "path": "/d/b/c", "value": 2}]`,
+			want:  `{"a": {"b": {"c": 1}}, "d": {"b": {"c": 2}}}`,
+		},
+		{
+			name:  "copy an array element into the same array",
+			doc:   `{"a": [[1], 2]}`,
+			patch: `[{"op": "copy", "from": "/a/0", "path": "/a/-"}, {"op": "add", "path": "/a/2/-", "value": 3}]`,
+			want:  `{"a": [[1], 2, [1, 3]]}`,
+		},
+		{
+			name:  "move an array element backwards",
+			doc:   `{"a": [1, 2, 3, 4]}`,
+			patch: `[{"op": "move", "from": "/a/3", "path": "/a/0"}]`,
+			want:  `{"a": [4, 1, 2, 3]}`,
+		},
+		{
+			name:  "move between arrays",
+			doc:   `{"a": [1, 2], "b": [3]}`,
+			patch: `[{"op": "move", "from": "/a/0", "path": "/b/-"}]`,
+			want:  `{"a": [2], "b": [3, 1]}`,
+		},
+		{
+			name:  "move onto itself",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a"}]`,
+			want:  `{"a": {"b": 1}}`,
+		},
+		{
+			name:  "move into a child of itself",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/b"}]`,
+		},
+		{
+			name:  "add and remove nested array items",
+			doc:   `{"a": [[1, 2], [3]]}`,
+			patch: `[{"op": "add", "path": "/a/0/1", "value": 9}, {"op": "remove", "path": "/a/1/0"}]`,
+			want:  `{"a": [[1, 9, 2], []]}`,
+		},
+		{
+			name:  "replace the whole document",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "replace", "path": "", "value": [1]}]`,
+			want:  `[1]`,
+		},
+		{
+			name:  "replace a missing member",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "replace", "path": "/b", "value": 2}]`,
+		},
+		{
+			name:  "add past the end of an array",
+			doc:   `{"a": [1]}`,
+			patch: `[{"op": "add", "path": "/a/2", "value": 2}]`,
+		},
+		{
+			name:  "array index with a leading zero",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/a/01"}]`,
+		},
+		{
+			name:    "path without a leading /",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "remove", "path": "a"}]`,
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "increment", "path": "/a"}]`,
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "add without a value",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "add", "path": "/b"}]`,
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "not an array of operations",
+			doc:     `{"a": 1}`,
+			patch:   `{"op": "remove", "path": "/a"}`,
+			wantErr: ErrMalformed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("applied as %s, want an error", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameJSON(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The examples of RFC 7396 appendix A
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b"}`, `{"a": null}`, `{}`},
+		{`{"a": "b", "b": "c"}`, `{"a": null}`, `{"b": "c"}`},
+		{`{"a": ["b"]}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "c"}`, `{"a": ["b"]}`, `{"a": ["b"]}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a", "b"]`, `["c", "d"]`, `["c", "d"]`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"a": "foo"}`, `null`, `null`},
+		{`{"a": "foo"}`, `"bar"`, `"bar"`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`[1, 2]`, `{"a": "b", "c": null}`, `{"a": "b"}`},
+		{`{}`, `{"a": {"bb": {"ccc": null}}}`, `{"a": {"bb": {}}}`},
+	}
+	for _, tt := range tests {
+		got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Merge(%s, %s): %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if !sameJSON(t, got, tt.want) {
+			t.Errorf("Merge(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+
+	if _, err := Merge([]byte(`{"a": 1}`), []byte(`{"a": `)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated patch: %v, want ErrMalformed", err)
+	}
+}
+
+// An amount goes through either kind of patch digit for digit
+func TestNumbersKept(t *testing.T) {
+	doc := []byte(`{"salary":"0","bonus":1234567890123456789.000000000000000001}`)
+
+	got, err := Merge(doc, []byte(`{"salary":98765432109876543210.12345678901234567890}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"bonus":1234567890123456789.000000000000000001,"salary":98765432109876543210.12345678901234567890}`; string(got) != want {
+		t.Errorf("Merge = %s, want %s", got, want)
+	}
+
+	got, err = Apply(doc, []byte(`[{"op":"replace","path":"/salary","value":0.10},{"op":"test","path":"/bonus","value":1234567890123456789.000000000000000001}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"bonus":1234567890123456789.000000000000000001,"salary":0.10}`; string(got) != want {
+		t.Errorf("Apply = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server/bank"
+	"server/sql/database"
+)
+
+// Batch pays the net of the payslips of a locked run paid in the currency of "scheme" to the
+// bank accounts of that scheme, the payslips in other currencies are left to the other scheme
+// and a net of 0 isn't paid. Every employee to pay needs an account of the scheme, the error
+// names those without one.
+func Batch(run Run, payslips []Payslip, accounts []*database.BankAccount, scheme string, created time.Time) (bank.Batch, error) {
+	if run.Status != StatusLocked {
+		return bank.Batch{}, fmt.Errorf("payroll run is %s, only a locked run is paid", run.Status)
+	}
+	payDate, err := time.Parse(time.DateOnly, run.PayDate)
+	if err != nil {
+		return bank.Batch{}, err
+	}
+
+	byUser := make(map[int64]bank.Account, len(accounts))
+	for _, a := range accounts {
+		byUser[a.UserID] = bank.AccountFromDB(a)
+	}
+
+	currency := bank.Currency(scheme)
+	batch := bank.Batch{
+		ID:          fmt.Sprintf("PAYROLL-%d-%s", run.ID, created.UTC().Format("20060102150405")),
+		Description: "PAYROLL",
+		Created:     created,
+		PayDate:     payDate,
+	}
+	var missing []string
+	for _, p := range payslips {
+		if p.Currency != currency || p.NetPay.Sign() <= 0 {
+			continue
+		}
+		account, ok := byUser[p.UserID]
+		if !ok || account.Scheme != scheme {
+			missing = append(missing, fmt.Sprint(p.EmployeeID))
+			continue
+		}
+		batch.Payments = append(batch.Payments, bank.Payment{
+			ID:        fmt.Sprintf("PAYSLIP-%d", p.ID),
+			Account:   account,
+			Amount:    p.NetPay,
+			Reference: fmt.Sprintf("Salary %s to %s", p.PeriodStart, p.PeriodEnd),
+		})
+	}
+
+	if len(missing) > 0 {
+		if len(missing) == 1 {
+			return bank.Batch{}, fmt.Errorf("employee %s has no %s bank account", missing[0], strings.ToUpper(scheme))
+		}
+		return bank.Batch{}, fmt.Errorf("employees %s have no %s bank account", strings.Join(missing, ", "), strings.ToUpper(scheme))
+	}
+	if len(batch.Payments) == 0 {
+		return bank.Batch{}, errors.New("payroll run pays nothing in " + currency)
+	}
+	return batch, nil
+}
@@ -0,0 +1,146 @@
+// Package payroll pays the yearly salaries out per pay period.
+//
+// A run covers one monthly or bi-weekly period, it snapshots the gross, every deduction and
+// the net of each employee with a salary into a payslip. The yearly tax breakdown of the
+// salary is split evenly over the periods of the year, so twelve monthly payslips add up
+// to the yearly figures give or take the rounding of each one.
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"server/http/helper"
+	"server/money"
+	"server/tax"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Pay frequencies, "payroll_runs.frequency"
+const (
+	Monthly  = "monthly"
+	Biweekly = "biweekly"
+)
+
+// Statuses of a run, "payroll_runs.status". Only a draft can be recalculated or deleted,
+// a locked run is issued to the employees, a reversed one stays for the record.
+const (
+	StatusDraft    = "draft"
+	StatusLocked   = "locked"
+	StatusReversed = "reversed"
+)
+
+// PeriodsPerYear is how many pay periods a year of the frequency has
+func PeriodsPerYear(frequency string) (int64, error) {
+	switch frequency {
+	case Monthly:
+		return 12, nil
+	case Biweekly:
+		return 26, nil
+	}
+	return 0, fmt.Errorf("frequency must be %q or %q", Monthly, Biweekly)
+}
+
+// Period is the span a run pays for
+type Period struct {
+	Frequency string
+	Start     pgtype.Date
+	End       pgtype.Date
+	PayDate   pgtype.Date
+}
+
+// NewPeriod reads the period starting on "start" (YYYY-MM-DD). A monthly period is a calendar
+// month and starts on the 1st, a bi-weekly one is 14 days from any day. The pay date is the
+// last day of the period unless "payDate" says otherwise.
+func NewPeriod(frequency, start, payDate string) (Period, error) {
+	if _, err := PeriodsPerYear(frequency); err != nil {
+		return Period{}, err
+	}
+	p := Period{Frequency: frequency}
+
+	var err error
+	if p.Start, err = helper.ParseDate(start); err != nil {
+		return Period{}, errors.New("period_start must be YYYY-MM-DD")
+	}
+	switch frequency {
+	case Monthly:
+		if p.Start.Time.Day() != 1 {
+			return Period{}, errors.New("a monthly period_start must be the 1st of a month")
+		}
+		p.End = pgtype.Date{Time: p.Start.Time.AddDate(0, 1, -1), Valid: true}
+	case Biweekly:
+		p.End = pgtype.Date{Time: p.Start.Time.AddDate(0, 0, 13), Valid: true}
+	}
+
+	p.PayDate = p.End
+	if payDate != "" {
+		if p.PayDate, err = helper.ParseDate(payDate); err != nil {
+			return Period{}, errors.New("pay_date must be YYYY-MM-DD")
+		}
+		if p.PayDate.Time.Before(p.Start.Time) {
+			return Period{}, errors.New("pay_date can't be before period_start")
+		}
+	}
+	return p, nil
+}
+
+// TaxYear is the year taxes are due for, the year the period is paid in
+func (p Period) TaxYear() int32 {
+	return int32(p.PayDate.Time.Year())
+}
+
+// Line is one deduction of a payslip, its share of the yearly line item
+type Line struct {
+	Kind   string       `json:"kind"` // "income_tax" or "contribution"
+	Name   string       `json:"name"`
+	Rate   money.Rate   `json:"rate"`
+	Amount money.Amount `json:"amount"`
+}
+
+// Pay is what a payslip pays for one period, in the currency of the tax regime
+type Pay struct {
+	GrossPay        money.Amount
+	Deductions      []Line
+	IncomeTax       money.Amount
+	Contributions   money.Amount
+	TotalDeductions money.Amount
+	NetPay          money.Amount
+}
+
+// Split pays a yearly breakdown out over "periods" periods. Every amount is rounded to the
+// minor unit of the currency, the totals are the sums of the rounded lines so a payslip
+// always adds up.
+func Split(b tax.Breakdown, periods int64) Pay {
+	p := Pay{
+		GrossPay:   b.GrossSalary.Div(periods).Round(b.Currency),
+		Deductions: make([]Line, 0, len(b.Deductions)),
+	}
+	for _, item := range b.Deductions {
+		amount := item.Amount.Div(periods).Round(b.Currency)
+		switch item.Kind {
+		case "income_tax":
+			p.IncomeTax = p.IncomeTax.Add(amount)
+		default:
+			p.Contributions = p.Contributions.Add(amount)
+		}
+		p.Deductions = append(p.Deductions, Line{Kind: item.Kind, Name: item.Name, Rate: item.Rate, Amount: amount})
+	}
+	p.TotalDeductions = p.IncomeTax.Add(p.Contributions)
+	p.NetPay = money.Max(money.Amount{}, p.GrossPay.Sub(p.TotalDeductions))
+	return p
+}
+
+// dateString formats a DATE column
+func dateString(d pgtype.Date) string {
+	return d.Time.Format(helper.DateLayout)
+}
+
+// timePtr is a nullable TIMESTAMP column, nil when NULL
+func timePtr(t pgtype.Timestamp) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
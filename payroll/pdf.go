@@ -0,0 +1,179 @@
+package payroll
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"server/money"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Company is the employer printed on top of the payslips
+type Company struct {
+	Name    string
+	Address string // lines separated by ";" or newlines
+}
+
+// YearToDate sums the payslips of an employee in the tax year up to and including one of them
+type YearToDate struct {
+	Payslips        int
+	GrossPay        money.Amount
+	IncomeTax       money.Amount
+	Contributions   money.Amount
+	TotalDeductions money.Amount
+	NetPay          money.Amount
+}
+
+// YearToDateOf adds up "paid", the locked payslips of the tax year of p, of the same employee
+// and currency paid on or before p. A payslip of a draft or reversed run isn't among them,
+// it counts itself in so its year to date is what it would be once issued.
+func YearToDateOf(p Payslip, paid []Payslip) YearToDate {
+	var ytd YearToDate
+	add := func(q Payslip) {
+		ytd.Payslips++
+		ytd.GrossPay = ytd.GrossPay.Add(q.GrossPay)
+		ytd.IncomeTax = ytd.IncomeTax.Add(q.IncomeTax)
+		ytd.Contributions = ytd.Contributions.Add(q.Contributions)
+		ytd.TotalDeductions = ytd.TotalDeductions.Add(q.TotalDeductions)
+		ytd.NetPay = ytd.NetPay.Add(q.NetPay)
+	}
+
+	counted := false
+	for _, q := range paid {
+		if q.EmployeeID != p.EmployeeID || q.Currency != p.Currency || q.TaxYear != p.TaxYear {
+			continue
+		}
+		// Dates are YYYY-MM-DD, they compare as strings
+		if q.PayDate > p.PayDate || (q.PayDate == p.PayDate && q.ID > p.ID) {
+			continue
+		}
+		counted = counted || q.ID == p.ID
+		add(q)
+	}
+	if !counted {
+		add(p)
+	}
+	return ytd
+}
+
+// Layout of the A4 page, in mm
+const (
+	pdfMargin = 15.0
+	pdfWidth  = 210 - 2*pdfMargin
+	pdfLine   = 6.0
+)
+
+// WritePDF renders the payslip as a one page PDF document with the company on top, the
+// period, the gross, every deduction, the net and the year to date totals
+func (p Payslip) WritePDF(w io.Writer, company Company, ytd YearToDate) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(fmt.Sprintf("Payslip #%d", p.ID), true)
+	pdf.SetAuthor(company.Name, true)
+	pdf.SetCreationDate(p.CreatedAt)
+	pdf.SetCompression(true)
+	// The core fonts are Latin-1, accented names and addresses are translated to it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	// Company
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(pdfWidth/2, 8, tr(company.Name), "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(pdfWidth/2, 8, fmt.Sprintf("PAYSLIP #%d", p.ID), "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range strings.FieldsFunc(company.Address, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			pdf.CellFormat(pdfWidth, 4.5, tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+	switch p.Status {
+	case StatusDraft:
+		pdfNotice(pdf, "DRAFT - not issued yet")
+	case StatusReversed:
+		pdfNotice(pdf, "REVERSED - replaced by a corrected payslip")
+	}
+	pdfRule(pdf)
+
+	// Employee and period
+	details := [][2]string{
+		{"Employee", fmt.Sprintf("#%d", p.EmployeeID)},
+		{"Job title", p.JobTitle},
+		{"Country", p.Country},
+		{"Period", p.PeriodStart + " to " + p.PeriodEnd},
+		{"Pay date", p.PayDate},
+		{"Tax year", fmt.Sprint(p.TaxYear)},
+		{"Currency", p.Currency},
+	}
+	for _, d := range details {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, pdfLine, d[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(pdfWidth-35, pdfLine, tr(d[1]), "", 1, "L", false, 0, "")
+	}
+	if p.SalaryCurrency != p.Currency {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(pdfWidth, pdfLine, fmt.Sprintf("Annual salary %s %s at 1 %s = %s %s",
+			p.AnnualSalary.Format(p.SalaryCurrency), p.SalaryCurrency, p.SalaryCurrency, p.ExchangeRate, p.Currency), "", 1, "L", false, 0, "")
+	}
+	pdfRule(pdf)
+
+	// Earnings and deductions
+	pdfRow(pdf, "B", "Description", "Rate", "Amount")
+	pdfRow(pdf, "", "Gross pay", "", p.GrossPay.Format(p.Currency))
+	for _, line := range p.Deductions {
+		pdfRow(pdf, "", tr(line.Name), line.Rate.String()+" %", pdfDeduction(line.Amount, p.Currency))
+	}
+	pdfRule(pdf)
+	pdfRow(pdf, "", "Income tax", "", pdfDeduction(p.IncomeTax, p.Currency))
+	pdfRow(pdf, "", "Contributions", "", pdfDeduction(p.Contributions, p.Currency))
+	pdfRow(pdf, "", "Total deductions", "", pdfDeduction(p.TotalDeductions, p.Currency))
+	pdfRow(pdf, "B", "Net pay", "", p.NetPay.Format(p.Currency)+" "+p.Currency)
+	pdfRule(pdf)
+
+	// Year to date
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(pdfWidth, 8, fmt.Sprintf("Year to date %d, %d payslip(s)", p.TaxYear, ytd.Payslips), "", 1, "L", false, 0, "")
+	pdfRow(pdf, "", "Gross pay", "", ytd.GrossPay.Format(p.Currency))
+	pdfRow(pdf, "", "Income tax", "", ytd.IncomeTax.Format(p.Currency))
+	pdfRow(pdf, "", "Contributions", "", ytd.Contributions.Format(p.Currency))
+	pdfRow(pdf, "", "Total deductions", "", ytd.TotalDeductions.Format(p.Currency))
+	pdfRow(pdf, "B", "Net pay", "", ytd.NetPay.Format(p.Currency)+" "+p.Currency)
+
+	return pdf.Output(w)
+}
+
+// pdfRow is a line of the amount table, description, rate and a right aligned amount
+func pdfRow(pdf *gofpdf.Fpdf, style, description, rate, amount string) {
+	pdf.SetFont("Helvetica", style, 10)
+	pdf.CellFormat(pdfWidth-75, pdfLine, description, "", 0, "L", false, 0, "")
+	pdf.CellFormat(30, pdfLine, rate, "", 0, "R", false, 0, "")
+	pdf.CellFormat(45, pdfLine, amount, "", 1, "R", false, 0, "")
+}
+
+// pdfDeduction is an amount taken off the gross, with a minus unless it is 0
+func pdfDeduction(a money.Amount, currency string) string {
+	if a.Round(currency).IsZero() {
+		return a.Format(currency)
+	}
+	return "-" + a.Format(currency)
+}
+
+// pdfRule draws a horizontal line across the page
+func pdfRule(pdf *gofpdf.Fpdf) {
+	pdf.Ln(2)
+	y := pdf.GetY()
+	pdf.Line(pdfMargin, y, pdfMargin+pdfWidth, y)
+	pdf.Ln(2)
+}
+
+// pdfNotice is a line in red under the header
+func pdfNotice(pdf *gofpdf.Fpdf, text string) {
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetTextColor(200, 0, 0)
+	pdf.CellFormat(pdfWidth, 8, text, "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
@@ -0,0 +1,256 @@
+package payroll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"server/fx"
+	"server/money"
+	"server/sql/database"
+	"server/tax"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Run is a row of "payroll_runs"
+type Run struct {
+	ID             int64      `json:"id"`
+	Frequency      string     `json:"frequency"`
+	PeriodStart    string     `json:"period_start"`
+	PeriodEnd      string     `json:"period_end"`
+	PayDate        string     `json:"pay_date"`
+	Status         string     `json:"status"`
+	CreatedBy      *int64     `json:"created_by"`
+	LockedBy       *int64     `json:"locked_by,omitempty"`
+	LockedAt       *time.Time `json:"locked_at,omitempty"`
+	ReversedBy     *int64     `json:"reversed_by,omitempty"`
+	ReversedAt     *time.Time `json:"reversed_at,omitempty"`
+	ReversalReason string     `json:"reversal_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PeriodOf is the period a run pays for
+func PeriodOf(run *database.PayrollRun) Period {
+	return Period{Frequency: run.Frequency, Start: run.PeriodStart, End: run.PeriodEnd, PayDate: run.PayDate}
+}
+
+// RunFromDB decodes a "payroll_runs" row
+func RunFromDB(row *database.PayrollRun) Run {
+	return Run{
+		ID:             row.ID,
+		Frequency:      row.Frequency,
+		PeriodStart:    dateString(row.PeriodStart),
+		PeriodEnd:      dateString(row.PeriodEnd),
+		PayDate:        dateString(row.PayDate),
+		Status:         row.Status,
+		CreatedBy:      row.CreatedBy,
+		LockedBy:       row.LockedBy,
+		LockedAt:       timePtr(row.LockedAt),
+		ReversedBy:     row.ReversedBy,
+		ReversedAt:     timePtr(row.ReversedAt),
+		ReversalReason: row.ReversalReason,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+}
+
+// Payslip is a row of "payslips", every amount but the annual salary is in Currency
+type Payslip struct {
+	ID              int64        `json:"id"`
+	RunID           int64        `json:"run_id"`
+	EmployeeID      int32        `json:"employee_id"`
+	UserID          int64        `json:"user_id"`
+	JobTitle        string       `json:"job_title"`
+	Country         string       `json:"country"`
+	PeriodStart     string       `json:"period_start"`
+	PeriodEnd       string       `json:"period_end"`
+	PayDate         string       `json:"pay_date"`
+	TaxRegimeID     int32        `json:"tax_regime_id"`
+	TaxYear         int32        `json:"tax_year"`
+	Currency        string       `json:"currency"`
+	AnnualSalary    money.Amount `json:"annual_salary"`
+	SalaryCurrency  string       `json:"salary_currency"`
+	ExchangeRate    money.Rate   `json:"exchange_rate"` // 1 SalaryCurrency is worth ExchangeRate Currency
+	GrossPay        money.Amount `json:"gross_pay"`
+	Deductions      []Line       `json:"deductions"`
+	IncomeTax       money.Amount `json:"income_tax"`
+	Contributions   money.Amount `json:"contributions"`
+	TotalDeductions money.Amount `json:"total_deductions"`
+	NetPay          money.Amount `json:"net_pay"`
+	Status          string       `json:"status,omitempty"` // of the run, set for the employee
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// PayslipFromDB decodes a "payslips" row
+func PayslipFromDB(row *database.Payslip) (Payslip, error) {
+	p := Payslip{
+		ID:             row.ID,
+		RunID:          row.RunID,
+		EmployeeID:     row.EmployeeID,
+		UserID:         row.UserID,
+		JobTitle:       row.JobTitle,
+		Country:        row.Country,
+		PeriodStart:    dateString(row.PeriodStart),
+		PeriodEnd:      dateString(row.PeriodEnd),
+		PayDate:        dateString(row.PayDate),
+		TaxRegimeID:    row.TaxRegimeID,
+		TaxYear:        row.TaxYear,
+		Currency:       row.Currency,
+		SalaryCurrency: row.SalaryCurrency,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+
+	var err error
+	amounts := []struct {
+		column  string
+		numeric pgtype.Numeric
+		value   *money.Amount
+	}{
+		{"annual_salary", row.AnnualSalary, &p.AnnualSalary},
+		{"gross_pay", row.GrossPay, &p.GrossPay},
+		{"income_tax", row.IncomeTax, &p.IncomeTax},
+		{"contributions", row.Contributions, &p.Contributions},
+		{"total_deductions", row.TotalDeductions, &p.TotalDeductions},
+		{"net_pay", row.NetPay, &p.NetPay},
+	}
+	for _, a := range amounts {
+		if *a.value, err = money.AmountFromNumeric(a.numeric); err != nil {
+			return Payslip{}, fmt.Errorf("payslip %d: %s: %w", row.ID, a.column, err)
+		}
+	}
+	if p.ExchangeRate, err = money.RateFromNumeric(row.ExchangeRate); err != nil {
+		return Payslip{}, fmt.Errorf("payslip %d: exchange_rate: %w", row.ID, err)
+	}
+	if err := json.Unmarshal(row.Deductions, &p.Deductions); err != nil {
+		return Payslip{}, fmt.Errorf("payslip %d: deductions: %w", row.ID, err)
+	}
+	if p.Deductions == nil {
+		p.Deductions = []Line{}
+	}
+	return p, nil
+}
+
+// Total sums the payslips of a run paid in one currency
+type Total struct {
+	Currency        string       `json:"currency"`
+	Payslips        int          `json:"payslips"`
+	GrossPay        money.Amount `json:"gross_pay"`
+	TotalDeductions money.Amount `json:"total_deductions"`
+	NetPay          money.Amount `json:"net_pay"`
+}
+
+// Totals sums the payslips per currency, amounts in different currencies don't add up
+func Totals(payslips []Payslip) []Total {
+	totals := []Total{}
+	for _, p := range payslips {
+		i := slices.IndexFunc(totals, func(t Total) bool { return t.Currency == p.Currency })
+		if i < 0 {
+			totals = append(totals, Total{Currency: p.Currency})
+			i = len(totals) - 1
+		}
+		t := &totals[i]
+		t.Payslips++
+		t.GrossPay = t.GrossPay.Add(p.GrossPay)
+		t.TotalDeductions = t.TotalDeductions.Add(p.TotalDeductions)
+		t.NetPay = t.NetPay.Add(p.NetPay)
+	}
+	slices.SortFunc(totals, func(a, b Total) int { return strings.Compare(a.Currency, b.Currency) })
+	return totals
+}
+
+// Skipped is an employee left out of a run, and why
+type Skipped struct {
+	EmployeeID int32  `json:"employee_id"`
+	Reason     string `json:"reason"`
+}
+
+// Prepare calculates the payslips of a period: every employee with a salary on its last day,
+// taxed with the regime of the pay date's year, the salary converted to the currency of the
+// regime at the exchange rates of the pay date. Employees without a tax regime or an exchange
+// rate are skipped, any other error fails the whole run. The payslips have no RunID yet.
+func Prepare(ctx context.Context, queries database.Querier, period Period) ([]database.CreatePayslipParams, []Skipped, error) {
+	periods, err := PeriodsPerYear(period.Frequency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	employees, err := queries.ListPayrollEmployees(ctx, period.End)
+	if err != nil {
+		return nil, nil, err
+	}
+	converter, err := fx.Load(ctx, queries, period.PayDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// One regime per country, the lookup misses are remembered too
+	regimes := make(map[string]*tax.Regime)
+	regimeErrs := make(map[string]error)
+
+	var payslips []database.CreatePayslipParams
+	var skipped []Skipped
+	for _, emp := range employees {
+		country := tax.NormalizeCountry(emp.Country)
+		regime, ok := regimes[country]
+		if !ok && regimeErrs[country] == nil {
+			regime, err = tax.Load(ctx, queries, country, period.TaxYear())
+			if errors.Is(err, tax.ErrNoRegime) {
+				regimeErrs[country] = fmt.Errorf("no tax rules for %q in %d", emp.Country, period.TaxYear())
+			} else if err != nil {
+				return nil, nil, err
+			} else {
+				regimes[country] = regime
+			}
+		}
+		if err := regimeErrs[country]; err != nil {
+			skipped = append(skipped, Skipped{EmployeeID: emp.ID, Reason: err.Error()})
+			continue
+		}
+
+		salary, err := money.AmountFromNumeric(emp.Salary)
+		if err != nil {
+			return nil, nil, fmt.Errorf("employee %d: salary: %w", emp.ID, err)
+		}
+		// The brackets are in the currency of the regime
+		rate, err := converter.Rate(emp.Currency, regime.Currency)
+		if errors.Is(err, fx.ErrNoRate) {
+			skipped = append(skipped, Skipped{EmployeeID: emp.ID, Reason: err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pay := Split(regime.Calculate(salary.Mul(rate)), periods)
+		deductions, err := json.Marshal(pay.Deductions)
+		if err != nil {
+			return nil, nil, err
+		}
+		payslips = append(payslips, database.CreatePayslipParams{
+			EmployeeID:      emp.ID,
+			UserID:          emp.UserID,
+			JobTitle:        emp.JobTitle,
+			Country:         emp.Country,
+			PeriodStart:     period.Start,
+			PeriodEnd:       period.End,
+			PayDate:         period.PayDate,
+			TaxRegimeID:     regime.ID,
+			TaxYear:         period.TaxYear(),
+			Currency:        regime.Currency,
+			AnnualSalary:    salary.Numeric(),
+			SalaryCurrency:  emp.Currency,
+			ExchangeRate:    rate.Numeric(),
+			GrossPay:        pay.GrossPay.Numeric(),
+			Deductions:      deductions,
+			IncomeTax:       pay.IncomeTax.Numeric(),
+			Contributions:   pay.Contributions.Numeric(),
+			TotalDeductions: pay.TotalDeductions.Numeric(),
+			NetPay:          pay.NetPay.Numeric(),
+		})
+	}
+	return payslips, skipped, nil
+}
@@ -0,0 +1,254 @@
+// Package sheet writes reports as XLSX workbooks of typed cells: amounts, rates and counts
+// are numbers a spreadsheet adds up, dates are dates, each table has a formatted header.
+//
+// Amounts are written with their exact decimal digits, like everywhere else they never go
+// through float64.
+package sheet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"server/money"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ContentType of an XLSX file
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Excel refuses sheet names longer than this or with one of invalidNameChars
+const (
+	maxNameLength    = 31
+	invalidNameChars = `[]:*?/\`
+)
+
+// Column widths, in characters
+const (
+	minWidth = 8
+	maxWidth = 60
+)
+
+// Money is an amount in a currency, shown with the minor units of the currency
+type Money struct {
+	Amount   money.Amount
+	Currency string
+}
+
+// OptionalMoney is an empty cell for a nil amount
+func OptionalMoney(amount *money.Amount, currency string) any {
+	if amount == nil {
+		return nil
+	}
+	return Money{Amount: *amount, Currency: currency}
+}
+
+// Date is a day, "YYYY-MM-DD"
+type Date string
+
+// Workbook is a set of sheets, each a table under a header row. A cell is typed by its value:
+// nil is empty, a string is text, an integer, Money, money.Amount, money.Rate, Date and
+// time.Time are numbers with a format.
+type Workbook struct {
+	file   *excelize.File
+	header int
+	styles map[string]int // by number format
+	names  map[string]bool
+}
+
+// New is a workbook without sheets
+func New() (*Workbook, error) {
+	file := excelize.NewFile()
+	header, err := file.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"305496"}},
+		Alignment: &excelize.Alignment{Vertical: "center"},
+		Border:    []excelize.Border{{Type: "bottom", Color: "1F3864", Style: 2}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Workbook{file: file, header: header, styles: make(map[string]int), names: make(map[string]bool)}, nil
+}
+
+// AddSheet adds the table "rows" under "header" as a sheet named after "name", the header
+// stays in view while scrolling and filters every column
+func (b *Workbook) AddSheet(name string, header []string, rows [][]any) error {
+	name = b.sheetName(name)
+	if len(b.names) == 0 {
+		// A new file comes with an empty "Sheet1"
+		if err := b.file.SetSheetName(b.file.GetSheetName(0), name); err != nil {
+			return err
+		}
+	} else if _, err := b.file.NewSheet(name); err != nil {
+		return err
+	}
+	b.names[name] = true
+
+	widths := make([]int, len(header))
+	for i, title := range header {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := b.file.SetCellStr(name, cell, title); err != nil {
+			return err
+		}
+		widths[i] = utf8.RuneCountInString(title) + 2 // room for the filter button
+	}
+	last, _ := excelize.CoordinatesToCellName(max(len(header), 1), 1)
+	if err := b.file.SetCellStyle(name, "A1", last, b.header); err != nil {
+		return err
+	}
+
+	for r, row := range rows {
+		for c, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(c+1, r+2)
+			text, err := b.setCell(name, cell, value)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", name, cell, err)
+			}
+			if c < len(widths) {
+				widths[c] = max(widths[c], utf8.RuneCountInString(text))
+			}
+		}
+	}
+
+	for i, width := range widths {
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		if err := b.file.SetColWidth(name, column, column, float64(min(max(width+1, minWidth), maxWidth))); err != nil {
+			return err
+		}
+	}
+	err := b.file.SetPanes(name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	if err != nil {
+		return err
+	}
+	bottom, _ := excelize.CoordinatesToCellName(max(len(header), 1), len(rows)+1)
+	return b.file.AutoFilter(name, "A1:"+bottom, nil)
+}
+
+// setCell writes a typed value and returns about what the cell shows, for its column width
+func (b *Workbook) setCell(sheet, cell string, value any) (string, error) {
+	var text, format string
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, b.file.SetCellStr(sheet, cell, v)
+	case int:
+		text = strconv.Itoa(v)
+	case int32:
+		text = strconv.FormatInt(int64(v), 10)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case Money:
+		text, format = v.Amount.String(), moneyFormat(v.Currency)
+		if err := b.setNumber(sheet, cell, text, format); err != nil {
+			return "", err
+		}
+		return v.Amount.Format(v.Currency) + " " + v.Currency, nil
+	case money.Amount:
+		text, format = v.String(), "#,##0.00"
+	case money.Rate:
+		text, format = v.String(), "0.0000######"
+	case Date:
+		day, err := time.Parse(time.DateOnly, string(v))
+		if err != nil {
+			return "", err
+		}
+		return string(v), b.setTime(sheet, cell, day, "yyyy-mm-dd")
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04"), b.setTime(sheet, cell, v.UTC(), "yyyy-mm-dd hh:mm")
+	default:
+		return "", fmt.Errorf("no cell type for %T", value)
+	}
+	return text, b.setNumber(sheet, cell, text, format)
+}
+
+// setNumber writes the decimal digits of "number" as they are
+func (b *Workbook) setNumber(sheet, cell, number, format string) error {
+	if err := b.file.SetCellDefault(sheet, cell, number); err != nil {
+		return err
+	}
+	return b.setFormat(sheet, cell, format)
+}
+
+func (b *Workbook) setTime(sheet, cell string, t time.Time, format string) error {
+	if err := b.file.SetCellValue(sheet, cell, t); err != nil {
+		return err
+	}
+	return b.setFormat(sheet, cell, format)
+}
+
+// setFormat styles the cell with a number format, "" keeps the general one
+func (b *Workbook) setFormat(sheet, cell, format string) error {
+	if format == "" {
+		return nil
+	}
+	style, ok := b.styles[format]
+	if !ok {
+		var err error
+		if style, err = b.file.NewStyle(&excelize.Style{CustomNumFmt: &format}); err != nil {
+			return err
+		}
+		b.styles[format] = style
+	}
+	return b.file.SetCellStyle(sheet, cell, cell, style)
+}
+
+// moneyFormat shows the minor units of the currency, followed by its code
+func moneyFormat(currency string) string {
+	format := "#,##0"
+	if units := money.MinorUnits(currency); units > 0 {
+		format += "." + strings.Repeat("0", int(units))
+	}
+	return format + ` "` + currency + `"`
+}
+
+// sheetName makes "name" a valid sheet name, not yet taken in the workbook
+func (b *Workbook) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidNameChars, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Sheet"
+	}
+
+	unique := truncate(name, maxNameLength)
+	for n := 2; b.taken(unique); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		unique = truncate(name, maxNameLength-len(suffix)) + suffix
+	}
+	return unique
+}
+
+// taken compares like Excel does, regardless of case
+func (b *Workbook) taken(name string) bool {
+	for existing := range b.names {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, length int) string {
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	return string([]rune(s)[:length])
+}
+
+// Write writes the workbook as an XLSX file
+func (b *Workbook) Write(w io.Writer) error {
+	if len(b.names) == 0 {
+		return fmt.Errorf("a workbook needs a sheet")
+	}
+	_, err := b.file.WriteTo(w)
+	return err
+}
@@ -17,10 +17,11 @@ INSERT INTO employees
     user_id,
     job_title,
     country,
-    salary
+    salary,
+    currency
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING id, user_id, job_title, country, salary, created_at
+    $1, $2, $3, $4, $5
+) RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
 `
 
 type CreateEmployeeParams struct {
@@ -28,6 +29,7 @@ type CreateEmployeeParams struct {
 	JobTitle string         `json:"job_title"`
 	Country  string         `json:"country"`
 	Salary   pgtype.Numeric `json:"salary"`
+	Currency string         `json:"currency"`
 }
 
 func (q *Queries) CreateEmployee(ctx context.Context, arg CreateEmployeeParams) (*Employee, error) {
@@ -36,6 +38,7 @@ func (q *Queries) CreateEmployee(ctx context.Context, arg CreateEmployeeParams)
 		arg.JobTitle,
 		arg.Country,
 		arg.Salary,
+		arg.Currency,
 	)
 	var i Employee
 	err := row.Scan(
@@ -45,16 +48,62 @@ func (q *Queries) CreateEmployee(ctx context.Context, arg CreateEmployeeParams)
 		&i.Country,
 		&i.Salary,
 		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return &i, err
+}
+
+const deleteEmployeeById = `-- name: DeleteEmployeeById :one
+UPDATE employees
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    version    = version + 1
+WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
+`
+
+type DeleteEmployeeByIdParams struct {
+	ID      int32 `json:"id"`
+	Version int32 `json:"version"`
+}
+
+// Soft delete, misses the row once it's no longer at "version"
+func (q *Queries) DeleteEmployeeById(ctx context.Context, arg DeleteEmployeeByIdParams) (*Employee, error) {
+	row := q.db.QueryRow(ctx, deleteEmployeeById, arg.ID, arg.Version)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.JobTitle,
+		&i.Country,
+		&i.Salary,
+		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
 const deleteEmployeeByUserId = `-- name: DeleteEmployeeByUserId :one
-DELETE FROM employees WHERE user_id = $1 RETURNING id, user_id, job_title, country, salary, created_at
+UPDATE employees
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    version    = version + 1
+WHERE user_id = $1 AND version = $2 AND deleted_at IS NULL
+RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
 `
 
-func (q *Queries) DeleteEmployeeByUserId(ctx context.Context, userID int64) (*Employee, error) {
-	row := q.db.QueryRow(ctx, deleteEmployeeByUserId, userID)
+type DeleteEmployeeByUserIdParams struct {
+	UserID  int64 `json:"user_id"`
+	Version int32 `json:"version"`
+}
+
+// Soft delete, misses the row once it's no longer at "version"
+func (q *Queries) DeleteEmployeeByUserId(ctx context.Context, arg DeleteEmployeeByUserIdParams) (*Employee, error) {
+	row := q.db.QueryRow(ctx, deleteEmployeeByUserId, arg.UserID, arg.Version)
 	var i Employee
 	err := row.Scan(
 		&i.ID,
@@ -63,32 +112,74 @@ func (q *Queries) DeleteEmployeeByUserId(ctx context.Context, userID int64) (*Em
 		&i.Country,
 		&i.Salary,
 		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
-const getAvgSalaryPerJobTitle = `-- name: GetAvgSalaryPerJobTitle :one
+const getAvgSalaryPerJobTitle = `-- name: GetAvgSalaryPerJobTitle :many
 SELECT 
-    ROUND(AVG(salary), 2)   AS average_salary,
+    currency,
+    SUM(salary)             AS total_salary,
     COUNT(*)                AS employee_count
 FROM employees
-WHERE job_title = $1
+WHERE job_title = $1 AND deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency
 `
 
 type GetAvgSalaryPerJobTitleRow struct {
-	AverageSalary pgtype.Numeric `json:"average_salary"`
+	Currency      string         `json:"currency"`
+	TotalSalary   pgtype.Numeric `json:"total_salary"`
 	EmployeeCount int64          `json:"employee_count"`
 }
 
-func (q *Queries) GetAvgSalaryPerJobTitle(ctx context.Context, jobTitle string) (*GetAvgSalaryPerJobTitleRow, error) {
-	row := q.db.QueryRow(ctx, getAvgSalaryPerJobTitle, jobTitle)
-	var i GetAvgSalaryPerJobTitleRow
-	err := row.Scan(&i.AverageSalary, &i.EmployeeCount)
+// One row per currency, amounts in different currencies only add up once converted
+func (q *Queries) GetAvgSalaryPerJobTitle(ctx context.Context, jobTitle string) ([]*GetAvgSalaryPerJobTitleRow, error) {
+	rows, err := q.db.Query(ctx, getAvgSalaryPerJobTitle, jobTitle)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetAvgSalaryPerJobTitleRow
+	for rows.Next() {
+		var i GetAvgSalaryPerJobTitleRow
+		if err := rows.Scan(&i.Currency, &i.TotalSalary, &i.EmployeeCount); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedEmployeeById = `-- name: GetDeletedEmployeeById :one
+SELECT id, user_id, job_title, country, salary, created_at, currency, version, deleted_at FROM employees WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetDeletedEmployeeById(ctx context.Context, id int32) (*Employee, error) {
+	row := q.db.QueryRow(ctx, getDeletedEmployeeById, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.JobTitle,
+		&i.Country,
+		&i.Salary,
+		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
+	)
 	return &i, err
 }
 
 const getEmployeByuserById = `-- name: GetEmployeByuserById :one
-SELECT id, user_id, job_title, country, salary, created_at FROM employees WHERE user_id = $1
+SELECT id, user_id, job_title, country, salary, created_at, currency, version, deleted_at FROM employees WHERE user_id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetEmployeByuserById(ctx context.Context, userID int64) (*Employee, error) {
@@ -101,62 +192,322 @@ func (q *Queries) GetEmployeByuserById(ctx context.Context, userID int64) (*Empl
 		&i.Country,
 		&i.Salary,
 		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return &i, err
+}
+
+const getEmployeeById = `-- name: GetEmployeeById :one
+SELECT id, user_id, job_title, country, salary, created_at, currency, version, deleted_at FROM employees WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetEmployeeById(ctx context.Context, id int32) (*Employee, error) {
+	row := q.db.QueryRow(ctx, getEmployeeById, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.JobTitle,
+		&i.Country,
+		&i.Salary,
+		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
-const getSalaryMetricsByCountry = `-- name: GetSalaryMetricsByCountry :one
+const getSalaryMetricsByCountry = `-- name: GetSalaryMetricsByCountry :many
 SELECT 
-    ROUND(MIN(salary), 2)   AS min_salary,
-    ROUND(MAX(salary), 2)   AS max_salary,
-    ROUND(AVG(salary), 2)   AS avg_salary,
+    currency,
+    MIN(salary)             AS min_salary,
+    MAX(salary)             AS max_salary,
+    SUM(salary)             AS total_salary,
     COUNT(*)                AS employee_count
 FROM employees
-WHERE country = $1
+WHERE country = $1 AND deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency
 `
 
 type GetSalaryMetricsByCountryRow struct {
+	Currency      string         `json:"currency"`
 	MinSalary     pgtype.Numeric `json:"min_salary"`
 	MaxSalary     pgtype.Numeric `json:"max_salary"`
-	AvgSalary     pgtype.Numeric `json:"avg_salary"`
+	TotalSalary   pgtype.Numeric `json:"total_salary"`
 	EmployeeCount int64          `json:"employee_count"`
 }
 
-func (q *Queries) GetSalaryMetricsByCountry(ctx context.Context, country string) (*GetSalaryMetricsByCountryRow, error) {
-	row := q.db.QueryRow(ctx, getSalaryMetricsByCountry, country)
-	var i GetSalaryMetricsByCountryRow
+// One row per currency, amounts in different currencies only add up once converted
+func (q *Queries) GetSalaryMetricsByCountry(ctx context.Context, country string) ([]*GetSalaryMetricsByCountryRow, error) {
+	rows, err := q.db.Query(ctx, getSalaryMetricsByCountry, country)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetSalaryMetricsByCountryRow
+	for rows.Next() {
+		var i GetSalaryMetricsByCountryRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.MinSalary,
+			&i.MaxSalary,
+			&i.TotalSalary,
+			&i.EmployeeCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmployees = `-- name: ListEmployees :many
+SELECT id, user_id, job_title, country, salary, created_at, currency, version, deleted_at FROM employees
+WHERE
+    (deleted_at IS NOT NULL) = $1::bool
+    AND ($2::text IS NULL OR country = $2)
+    AND ($3::text IS NULL OR job_title = $3)
+    AND ($4::text IS NULL OR currency = $4)
+    AND ($5::numeric IS NULL OR salary >= $5)
+    AND ($6::numeric IS NULL OR salary <= $6)
+    AND (
+        $7::int IS NULL
+        OR CASE WHEN $8::bool THEN
+            CASE $9::text
+                WHEN 'user_id'    THEN (user_id, id)    < ($10::bigint, $7)
+                WHEN 'job_title'  THEN (job_title, id)  < ($11::text, $7)
+                WHEN 'country'    THEN (country, id)    < ($11, $7)
+                WHEN 'salary'     THEN (salary, id)     < ($12::numeric, $7)
+                WHEN 'created_at' THEN (created_at, id) < ($13::timestamp, $7)
+                ELSE id < $7
+            END
+        ELSE
+            CASE $9::text
+                WHEN 'user_id'    THEN (user_id, id)    > ($10, $7)
+                WHEN 'job_title'  THEN (job_title, id)  > ($11, $7)
+                WHEN 'country'    THEN (country, id)    > ($11, $7)
+                WHEN 'salary'     THEN (salary, id)     > ($12, $7)
+                WHEN 'created_at' THEN (created_at, id) > ($13, $7)
+                ELSE id > $7
+            END
+        END
+    )
+ORDER BY
+    CASE WHEN $9::text = 'user_id'    AND NOT $8::bool THEN user_id    END ASC,
+    CASE WHEN $9::text = 'user_id'    AND $8::bool     THEN user_id    END DESC,
+    CASE WHEN $9::text = 'job_title'  AND NOT $8::bool THEN job_title  END ASC,
+    CASE WHEN $9::text = 'job_title'  AND $8::bool     THEN job_title  END DESC,
+    CASE WHEN $9::text = 'country'    AND NOT $8::bool THEN country    END ASC,
+    CASE WHEN $9::text = 'country'    AND $8::bool     THEN country    END DESC,
+    CASE WHEN $9::text = 'salary'     AND NOT $8::bool THEN salary     END ASC,
+    CASE WHEN $9::text = 'salary'     AND $8::bool     THEN salary     END DESC,
+    CASE WHEN $9::text = 'created_at' AND NOT $8::bool THEN created_at END ASC,
+    CASE WHEN $9::text = 'created_at' AND $8::bool     THEN created_at END DESC,
+    CASE WHEN NOT $8::bool THEN id END ASC,
+    CASE WHEN $8::bool     THEN id END DESC
+LIMIT $14::int
+`
+
+type ListEmployeesParams struct {
+	Deleted      bool             `json:"deleted"`
+	Country      *string          `json:"country"`
+	JobTitle     *string          `json:"job_title"`
+	Currency     *string          `json:"currency"`
+	MinSalary    pgtype.Numeric   `json:"min_salary"`
+	MaxSalary    pgtype.Numeric   `json:"max_salary"`
+	CursorID     *int32           `json:"cursor_id"`
+	SortDesc     bool             `json:"sort_desc"`
+	SortBy       string           `json:"sort_by"`
+	CursorInt    *int64           `json:"cursor_int"`
+	CursorText   *string          `json:"cursor_text"`
+	CursorSalary pgtype.Numeric   `json:"cursor_salary"`
+	CursorTime   pgtype.Timestamp `json:"cursor_time"`
+	PageSize     int32            `json:"page_size"`
+}
+
+// The live employees, or the deleted ones with "deleted"
+func (q *Queries) ListEmployees(ctx context.Context, arg ListEmployeesParams) ([]*Employee, error) {
+	rows, err := q.db.Query(ctx, listEmployees,
+		arg.Deleted,
+		arg.Country,
+		arg.JobTitle,
+		arg.Currency,
+		arg.MinSalary,
+		arg.MaxSalary,
+		arg.CursorID,
+		arg.SortDesc,
+		arg.SortBy,
+		arg.CursorInt,
+		arg.CursorText,
+		arg.CursorSalary,
+		arg.CursorTime,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Employee
+	for rows.Next() {
+		var i Employee
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.JobTitle,
+			&i.Country,
+			&i.Salary,
+			&i.CreatedAt,
+			&i.Currency,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedEmployees = `-- name: PurgeDeletedEmployees :many
+DELETE FROM employees e
+WHERE e.deleted_at < $1::timestamp
+    AND NOT (
+        $2::bool
+        AND EXISTS (SELECT 1 FROM users u WHERE u.id = e.user_id AND u.deleted_at IS NOT NULL)
+    )
+RETURNING e.id, e.user_id, e.job_title, e.country, e.salary, e.created_at, e.currency, e.version, e.deleted_at
+`
+
+type PurgeDeletedEmployeesParams struct {
+	DeletedBefore      pgtype.Timestamp `json:"deleted_before"`
+	KeepOfDeletedUsers bool             `json:"keep_of_deleted_users"`
+}
+
+// Deleted before "deleted_before", their salary history goes with them. With "keep_of_deleted_users"
+// the employees of a deleted user stay, anonymized along with it.
+func (q *Queries) PurgeDeletedEmployees(ctx context.Context, arg PurgeDeletedEmployeesParams) ([]*Employee, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedEmployees, arg.DeletedBefore, arg.KeepOfDeletedUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Employee
+	for rows.Next() {
+		var i Employee
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.JobTitle,
+			&i.Country,
+			&i.Salary,
+			&i.CreatedAt,
+			&i.Currency,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreEmployeeById = `-- name: RestoreEmployeeById :one
+UPDATE employees
+SET
+    deleted_at = NULL,
+    version    = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
+`
+
+// Fails on "employees_user_id_key" once the user has another live employee
+func (q *Queries) RestoreEmployeeById(ctx context.Context, id int32) (*Employee, error) {
+	row := q.db.QueryRow(ctx, restoreEmployeeById, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.JobTitle,
+		&i.Country,
+		&i.Salary,
+		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return &i, err
+}
+
+const restoreEmployeeByUserId = `-- name: RestoreEmployeeByUserId :one
+UPDATE employees
+SET
+    deleted_at = NULL,
+    version    = version + 1
+WHERE user_id = $1 AND deleted_at >= $2::timestamp
+RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
+`
+
+type RestoreEmployeeByUserIdParams struct {
+	UserID       int64            `json:"user_id"`
+	DeletedSince pgtype.Timestamp `json:"deleted_since"`
+}
+
+// The employee deleted along with its user, at the same time or later
+func (q *Queries) RestoreEmployeeByUserId(ctx context.Context, arg RestoreEmployeeByUserIdParams) (*Employee, error) {
+	row := q.db.QueryRow(ctx, restoreEmployeeByUserId, arg.UserID, arg.DeletedSince)
+	var i Employee
 	err := row.Scan(
-		&i.MinSalary,
-		&i.MaxSalary,
-		&i.AvgSalary,
-		&i.EmployeeCount,
+		&i.ID,
+		&i.UserID,
+		&i.JobTitle,
+		&i.Country,
+		&i.Salary,
+		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
-const updateEmployeeByUserId = `-- name: UpdateEmployeeByUserId :one
+const updateEmployeeById = `-- name: UpdateEmployeeById :one
 UPDATE employees
 SET 
     job_title  = $2,
     country    = $3,
-    salary     = $4
-WHERE user_id = $1
-RETURNING id, user_id, job_title, country, salary, created_at
+    version    = version + 1
+WHERE id = $1 AND version = $4 AND deleted_at IS NULL
+RETURNING id, user_id, job_title, country, salary, created_at, currency, version, deleted_at
 `
 
-type UpdateEmployeeByUserIdParams struct {
-	UserID   int64          `json:"user_id"`
-	JobTitle string         `json:"job_title"`
-	Country  string         `json:"country"`
-	Salary   pgtype.Numeric `json:"salary"`
+type UpdateEmployeeByIdParams struct {
+	ID       int32  `json:"id"`
+	JobTitle string `json:"job_title"`
+	Country  string `json:"country"`
+	Version  int32  `json:"version"`
 }
 
-func (q *Queries) UpdateEmployeeByUserId(ctx context.Context, arg UpdateEmployeeByUserIdParams) (*Employee, error) {
-	row := q.db.QueryRow(ctx, updateEmployeeByUserId,
-		arg.UserID,
+// Misses the row once it's no longer at "version", the one read before the change
+func (q *Queries) UpdateEmployeeById(ctx context.Context, arg UpdateEmployeeByIdParams) (*Employee, error) {
+	row := q.db.QueryRow(ctx, updateEmployeeById,
+		arg.ID,
 		arg.JobTitle,
 		arg.Country,
-		arg.Salary,
+		arg.Version,
 	)
 	var i Employee
 	err := row.Scan(
@@ -166,6 +517,9 @@ func (q *Queries) UpdateEmployeeByUserId(ctx context.Context, arg UpdateEmployee
 		&i.Country,
 		&i.Salary,
 		&i.CreatedAt,
+		&i.Currency,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -8,10 +8,32 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Adminuser struct {
-	ID        int32            `json:"id"`
-	UserID    int64            `json:"user_id"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
+type AuditLog struct {
+	ID         int64            `json:"id"`
+	ActorID    *int64           `json:"actor_id"`
+	Action     string           `json:"action"`
+	TargetType string           `json:"target_type"`
+	TargetID   string           `json:"target_id"`
+	Diff       []byte           `json:"diff"`
+	RequestID  string           `json:"request_id"`
+	Ip         string           `json:"ip"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	PrevHash   string           `json:"prev_hash"`
+	Hash       string           `json:"hash"`
+}
+
+type BankAccount struct {
+	ID            int64            `json:"id"`
+	UserID        int64            `json:"user_id"`
+	Scheme        string           `json:"scheme"`
+	HolderName    string           `json:"holder_name"`
+	Iban          string           `json:"iban"`
+	Bic           string           `json:"bic"`
+	RoutingNumber string           `json:"routing_number"`
+	AccountNumber string           `json:"account_number"`
+	AccountType   string           `json:"account_type"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
 }
 
 type Employee struct {
@@ -21,6 +43,130 @@ type Employee struct {
 	Country   string           `json:"country"`
 	Salary    pgtype.Numeric   `json:"salary"`
 	CreatedAt pgtype.Timestamp `json:"created_at"`
+	Currency  string           `json:"currency"`
+	Version   int32            `json:"version"`
+	DeletedAt pgtype.Timestamp `json:"deleted_at"`
+}
+
+type FxRate struct {
+	ID            int64            `json:"id"`
+	BaseCurrency  string           `json:"base_currency"`
+	QuoteCurrency string           `json:"quote_currency"`
+	Rate          pgtype.Numeric   `json:"rate"`
+	RateDate      pgtype.Date      `json:"rate_date"`
+	Source        string           `json:"source"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type PayrollRun struct {
+	ID             int64            `json:"id"`
+	Frequency      string           `json:"frequency"`
+	PeriodStart    pgtype.Date      `json:"period_start"`
+	PeriodEnd      pgtype.Date      `json:"period_end"`
+	PayDate        pgtype.Date      `json:"pay_date"`
+	Status         string           `json:"status"`
+	CreatedBy      *int64           `json:"created_by"`
+	LockedBy       *int64           `json:"locked_by"`
+	LockedAt       pgtype.Timestamp `json:"locked_at"`
+	ReversedBy     *int64           `json:"reversed_by"`
+	ReversedAt     pgtype.Timestamp `json:"reversed_at"`
+	ReversalReason string           `json:"reversal_reason"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+type Payslip struct {
+	ID              int64            `json:"id"`
+	RunID           int64            `json:"run_id"`
+	EmployeeID      int32            `json:"employee_id"`
+	UserID          int64            `json:"user_id"`
+	JobTitle        string           `json:"job_title"`
+	Country         string           `json:"country"`
+	PeriodStart     pgtype.Date      `json:"period_start"`
+	PeriodEnd       pgtype.Date      `json:"period_end"`
+	PayDate         pgtype.Date      `json:"pay_date"`
+	TaxRegimeID     int32            `json:"tax_regime_id"`
+	TaxYear         int32            `json:"tax_year"`
+	Currency        string           `json:"currency"`
+	AnnualSalary    pgtype.Numeric   `json:"annual_salary"`
+	SalaryCurrency  string           `json:"salary_currency"`
+	ExchangeRate    pgtype.Numeric   `json:"exchange_rate"`
+	GrossPay        pgtype.Numeric   `json:"gross_pay"`
+	Deductions      []byte           `json:"deductions"`
+	IncomeTax       pgtype.Numeric   `json:"income_tax"`
+	Contributions   pgtype.Numeric   `json:"contributions"`
+	TotalDeductions pgtype.Numeric   `json:"total_deductions"`
+	NetPay          pgtype.Numeric   `json:"net_pay"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type Permission struct {
+	ID          int32            `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        int32            `json:"id"`
+	UserID    int64            `json:"user_id"`
+	FamilyID  string           `json:"family_id"`
+	TokenHash string           `json:"token_hash"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	RevokedAt pgtype.Timestamp `json:"revoked_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       string           `json:"jti"`
+	UserID    int64            `json:"user_id"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type Role struct {
+	ID          int32            `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID       int32 `json:"role_id"`
+	PermissionID int32 `json:"permission_id"`
+}
+
+type SalaryHistory struct {
+	ID            int64            `json:"id"`
+	EmployeeID    int32            `json:"employee_id"`
+	Salary        pgtype.Numeric   `json:"salary"`
+	EffectiveFrom pgtype.Date      `json:"effective_from"`
+	Reason        string           `json:"reason"`
+	ApprovedBy    *int64           `json:"approved_by"`
+	Note          string           `json:"note"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	Currency      string           `json:"currency"`
+}
+
+type SigningKey struct {
+	Kid        string           `json:"kid"`
+	Algorithm  string           `json:"algorithm"`
+	PrivateKey string           `json:"private_key"`
+	PublicKey  string           `json:"public_key"`
+	RetiresAt  pgtype.Timestamp `json:"retires_at"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type TaxRegime struct {
+	ID                int32            `json:"id"`
+	Country           string           `json:"country"`
+	TaxYear           int32            `json:"tax_year"`
+	StandardAllowance pgtype.Numeric   `json:"standard_allowance"`
+	Brackets          []byte           `json:"brackets"`
+	Contributions     []byte           `json:"contributions"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
+	Currency          string           `json:"currency"`
 }
 
 type User struct {
@@ -29,4 +175,19 @@ type User struct {
 	Email        string           `json:"email"`
 	PasswordHash string           `json:"password_hash"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	DeletedAt    pgtype.Timestamp `json:"deleted_at"`
+	AnonymizedAt pgtype.Timestamp `json:"anonymized_at"`
+}
+
+type UserRole struct {
+	UserID    int64            `json:"user_id"`
+	RoleID    int32            `json:"role_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type UserTokenCutoff struct {
+	UserID    int64            `json:"user_id"`
+	NotBefore pgtype.Timestamp `json:"not_before"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
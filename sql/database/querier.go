@@ -6,21 +6,121 @@ package database
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
-	CreateAdminUser(ctx context.Context, userID int64) (*Adminuser, error)
+	// Deleted before "deleted_before" and not anonymized yet: nothing of the row tells who it was,
+	// and nobody can log in as it
+	AnonymizeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*User, error)
+	ApplyEffectiveSalaries(ctx context.Context, arg ApplyEffectiveSalariesParams) ([]*Employee, error)
+	AssignUserRole(ctx context.Context, arg AssignUserRoleParams) (int64, error)
+	// Deleted users don't count
+	CountUsersWithRole(ctx context.Context, name string) (int64, error)
+	CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (*AuditLog, error)
 	CreateEmployee(ctx context.Context, arg CreateEmployeeParams) (*Employee, error)
+	CreatePayrollRun(ctx context.Context, arg CreatePayrollRunParams) (*PayrollRun, error)
+	CreatePayslip(ctx context.Context, arg CreatePayslipParams) (*Payslip, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (*RefreshToken, error)
+	CreateSalaryChange(ctx context.Context, arg CreateSalaryChangeParams) (*SalaryHistory, error)
+	CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) (*SigningKey, error)
+	CreateTaxRegime(ctx context.Context, arg CreateTaxRegimeParams) (*TaxRegime, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (*User, error)
-	DeleteAdminUser(ctx context.Context, userID int64) (*Adminuser, error)
-	DeleteEmployeeByUserId(ctx context.Context, userID int64) (*Employee, error)
-	GetAdminUser(ctx context.Context, userID int64) (*Adminuser, error)
-	GetAvgSalaryPerJobTitle(ctx context.Context, jobTitle string) (*GetAvgSalaryPerJobTitleRow, error)
+	// Soft delete, misses the row once it's no longer at "version"
+	DeleteEmployeeById(ctx context.Context, arg DeleteEmployeeByIdParams) (*Employee, error)
+	// Soft delete, misses the row once it's no longer at "version"
+	DeleteEmployeeByUserId(ctx context.Context, arg DeleteEmployeeByUserIdParams) (*Employee, error)
+	DeleteExpiredRefreshTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error)
+	DeleteExpiredSigningKeys(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error)
+	DeleteExpiredUserTokenCutoffs(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error)
+	DeleteFxRate(ctx context.Context, id int64) (*FxRate, error)
+	// Only drafts, their payslips go with them
+	DeletePayrollRun(ctx context.Context, id int64) (*PayrollRun, error)
+	// Clears a draft before it is calculated again
+	DeleteRunPayslips(ctx context.Context, runID int64) error
+	DeleteScheduledSalaryChange(ctx context.Context, arg DeleteScheduledSalaryChangeParams) (*SalaryHistory, error)
+	DeleteTaxRegime(ctx context.Context, id int32) (*TaxRegime, error)
+	DeleteUserBankAccount(ctx context.Context, userID int64) (*BankAccount, error)
+	// Soft delete, the email stays taken until the retention job
+	DeleteUserById(ctx context.Context, id int32) (*User, error)
+	// One row per currency, amounts in different currencies only add up once converted
+	GetAvgSalaryPerJobTitle(ctx context.Context, jobTitle string) ([]*GetAvgSalaryPerJobTitleRow, error)
+	GetDeletedEmployeeById(ctx context.Context, id int32) (*Employee, error)
+	GetDeletedUserById(ctx context.Context, id int32) (*User, error)
 	GetEmployeByuserById(ctx context.Context, userID int64) (*Employee, error)
-	GetSalaryMetricsByCountry(ctx context.Context, country string) (*GetSalaryMetricsByCountryRow, error)
+	GetEmployeeById(ctx context.Context, id int32) (*Employee, error)
+	// The latest rate of every currency pair not after "as_of"
+	GetFxRatesAsOf(ctx context.Context, asOf pgtype.Date) ([]*FxRate, error)
+	GetLatestAuditEntry(ctx context.Context) (*AuditLog, error)
+	GetPayrollRun(ctx context.Context, id int64) (*PayrollRun, error)
+	// Locks the run until the end of the transaction, while its payslips are replaced
+	GetPayrollRunForUpdate(ctx context.Context, id int64) (*PayrollRun, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	GetRoleByName(ctx context.Context, name string) (*Role, error)
+	// One row per currency, amounts in different currencies only add up once converted
+	GetSalaryMetricsByCountry(ctx context.Context, country string) ([]*GetSalaryMetricsByCountryRow, error)
+	GetTaxRegimeById(ctx context.Context, id int32) (*TaxRegime, error)
+	// The regime in force in "tax_year", the latest one not after it
+	GetTaxRegimeForYear(ctx context.Context, arg GetTaxRegimeForYearParams) (*TaxRegime, error)
+	GetUserBankAccount(ctx context.Context, userID int64) (*BankAccount, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserById(ctx context.Context, id int32) (*User, error)
 	GetUserByName(ctx context.Context, username string) (*User, error)
-	UpdateEmployeeByUserId(ctx context.Context, arg UpdateEmployeeByUserIdParams) (*Employee, error)
+	GetUserPayslip(ctx context.Context, arg GetUserPayslipParams) (*Payslip, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]*AuditLog, error)
+	ListAuditEntriesAfter(ctx context.Context, arg ListAuditEntriesAfterParams) ([]*AuditLog, error)
+	// The live employees, or the deleted ones with "deleted"
+	ListEmployees(ctx context.Context, arg ListEmployeesParams) ([]*Employee, error)
+	ListFxRates(ctx context.Context, arg ListFxRatesParams) ([]*FxRate, error)
+	// Payslips of the locked runs of "tax_year", what year to date totals add up, only those
+	// of "user_id" or of "employee_ids" when set
+	ListLockedPayslipsForYear(ctx context.Context, arg ListLockedPayslipsForYearParams) ([]*Payslip, error)
+	// Every employee with a salary in effect on "as_of", with that salary
+	ListPayrollEmployees(ctx context.Context, asOf pgtype.Date) ([]*ListPayrollEmployeesRow, error)
+	ListPayrollRuns(ctx context.Context, arg ListPayrollRunsParams) ([]*PayrollRun, error)
+	ListRolePermissions(ctx context.Context, roleID int32) ([]string, error)
+	ListRoles(ctx context.Context) ([]*Role, error)
+	// The accounts of the employees paid by a run
+	ListRunBankAccounts(ctx context.Context, runID int64) ([]*BankAccount, error)
+	ListRunPayslips(ctx context.Context, runID int64) ([]*Payslip, error)
+	ListSalaryHistory(ctx context.Context, employeeID int32) ([]*SalaryHistory, error)
+	ListSigningKeys(ctx context.Context, expiresAt pgtype.Timestamp) ([]*SigningKey, error)
+	ListTaxRegimes(ctx context.Context, country *string) ([]*TaxRegime, error)
+	// Payslips of locked and reversed runs, drafts are not issued yet
+	ListUserPayslips(ctx context.Context, userID int64) ([]*Payslip, error)
+	ListUserPermissions(ctx context.Context, userID int64) ([]string, error)
+	ListUserRoles(ctx context.Context, userID int64) ([]string, error)
+	ListUsersByIDs(ctx context.Context, ids []int32) ([]*User, error)
+	LockPayrollRun(ctx context.Context, arg LockPayrollRunParams) (*PayrollRun, error)
+	// Deleted before "deleted_before", their salary history goes with them. With "keep_of_deleted_users"
+	// the employees of a deleted user stay, anonymized along with it.
+	PurgeDeletedEmployees(ctx context.Context, arg PurgeDeletedEmployeesParams) ([]*Employee, error)
+	// Deleted before "deleted_before", along with everything that is theirs: employee, bank account,
+	// roles and tokens. Payslips are snapshots and stay.
+	PurgeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*User, error)
+	RemoveUserRole(ctx context.Context, arg RemoveUserRoleParams) (int64, error)
+	// Fails on "employees_user_id_key" once the user has another live employee
+	RestoreEmployeeById(ctx context.Context, id int32) (*Employee, error)
+	// The employee deleted along with its user, at the same time or later
+	RestoreEmployeeByUserId(ctx context.Context, arg RestoreEmployeeByUserIdParams) (*Employee, error)
+	// An anonymized user has nothing left to restore
+	RestoreUserById(ctx context.Context, id int32) (*User, error)
+	ReversePayrollRun(ctx context.Context, arg ReversePayrollRunParams) (*PayrollRun, error)
+	RevokeRefreshToken(ctx context.Context, id int32) (*RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error
+	// Misses the row once it's no longer at "version", the one read before the change
+	UpdateEmployeeById(ctx context.Context, arg UpdateEmployeeByIdParams) (*Employee, error)
+	UpdateTaxRegime(ctx context.Context, arg UpdateTaxRegimeParams) (*TaxRegime, error)
+	// One account per user, registering another replaces it
+	UpsertBankAccount(ctx context.Context, arg UpsertBankAccountParams) (*BankAccount, error)
+	// Imports are idempotent, the same pair and day again replaces the rate
+	UpsertFxRate(ctx context.Context, arg UpsertFxRateParams) (*FxRate, error)
 }
 
 var _ Querier = (*Queries)(nil)
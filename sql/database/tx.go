@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Transactor is a Querier able to run several queries as one transaction
+type Transactor interface {
+	Querier
+	// InTx runs fn on a Querier bound to a single transaction, committed when fn returns nil
+	// and rolled back when it returns an error
+	InTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// TxQueries are the Queries of a pool, able to open transactions on it
+type TxQueries struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+var _ Transactor = (*TxQueries)(nil)
+
+func NewTxQueries(pool *pgxpool.Pool) *TxQueries {
+	return &TxQueries{Queries: New(pool), pool: pool}
+}
+
+func (q *TxQueries) InTx(ctx context.Context, fn func(Querier) error) error {
+	return pgx.BeginFunc(ctx, q.pool, func(tx pgx.Tx) error {
+		return fn(q.WithTx(tx))
+	})
+}
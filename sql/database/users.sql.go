@@ -11,6 +11,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeDeletedUsers = `-- name: AnonymizeDeletedUsers :many
+UPDATE users
+SET
+    username      = 'deleted user',
+    email         = 'deleted-' || id || '@anonymized.invalid',
+    password_hash = '',
+    anonymized_at = CURRENT_TIMESTAMP
+WHERE deleted_at < $1::timestamp AND anonymized_at IS NULL
+RETURNING id, username, email, password_hash, created_at, deleted_at, anonymized_at
+`
+
+// Deleted before "deleted_before" and not anonymized yet: nothing of the row tells who it was,
+// and nobody can log in as it
+func (q *Queries) AnonymizeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*User, error) {
+	rows, err := q.db.Query(ctx, anonymizeDeletedUsers, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users
 (
@@ -20,7 +61,7 @@ INSERT INTO users
     created_at
 ) VALUES (
     $1, $2, $3, $4
-) RETURNING id, username, email, password_hash, created_at
+) RETURNING id, username, email, password_hash, created_at, deleted_at, anonymized_at
 `
 
 type CreateUserParams struct {
@@ -44,12 +85,74 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (*User,
 		&i.Email,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+	)
+	return &i, err
+}
+
+const deleteUserById = `-- name: DeleteUserById :one
+UPDATE users SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, username, email, password_hash, created_at, deleted_at, anonymized_at
+`
+
+// Soft delete, the email stays taken until the retention job
+func (q *Queries) DeleteUserById(ctx context.Context, id int32) (*User, error) {
+	row := q.db.QueryRow(ctx, deleteUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+	)
+	return &i, err
+}
+
+const getDeletedUserById = `-- name: GetDeletedUserById :one
+SELECT id, username, email, password_hash, created_at, deleted_at, anonymized_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL LIMIT 1
+`
+
+func (q *Queries) GetDeletedUserById(ctx context.Context, id int32) (*User, error) {
+	row := q.db.QueryRow(ctx, getDeletedUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+	)
+	return &i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, email, password_hash, created_at, deleted_at, anonymized_at FROM users WHERE email = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
 	)
 	return &i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, email, password_hash, created_at FROM users WHERE id = $1 LIMIT 1
+SELECT id, username, email, password_hash, created_at, deleted_at, anonymized_at FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id int32) (*User, error) {
@@ -61,12 +164,14 @@ func (q *Queries) GetUserById(ctx context.Context, id int32) (*User, error) {
 		&i.Email,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
 	)
 	return &i, err
 }
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, username, email, password_hash, created_at FROM users WHERE username = $1 LIMIT 1
+SELECT id, username, email, password_hash, created_at, deleted_at, anonymized_at FROM users WHERE username = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUserByName(ctx context.Context, username string) (*User, error) {
@@ -78,6 +183,96 @@ func (q *Queries) GetUserByName(ctx context.Context, username string) (*User, er
 		&i.Email,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+	)
+	return &i, err
+}
+
+const listUsersByIDs = `-- name: ListUsersByIDs :many
+SELECT id, username, email, password_hash, created_at, deleted_at, anonymized_at FROM users WHERE id = ANY($1::int[]) AND deleted_at IS NULL ORDER BY id
+`
+
+func (q *Queries) ListUsersByIDs(ctx context.Context, ids []int32) ([]*User, error) {
+	rows, err := q.db.Query(ctx, listUsersByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :many
+DELETE FROM users WHERE deleted_at < $1::timestamp RETURNING id, username, email, password_hash, created_at, deleted_at, anonymized_at
+`
+
+// Deleted before "deleted_before", along with everything that is theirs: employee, bank account,
+// roles and tokens. Payslips are snapshots and stay.
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*User, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedUsers, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreUserById = `-- name: RestoreUserById :one
+UPDATE users SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL AND anonymized_at IS NULL
+RETURNING id, username, email, password_hash, created_at, deleted_at, anonymized_at
+`
+
+// An anonymized user has nothing left to restore
+func (q *Queries) RestoreUserById(ctx context.Context, id int32) (*User, error) {
+	row := q.db.QueryRow(ctx, restoreUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
 	)
 	return &i, err
 }
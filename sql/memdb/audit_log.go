@@ -0,0 +1,102 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"server/sql/database"
+)
+
+// The audit log is append-only, there is no way to update or delete an entry
+
+func copyAuditEntry(e *database.AuditLog) *database.AuditLog {
+	c := *e
+	c.Diff = append([]byte(nil), e.Diff...)
+	if e.ActorID != nil {
+		actorID := *e.ActorID
+		c.ActorID = &actorID
+	}
+	return &c
+}
+
+func (s *Store) CreateAuditEntry(ctx context.Context, arg database.CreateAuditEntryParams) (*database.AuditLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.auditLog {
+		if e.PrevHash == arg.PrevHash {
+			return fail[database.AuditLog](uniqueErr("audit_log", "audit_log_prev_hash_key"))
+		}
+		if e.Hash == arg.Hash {
+			return fail[database.AuditLog](uniqueErr("audit_log", "audit_log_hash_key"))
+		}
+	}
+
+	entry := &database.AuditLog{
+		ID:         int64(len(s.auditLog) + 1),
+		ActorID:    arg.ActorID,
+		Action:     arg.Action,
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Diff:       arg.Diff,
+		RequestID:  arg.RequestID,
+		Ip:         arg.Ip,
+		CreatedAt:  arg.CreatedAt,
+		PrevHash:   arg.PrevHash,
+		Hash:       arg.Hash,
+	}
+	entry = copyAuditEntry(entry)
+	s.auditLog = append(s.auditLog, entry)
+
+	return copyAuditEntry(entry), nil
+}
+
+func (s *Store) GetLatestAuditEntry(ctx context.Context) (*database.AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.auditLog) == 0 {
+		return noRows[database.AuditLog]()
+	}
+	return copyAuditEntry(s.auditLog[len(s.auditLog)-1]), nil
+}
+
+func (s *Store) ListAuditEntries(ctx context.Context, arg database.ListAuditEntriesParams) ([]*database.AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.AuditLog
+	// Newest first, "ORDER BY id DESC"
+	for i := len(s.auditLog) - 1; i >= 0 && len(items) < int(arg.PageSize); i-- {
+		e := s.auditLog[i]
+		switch {
+		case arg.ActorID != nil && (e.ActorID == nil || *e.ActorID != *arg.ActorID):
+		case arg.Action != nil && e.Action != *arg.Action:
+		case arg.TargetType != nil && e.TargetType != *arg.TargetType:
+		case arg.TargetID != nil && e.TargetID != *arg.TargetID:
+		case arg.Since.Valid && e.CreatedAt.Time.Before(arg.Since.Time):
+		case arg.Until.Valid && !e.CreatedAt.Time.Before(arg.Until.Time):
+		case arg.CursorID != nil && e.ID >= *arg.CursorID:
+		default:
+			items = append(items, copyAuditEntry(e))
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) ListAuditEntriesAfter(ctx context.Context, arg database.ListAuditEntriesAfterParams) ([]*database.AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// IDs are 1..n in insertion order
+	start := sort.Search(len(s.auditLog), func(i int) bool { return s.auditLog[i].ID > arg.ID })
+
+	var items []*database.AuditLog
+	for _, e := range s.auditLog[start:] {
+		if len(items) >= int(arg.Limit) {
+			break
+		}
+		items = append(items, copyAuditEntry(e))
+	}
+	return items, nil
+}
@@ -0,0 +1,102 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"regexp"
+	"slices"
+
+	"server/sql/database"
+)
+
+func copyBankAccount(a *database.BankAccount) *database.BankAccount {
+	c := *a
+	return &c
+}
+
+var (
+	bankIBAN          = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bankRoutingNumber = regexp.MustCompile(`^[0-9]{9}$`)
+	bankAccountNumber = regexp.MustCompile(`^[0-9]{4,17}$`)
+)
+
+// bankAccountSchemeOK is "chk_bank_accounts_scheme"
+func bankAccountSchemeOK(arg database.UpsertBankAccountParams) bool {
+	switch arg.Scheme {
+	case "sepa":
+		return bankIBAN.MatchString(arg.Iban) &&
+			arg.RoutingNumber == "" && arg.AccountNumber == "" && arg.AccountType == ""
+	case "ach":
+		return arg.Iban == "" && arg.Bic == "" &&
+			bankRoutingNumber.MatchString(arg.RoutingNumber) && bankAccountNumber.MatchString(arg.AccountNumber) &&
+			(arg.AccountType == "checking" || arg.AccountType == "savings")
+	}
+	return false
+}
+
+func (s *Store) UpsertBankAccount(ctx context.Context, arg database.UpsertBankAccountParams) (*database.BankAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !bankAccountSchemeOK(arg) {
+		return fail[database.BankAccount](checkErr("bank_accounts", "chk_bank_accounts_scheme"))
+	}
+	if !s.userExists(arg.UserID) {
+		return fail[database.BankAccount](foreignKeyErr("bank_accounts", "fk_bank_accounts_user"))
+	}
+
+	now := s.currentTimestamp()
+	account, ok := s.bankAccounts[arg.UserID]
+	if !ok {
+		s.bankAccountSeq++
+		account = &database.BankAccount{ID: s.bankAccountSeq, UserID: arg.UserID, CreatedAt: now}
+		s.bankAccounts[arg.UserID] = account
+	}
+	account.Scheme = arg.Scheme
+	account.HolderName = arg.HolderName
+	account.Iban = arg.Iban
+	account.Bic = arg.Bic
+	account.RoutingNumber = arg.RoutingNumber
+	account.AccountNumber = arg.AccountNumber
+	account.AccountType = arg.AccountType
+	account.UpdatedAt = now
+
+	return copyBankAccount(account), nil
+}
+
+func (s *Store) GetUserBankAccount(ctx context.Context, userID int64) (*database.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.bankAccounts[userID]
+	if !ok {
+		return noRows[database.BankAccount]()
+	}
+	return copyBankAccount(account), nil
+}
+
+func (s *Store) DeleteUserBankAccount(ctx context.Context, userID int64) (*database.BankAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.bankAccounts[userID]
+	if !ok {
+		return noRows[database.BankAccount]()
+	}
+	delete(s.bankAccounts, userID)
+	return copyBankAccount(account), nil
+}
+
+func (s *Store) ListRunBankAccounts(ctx context.Context, runID int64) ([]*database.BankAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.BankAccount
+	for _, p := range s.payslips {
+		if account, ok := s.bankAccounts[p.UserID]; ok && p.RunID == runID {
+			items = append(items, copyBankAccount(account))
+		}
+	}
+	slices.SortFunc(items, func(a, b *database.BankAccount) int { return cmp.Compare(a.UserID, b.UserID) })
+	return items, nil
+}
@@ -0,0 +1,401 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// employeeByUser finds the row for "WHERE user_id = $1 AND deleted_at IS NULL", caller holds the lock
+func (s *Store) employeeByUser(userID int64) *database.Employee {
+	for _, e := range s.employees {
+		if e.UserID == userID && !e.DeletedAt.Valid {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateEmployee(ctx context.Context, arg database.CreateEmployeeParams) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	salary, err := decimal(arg.Salary, 19, 4)
+	if err != nil {
+		return fail[database.Employee](err)
+	}
+	if !currencyOK(arg.Currency) {
+		return fail[database.Employee](checkErr("employees", "chk_employees_currency"))
+	}
+	if s.employeeByUser(arg.UserID) != nil {
+		return fail[database.Employee](uniqueErr("employees", "employees_user_id_key"))
+	}
+	if !s.userExists(arg.UserID) {
+		return fail[database.Employee](foreignKeyErr("employees", "fk_employee_user"))
+	}
+
+	s.employeeSeq++
+	emp := &database.Employee{
+		ID:        s.employeeSeq,
+		UserID:    arg.UserID,
+		JobTitle:  arg.JobTitle,
+		Country:   arg.Country,
+		Salary:    salary,
+		CreatedAt: s.currentTimestamp(),
+		Currency:  arg.Currency,
+		Version:   1,
+	}
+	s.employees[emp.ID] = emp
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) GetEmployeByuserById(ctx context.Context, userID int64) (*database.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	emp := s.employeeByUser(userID)
+	if emp == nil {
+		return noRows[database.Employee]()
+	}
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) DeleteEmployeeByUserId(ctx context.Context, arg database.DeleteEmployeeByUserIdParams) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	emp := s.employeeByUser(arg.UserID)
+	if emp == nil || emp.Version != arg.Version {
+		return noRows[database.Employee]()
+	}
+	emp.DeletedAt = s.currentTimestamp()
+	emp.Version++
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) GetSalaryMetricsByCountry(ctx context.Context, country string) ([]*database.GetSalaryMetricsByCountryRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.GetSalaryMetricsByCountryRow
+	for _, agg := range aggregateSalaries(s.employees, func(e *database.Employee) bool { return e.Country == country }) {
+		items = append(items, &database.GetSalaryMetricsByCountryRow{
+			Currency:      agg.currency,
+			MinSalary:     agg.min(),
+			MaxSalary:     agg.max(),
+			TotalSalary:   agg.total(),
+			EmployeeCount: agg.count,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) GetAvgSalaryPerJobTitle(ctx context.Context, jobTitle string) ([]*database.GetAvgSalaryPerJobTitleRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.GetAvgSalaryPerJobTitleRow
+	for _, agg := range aggregateSalaries(s.employees, func(e *database.Employee) bool { return e.JobTitle == jobTitle }) {
+		items = append(items, &database.GetAvgSalaryPerJobTitleRow{
+			Currency:      agg.currency,
+			TotalSalary:   agg.total(),
+			EmployeeCount: agg.count,
+		})
+	}
+	return items, nil
+}
+
+// salaryAggregate holds MIN / MAX / SUM / COUNT over the salary column of one currency
+type salaryAggregate struct {
+	currency  string
+	minSalary *big.Rat
+	maxSalary *big.Rat
+	sum       *big.Rat
+	count     int64
+}
+
+// aggregateSalaries is "GROUP BY currency ORDER BY currency" over the matching live rows
+func aggregateSalaries(employees map[int32]*database.Employee, match func(*database.Employee) bool) []*salaryAggregate {
+	groups := make(map[string]*salaryAggregate)
+	for _, e := range employees {
+		if e.DeletedAt.Valid || !match(e) {
+			continue
+		}
+		agg, ok := groups[e.Currency]
+		if !ok {
+			agg = &salaryAggregate{currency: e.Currency, sum: new(big.Rat)}
+			groups[e.Currency] = agg
+		}
+		agg.count++
+
+		salary, ok := numericToRat(e.Salary)
+		if !ok {
+			continue
+		}
+		agg.sum.Add(agg.sum, salary)
+		if agg.minSalary == nil || salary.Cmp(agg.minSalary) < 0 {
+			agg.minSalary = salary
+		}
+		if agg.maxSalary == nil || salary.Cmp(agg.maxSalary) > 0 {
+			agg.maxSalary = salary
+		}
+	}
+
+	aggs := make([]*salaryAggregate, 0, len(groups))
+	for _, agg := range groups {
+		aggs = append(aggs, agg)
+	}
+	sort.Slice(aggs, func(i, j int) bool { return aggs[i].currency < aggs[j].currency })
+	return aggs
+}
+
+// aggregates over zero rows are NULL, just like in Postgres
+
+func (a salaryAggregate) min() pgtype.Numeric {
+	if a.minSalary == nil {
+		return pgtype.Numeric{}
+	}
+	return ratToNumeric(a.minSalary, 4)
+}
+
+func (a salaryAggregate) max() pgtype.Numeric {
+	if a.maxSalary == nil {
+		return pgtype.Numeric{}
+	}
+	return ratToNumeric(a.maxSalary, 4)
+}
+
+func (a salaryAggregate) total() pgtype.Numeric {
+	return ratToNumeric(a.sum, 4)
+}
+
+func (s *Store) GetEmployeeById(ctx context.Context, id int32) (*database.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	emp, ok := s.employees[id]
+	if !ok || emp.DeletedAt.Valid {
+		return noRows[database.Employee]()
+	}
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) GetDeletedEmployeeById(ctx context.Context, id int32) (*database.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	emp, ok := s.employees[id]
+	if !ok || !emp.DeletedAt.Valid {
+		return noRows[database.Employee]()
+	}
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) UpdateEmployeeById(ctx context.Context, arg database.UpdateEmployeeByIdParams) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	emp, ok := s.employees[arg.ID]
+	if !ok || emp.Version != arg.Version || emp.DeletedAt.Valid {
+		return noRows[database.Employee]()
+	}
+
+	emp.JobTitle = arg.JobTitle
+	emp.Country = arg.Country
+	emp.Version++
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) DeleteEmployeeById(ctx context.Context, arg database.DeleteEmployeeByIdParams) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	emp, ok := s.employees[arg.ID]
+	if !ok || emp.Version != arg.Version || emp.DeletedAt.Valid {
+		return noRows[database.Employee]()
+	}
+	emp.DeletedAt = s.currentTimestamp()
+	emp.Version++
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) RestoreEmployeeById(ctx context.Context, id int32) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	emp, ok := s.employees[id]
+	if !ok || !emp.DeletedAt.Valid {
+		return noRows[database.Employee]()
+	}
+	// the partial unique index "employees_user_id_key"
+	if s.employeeByUser(emp.UserID) != nil {
+		return fail[database.Employee](uniqueErr("employees", "employees_user_id_key"))
+	}
+	emp.DeletedAt = pgtype.Timestamp{}
+	emp.Version++
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) RestoreEmployeeByUserId(ctx context.Context, arg database.RestoreEmployeeByUserIdParams) (*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*database.Employee
+	for _, e := range s.employees {
+		if e.UserID == arg.UserID && e.DeletedAt.Valid && !e.DeletedAt.Time.Before(arg.DeletedSince.Time) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) == 0 {
+		return noRows[database.Employee]()
+	}
+	// restoring two of them, or one next to a live one, breaks "employees_user_id_key"
+	if len(matched) > 1 || s.employeeByUser(arg.UserID) != nil {
+		return fail[database.Employee](uniqueErr("employees", "employees_user_id_key"))
+	}
+	emp := matched[0]
+	emp.DeletedAt = pgtype.Timestamp{}
+	emp.Version++
+
+	return copyEmployee(emp), nil
+}
+
+func (s *Store) PurgeDeletedEmployees(ctx context.Context, arg database.PurgeDeletedEmployeesParams) ([]*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*database.Employee
+	for id, e := range s.employees {
+		if !e.DeletedAt.Valid || !e.DeletedAt.Time.Before(arg.DeletedBefore.Time) {
+			continue
+		}
+		if user, ok := s.users[int32(e.UserID)]; arg.KeepOfDeletedUsers && ok && user.DeletedAt.Valid {
+			continue
+		}
+		delete(s.employees, id)
+		s.deleteSalaryHistory(id)
+		items = append(items, copyEmployee(e))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (s *Store) ListEmployees(ctx context.Context, arg database.ListEmployeesParams) ([]*database.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	minSalary, hasMin := numericToRat(arg.MinSalary)
+	maxSalary, hasMax := numericToRat(arg.MaxSalary)
+
+	var items []*database.Employee
+	for _, e := range s.employees {
+		if e.DeletedAt.Valid != arg.Deleted {
+			continue
+		}
+		if arg.Country != nil && e.Country != *arg.Country {
+			continue
+		}
+		if arg.JobTitle != nil && e.JobTitle != *arg.JobTitle {
+			continue
+		}
+		if arg.Currency != nil && e.Currency != *arg.Currency {
+			continue
+		}
+		salary, _ := numericToRat(e.Salary)
+		if hasMin && salary.Cmp(minSalary) < 0 {
+			continue
+		}
+		if hasMax && salary.Cmp(maxSalary) > 0 {
+			continue
+		}
+		if arg.CursorID != nil && !afterCursor(e, arg) {
+			continue
+		}
+		items = append(items, copyEmployee(e))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		c := compareEmployees(items[i], items[j], arg.SortBy)
+		if arg.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if int(arg.PageSize) < len(items) {
+		items = items[:max(arg.PageSize, 0)]
+	}
+	return items, nil
+}
+
+// compareEmployees orders two rows by (sortBy, id), unknown columns fall back to id
+func compareEmployees(a, b *database.Employee, sortBy string) int {
+	var c int
+	switch sortBy {
+	case "user_id":
+		c = cmp.Compare(a.UserID, b.UserID)
+	case "job_title":
+		c = strings.Compare(a.JobTitle, b.JobTitle)
+	case "country":
+		c = strings.Compare(a.Country, b.Country)
+	case "salary":
+		x, _ := numericToRat(a.Salary)
+		y, _ := numericToRat(b.Salary)
+		c = x.Cmp(y)
+	case "created_at":
+		c = a.CreatedAt.Time.Compare(b.CreatedAt.Time)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// afterCursor is the keyset condition "(sortBy, id) > (cursor, cursor_id)", flipped for descending order
+func afterCursor(e *database.Employee, arg database.ListEmployeesParams) bool {
+	cursor := &database.Employee{ID: *arg.CursorID}
+	switch arg.SortBy {
+	case "user_id":
+		if arg.CursorInt == nil {
+			return false
+		}
+		cursor.UserID = *arg.CursorInt
+	case "job_title":
+		if arg.CursorText == nil {
+			return false
+		}
+		cursor.JobTitle = *arg.CursorText
+	case "country":
+		if arg.CursorText == nil {
+			return false
+		}
+		cursor.Country = *arg.CursorText
+	case "salary":
+		if _, ok := numericToRat(arg.CursorSalary); !ok {
+			return false
+		}
+		cursor.Salary = arg.CursorSalary
+	case "created_at":
+		if !arg.CursorTime.Valid {
+			return false
+		}
+		cursor.CreatedAt = arg.CursorTime
+	}
+
+	c := compareEmployees(e, cursor, arg.SortBy)
+	if arg.SortDesc {
+		return c < 0
+	}
+	return c > 0
+}
@@ -0,0 +1,142 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func copyFxRate(r *database.FxRate) *database.FxRate {
+	c := *r
+	return &c
+}
+
+// fxRateFor finds the row for "UNIQUE (base_currency, quote_currency, rate_date)", caller holds the lock
+func (s *Store) fxRateFor(base, quote string, date pgtype.Date) *database.FxRate {
+	for _, r := range s.fxRates {
+		if r.BaseCurrency == base && r.QuoteCurrency == quote && r.RateDate.Time.Equal(date.Time) {
+			return r
+		}
+	}
+	return nil
+}
+
+// compareFxRates is "ORDER BY rate_date DESC, base_currency, quote_currency"
+func compareFxRates(a, b *database.FxRate) int {
+	if c := b.RateDate.Time.Compare(a.RateDate.Time); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.BaseCurrency, b.BaseCurrency); c != 0 {
+		return c
+	}
+	return strings.Compare(a.QuoteCurrency, b.QuoteCurrency)
+}
+
+func (s *Store) UpsertFxRate(ctx context.Context, arg database.UpsertFxRateParams) (*database.FxRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rate, err := decimal(arg.Rate, 20, 10)
+	if err != nil {
+		return fail[database.FxRate](err)
+	}
+	if r, ok := numericToRat(rate); !ok || r.Sign() <= 0 {
+		return fail[database.FxRate](checkErr("fx_rates", "chk_fx_rates_rate"))
+	}
+	if arg.BaseCurrency == arg.QuoteCurrency {
+		return fail[database.FxRate](checkErr("fx_rates", "chk_fx_rates_pair"))
+	}
+
+	// "ON CONFLICT ... DO UPDATE"
+	if existing := s.fxRateFor(arg.BaseCurrency, arg.QuoteCurrency, arg.RateDate); existing != nil {
+		existing.Rate = rate
+		existing.Source = arg.Source
+		return copyFxRate(existing), nil
+	}
+
+	s.fxRateSeq++
+	r := &database.FxRate{
+		ID:            s.fxRateSeq,
+		BaseCurrency:  arg.BaseCurrency,
+		QuoteCurrency: arg.QuoteCurrency,
+		Rate:          rate,
+		RateDate:      arg.RateDate,
+		Source:        arg.Source,
+		CreatedAt:     s.currentTimestamp(),
+	}
+	s.fxRates[r.ID] = r
+
+	return copyFxRate(r), nil
+}
+
+func (s *Store) ListFxRates(ctx context.Context, arg database.ListFxRatesParams) ([]*database.FxRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.FxRate
+	for _, r := range s.fxRates {
+		if arg.Currency != nil && r.BaseCurrency != *arg.Currency && r.QuoteCurrency != *arg.Currency {
+			continue
+		}
+		if arg.Since.Valid && r.RateDate.Time.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && r.RateDate.Time.After(arg.Until.Time) {
+			continue
+		}
+		items = append(items, copyFxRate(r))
+	}
+	slices.SortFunc(items, compareFxRates)
+
+	if int(arg.PageSize) < len(items) {
+		items = items[:max(arg.PageSize, 0)]
+	}
+	return items, nil
+}
+
+func (s *Store) GetFxRatesAsOf(ctx context.Context, asOf pgtype.Date) ([]*database.FxRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// "DISTINCT ON (base_currency, quote_currency) ... rate_date DESC"
+	latest := make(map[[2]string]*database.FxRate)
+	for _, r := range s.fxRates {
+		if r.RateDate.Time.After(asOf.Time) {
+			continue
+		}
+		key := [2]string{r.BaseCurrency, r.QuoteCurrency}
+		if current, ok := latest[key]; !ok || r.RateDate.Time.After(current.RateDate.Time) {
+			latest[key] = r
+		}
+	}
+
+	items := make([]*database.FxRate, 0, len(latest))
+	for _, r := range latest {
+		items = append(items, copyFxRate(r))
+	}
+	slices.SortFunc(items, func(a, b *database.FxRate) int {
+		if c := cmp.Compare(a.BaseCurrency, b.BaseCurrency); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.QuoteCurrency, b.QuoteCurrency)
+	})
+	return items, nil
+}
+
+func (s *Store) DeleteFxRate(ctx context.Context, id int64) (*database.FxRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.fxRates[id]
+	if !ok {
+		return noRows[database.FxRate]()
+	}
+	delete(s.fxRates, id)
+
+	return copyFxRate(r), nil
+}
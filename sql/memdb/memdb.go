@@ -0,0 +1,213 @@
+// Package memdb is an in-memory implementation of database.Querier.
+//
+// It mirrors the constraints declared in "sql/schema" (unique keys, foreign keys,
+// DECIMAL(p,s) rounding) and answers with the same errors pgx would, so handlers
+// behave the same way with or without Postgres.
+package memdb
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Postgres error codes returned by the store
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	numericOutOfRange   = "22003"
+	checkViolation      = "23514"
+	exclusionViolation  = "23P01"
+	raiseException      = "P0001"
+)
+
+// Store keeps every table in memory, guarded by a single lock
+type Store struct {
+	mu sync.RWMutex
+	tables
+
+	now func() time.Time
+}
+
+// tables are the rows of a Store, swapped as a whole when a transaction commits
+type tables struct {
+	users     map[int32]*database.User
+	employees map[int32]*database.Employee
+
+	salaryHistory    map[int64]*database.SalaryHistory
+	salaryHistorySeq int64
+
+	taxRegimes   map[int32]*database.TaxRegime
+	taxRegimeSeq int32
+
+	fxRates   map[int64]*database.FxRate
+	fxRateSeq int64
+
+	payrollRuns   map[int64]*database.PayrollRun
+	payrollRunSeq int64
+	payslips      map[int64]*database.Payslip
+	payslipSeq    int64
+
+	bankAccounts   map[int64]*database.BankAccount // by user_id
+	bankAccountSeq int64
+
+	roles           map[int32]*database.Role
+	permissions     map[int32]*database.Permission
+	rolePermissions map[database.RolePermission]struct{}
+	userRoles       map[userRoleKey]*database.UserRole
+
+	refreshTokens map[int32]*database.RefreshToken
+	revokedTokens map[string]*database.RevokedToken
+	tokenCutoffs  map[int64]*database.UserTokenCutoff
+	signingKeys   map[string]*database.SigningKey
+
+	// BIGSERIAL ids are 1..n, in insertion order
+	auditLog []*database.AuditLog
+
+	// SERIAL sequences
+	userSeq         int32
+	employeeSeq     int32
+	roleSeq         int32
+	permissionSeq   int32
+	refreshTokenSeq int32
+}
+
+var _ database.Transactor = (*Store)(nil)
+
+// New returns an empty store, seeded with the rows the migrations insert
+func New() *Store {
+	s := &Store{tables: tables{
+		users:     make(map[int32]*database.User),
+		employees: make(map[int32]*database.Employee),
+
+		salaryHistory: make(map[int64]*database.SalaryHistory),
+		taxRegimes:    make(map[int32]*database.TaxRegime),
+		fxRates:       make(map[int64]*database.FxRate),
+		payrollRuns:   make(map[int64]*database.PayrollRun),
+		payslips:      make(map[int64]*database.Payslip),
+		bankAccounts:  make(map[int64]*database.BankAccount),
+
+		roles:           make(map[int32]*database.Role),
+		permissions:     make(map[int32]*database.Permission),
+		rolePermissions: make(map[database.RolePermission]struct{}),
+		userRoles:       make(map[userRoleKey]*database.UserRole),
+
+		refreshTokens: make(map[int32]*database.RefreshToken),
+		revokedTokens: make(map[string]*database.RevokedToken),
+		tokenCutoffs:  make(map[int64]*database.UserTokenCutoff),
+		signingKeys:   make(map[string]*database.SigningKey),
+	}, now: time.Now}
+	s.seedRBAC()
+	s.seedTaxRegimes()
+	return s
+}
+
+// currentTimestamp behaves like "DEFAULT CURRENT_TIMESTAMP"
+func (s *Store) currentTimestamp() pgtype.Timestamp {
+	return pgtype.Timestamp{Time: s.now().UTC(), Valid: true}
+}
+
+func uniqueErr(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           uniqueViolation,
+		Message:        `duplicate key value violates unique constraint "` + constraint + `"`,
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func exclusionErr(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           exclusionViolation,
+		Message:        `conflicting key value violates exclusion constraint "` + constraint + `"`,
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyErr(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           foreignKeyViolation,
+		Message:        `insert or update on table "` + table + `" violates foreign key constraint "` + constraint + `"`,
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func checkErr(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           checkViolation,
+		Message:        `new row for relation "` + table + `" violates check constraint "` + constraint + `"`,
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// raiseErr is a "RAISE EXCEPTION" of a trigger
+func raiseErr(message string) error {
+	return &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     raiseException,
+		Message:  message,
+	}
+}
+
+// currencyOK is the "currency ~ '^[A-Z]{3}$'" check of every currency column
+func currencyOK(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// userExists checks the "REFERENCES users(id)" side of a foreign key, caller holds the lock
+func (s *Store) userExists(userID int64) bool {
+	if userID < 1 || userID > math.MaxInt32 {
+		return false
+	}
+	_, ok := s.users[int32(userID)]
+	return ok
+}
+
+// copies are returned so callers can never mutate the stored rows
+
+func copyUser(u *database.User) *database.User {
+	c := *u
+	return &c
+}
+
+func copyEmployee(e *database.Employee) *database.Employee {
+	c := *e
+	return &c
+}
+
+func copyRefreshToken(t *database.RefreshToken) *database.RefreshToken {
+	c := *t
+	return &c
+}
+
+// fail mimics sqlc's ":one" methods, which hand back a zero row along with the error
+func fail[T any](err error) (*T, error) {
+	var zero T
+	return &zero, err
+}
+
+// noRows is what a ":one" query returns when nothing matched
+func noRows[T any]() (*T, error) {
+	return fail[T](pgx.ErrNoRows)
+}
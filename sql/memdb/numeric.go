@@ -0,0 +1,58 @@
+package memdb
+
+import (
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// numericToRat converts a finite, non-NULL numeric to an exact rational
+func numericToRat(n pgtype.Numeric) (*big.Rat, bool) {
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite || n.Int == nil {
+		return nil, false
+	}
+
+	r := new(big.Rat).SetInt(n.Int)
+	if n.Exp > 0 {
+		r.Mul(r, new(big.Rat).SetInt(pow10(n.Exp)))
+	} else if n.Exp < 0 {
+		r.Quo(r, new(big.Rat).SetInt(pow10(-n.Exp)))
+	}
+	return r, true
+}
+
+// ratToNumeric rounds r to "scale" digits the way Postgres ROUND() does (half away from zero)
+func ratToNumeric(r *big.Rat, scale int32) pgtype.Numeric {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// |rem| * 2 >= denom  →  round away from zero
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+	}
+
+	return pgtype.Numeric{Int: quo, Exp: -scale, Valid: true}
+}
+
+// decimal coerces a value into a DECIMAL(precision, scale) column
+func decimal(n pgtype.Numeric, precision, scale int32) (pgtype.Numeric, error) {
+	r, ok := numericToRat(n)
+	if !ok {
+		return n, nil
+	}
+
+	rounded := ratToNumeric(r, scale)
+	if new(big.Int).Abs(rounded.Int).Cmp(pow10(precision)) >= 0 {
+		return pgtype.Numeric{}, &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     numericOutOfRange,
+			Message:  "numeric field overflow",
+		}
+	}
+	return rounded, nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
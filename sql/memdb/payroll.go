@@ -0,0 +1,383 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func copyPayrollRun(r *database.PayrollRun) *database.PayrollRun {
+	c := *r
+	c.CreatedBy, c.LockedBy, c.ReversedBy = copyInt64(r.CreatedBy), copyInt64(r.LockedBy), copyInt64(r.ReversedBy)
+	return &c
+}
+
+// copyInt64 copies a nullable BIGINT
+func copyInt64(v *int64) *int64 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func copyPayslip(p *database.Payslip) *database.Payslip {
+	c := *p
+	c.Deductions = slices.Clone(p.Deductions)
+	return &c
+}
+
+// comparePayrollRuns is "ORDER BY period_start DESC, id DESC"
+func comparePayrollRuns(a, b *database.PayrollRun) int {
+	if c := b.PeriodStart.Time.Compare(a.PeriodStart.Time); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.ID, a.ID)
+}
+
+// comparePayslips is "ORDER BY period_start DESC, id DESC"
+func comparePayslips(a, b *database.Payslip) int {
+	if c := b.PeriodStart.Time.Compare(a.PeriodStart.Time); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.ID, a.ID)
+}
+
+// payslipsFrozen is the "payslips_frozen" trigger, payslips only change while their run
+// is a draft, caller holds the lock
+func (s *Store) payslipsFrozen(runID int64) error {
+	if run, ok := s.payrollRuns[runID]; ok && run.Status != "draft" {
+		return raiseErr("payroll run is " + run.Status + ", its payslips can no longer change")
+	}
+	return nil
+}
+
+func (s *Store) CreatePayrollRun(ctx context.Context, arg database.CreatePayrollRunParams) (*database.PayrollRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if arg.Frequency != "monthly" && arg.Frequency != "biweekly" {
+		return fail[database.PayrollRun](checkErr("payroll_runs", "chk_payroll_runs_frequency"))
+	}
+	if arg.PeriodStart.Time.After(arg.PeriodEnd.Time) {
+		return fail[database.PayrollRun](checkErr("payroll_runs", "chk_payroll_runs_period"))
+	}
+	// "payroll_runs_period_overlap", only over the runs not reversed, both ends included
+	for _, r := range s.payrollRuns {
+		overlaps := !r.PeriodStart.Time.After(arg.PeriodEnd.Time) && !arg.PeriodStart.Time.After(r.PeriodEnd.Time)
+		if r.Status != "reversed" && overlaps {
+			return fail[database.PayrollRun](exclusionErr("payroll_runs", "payroll_runs_period_overlap"))
+		}
+	}
+	if arg.CreatedBy != nil && !s.userExists(*arg.CreatedBy) {
+		return fail[database.PayrollRun](foreignKeyErr("payroll_runs", "fk_payroll_runs_creator"))
+	}
+
+	s.payrollRunSeq++
+	run := &database.PayrollRun{
+		ID:          s.payrollRunSeq,
+		Frequency:   arg.Frequency,
+		PeriodStart: arg.PeriodStart,
+		PeriodEnd:   arg.PeriodEnd,
+		PayDate:     arg.PayDate,
+		Status:      "draft",
+		CreatedBy:   arg.CreatedBy,
+		CreatedAt:   s.currentTimestamp(),
+	}
+	run = copyPayrollRun(run)
+	s.payrollRuns[run.ID] = run
+
+	return copyPayrollRun(run), nil
+}
+
+// GetPayrollRunForUpdate needs no lock of its own, a transaction holds the store until it ends
+func (s *Store) GetPayrollRunForUpdate(ctx context.Context, id int64) (*database.PayrollRun, error) {
+	return s.GetPayrollRun(ctx, id)
+}
+
+func (s *Store) GetPayrollRun(ctx context.Context, id int64) (*database.PayrollRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.payrollRuns[id]
+	if !ok {
+		return noRows[database.PayrollRun]()
+	}
+	return copyPayrollRun(run), nil
+}
+
+func (s *Store) ListPayrollRuns(ctx context.Context, arg database.ListPayrollRunsParams) ([]*database.PayrollRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.PayrollRun
+	for _, r := range s.payrollRuns {
+		if arg.Status == nil || r.Status == *arg.Status {
+			items = append(items, copyPayrollRun(r))
+		}
+	}
+	slices.SortFunc(items, comparePayrollRuns)
+	if int(arg.PageSize) < len(items) {
+		items = items[:max(arg.PageSize, 0)]
+	}
+	return items, nil
+}
+
+func (s *Store) LockPayrollRun(ctx context.Context, arg database.LockPayrollRunParams) (*database.PayrollRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.payrollRuns[arg.ID]
+	if !ok || run.Status != "draft" {
+		return noRows[database.PayrollRun]()
+	}
+	if arg.LockedBy != nil && !s.userExists(*arg.LockedBy) {
+		return fail[database.PayrollRun](foreignKeyErr("payroll_runs", "fk_payroll_runs_locker"))
+	}
+	run.Status = "locked"
+	run.LockedBy = copyInt64(arg.LockedBy)
+	run.LockedAt = s.currentTimestamp()
+
+	return copyPayrollRun(run), nil
+}
+
+func (s *Store) ReversePayrollRun(ctx context.Context, arg database.ReversePayrollRunParams) (*database.PayrollRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.payrollRuns[arg.ID]
+	if !ok || run.Status != "locked" {
+		return noRows[database.PayrollRun]()
+	}
+	if arg.ReversedBy != nil && !s.userExists(*arg.ReversedBy) {
+		return fail[database.PayrollRun](foreignKeyErr("payroll_runs", "fk_payroll_runs_reverser"))
+	}
+	run.Status = "reversed"
+	run.ReversedBy = copyInt64(arg.ReversedBy)
+	run.ReversedAt = s.currentTimestamp()
+	run.ReversalReason = arg.ReversalReason
+
+	return copyPayrollRun(run), nil
+}
+
+func (s *Store) DeletePayrollRun(ctx context.Context, id int64) (*database.PayrollRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.payrollRuns[id]
+	if !ok || run.Status != "draft" {
+		return noRows[database.PayrollRun]()
+	}
+	delete(s.payrollRuns, id)
+
+	// "ON DELETE CASCADE" of "fk_payslips_run"
+	for slipID, p := range s.payslips {
+		if p.RunID == id {
+			delete(s.payslips, slipID)
+		}
+	}
+	return copyPayrollRun(run), nil
+}
+
+func (s *Store) ListPayrollEmployees(ctx context.Context, asOf pgtype.Date) ([]*database.ListPayrollEmployeesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// "JOIN LATERAL (... ORDER BY effective_from DESC, id DESC LIMIT 1)"
+	current := make(map[int32]*database.SalaryHistory)
+	for _, h := range s.salaryHistory {
+		if h.EffectiveFrom.Time.After(asOf.Time) {
+			continue
+		}
+		if latest, ok := current[h.EmployeeID]; !ok || compareSalaryChanges(h, latest) > 0 {
+			current[h.EmployeeID] = h
+		}
+	}
+
+	var items []*database.ListPayrollEmployeesRow
+	for employeeID, h := range current {
+		emp, ok := s.employees[employeeID]
+		if !ok || emp.DeletedAt.Valid {
+			continue
+		}
+		items = append(items, &database.ListPayrollEmployeesRow{
+			ID:       emp.ID,
+			UserID:   emp.UserID,
+			JobTitle: emp.JobTitle,
+			Country:  emp.Country,
+			Salary:   h.Salary,
+			Currency: h.Currency,
+		})
+	}
+	slices.SortFunc(items, func(a, b *database.ListPayrollEmployeesRow) int { return cmp.Compare(a.ID, b.ID) })
+	return items, nil
+}
+
+func (s *Store) CreatePayslip(ctx context.Context, arg database.CreatePayslipParams) (*database.Payslip, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	amounts := []*pgtype.Numeric{
+		&arg.AnnualSalary, &arg.GrossPay, &arg.IncomeTax, &arg.Contributions, &arg.TotalDeductions, &arg.NetPay,
+	}
+	for _, amount := range amounts {
+		value, err := decimal(*amount, 19, 4)
+		if err != nil {
+			return fail[database.Payslip](err)
+		}
+		*amount = value
+	}
+	rate, err := decimal(arg.ExchangeRate, 20, 10)
+	if err != nil {
+		return fail[database.Payslip](err)
+	}
+
+	if err := s.payslipsFrozen(arg.RunID); err != nil {
+		return fail[database.Payslip](err)
+	}
+	if !currencyOK(arg.Currency) {
+		return fail[database.Payslip](checkErr("payslips", "chk_payslips_currency"))
+	}
+	if !currencyOK(arg.SalaryCurrency) {
+		return fail[database.Payslip](checkErr("payslips", "chk_payslips_salary_currency"))
+	}
+	for _, p := range s.payslips {
+		if p.RunID == arg.RunID && p.EmployeeID == arg.EmployeeID {
+			return fail[database.Payslip](uniqueErr("payslips", "payslips_run_employee_key"))
+		}
+	}
+	if _, ok := s.payrollRuns[arg.RunID]; !ok {
+		return fail[database.Payslip](foreignKeyErr("payslips", "fk_payslips_run"))
+	}
+
+	deductions := arg.Deductions
+	if deductions == nil {
+		deductions = []byte("[]")
+	}
+	s.payslipSeq++
+	payslip := &database.Payslip{
+		ID:              s.payslipSeq,
+		RunID:           arg.RunID,
+		EmployeeID:      arg.EmployeeID,
+		UserID:          arg.UserID,
+		JobTitle:        arg.JobTitle,
+		Country:         arg.Country,
+		PeriodStart:     arg.PeriodStart,
+		PeriodEnd:       arg.PeriodEnd,
+		PayDate:         arg.PayDate,
+		TaxRegimeID:     arg.TaxRegimeID,
+		TaxYear:         arg.TaxYear,
+		Currency:        arg.Currency,
+		AnnualSalary:    arg.AnnualSalary,
+		SalaryCurrency:  arg.SalaryCurrency,
+		ExchangeRate:    rate,
+		GrossPay:        arg.GrossPay,
+		Deductions:      deductions,
+		IncomeTax:       arg.IncomeTax,
+		Contributions:   arg.Contributions,
+		TotalDeductions: arg.TotalDeductions,
+		NetPay:          arg.NetPay,
+		CreatedAt:       s.currentTimestamp(),
+	}
+	payslip = copyPayslip(payslip)
+	s.payslips[payslip.ID] = payslip
+
+	return copyPayslip(payslip), nil
+}
+
+func (s *Store) DeleteRunPayslips(ctx context.Context, runID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, p := range s.payslips {
+		if p.RunID == runID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.payslipsFrozen(runID); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(s.payslips, id)
+	}
+	return nil
+}
+
+func (s *Store) ListRunPayslips(ctx context.Context, runID int64) ([]*database.Payslip, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.Payslip
+	for _, p := range s.payslips {
+		if p.RunID == runID {
+			items = append(items, copyPayslip(p))
+		}
+	}
+	slices.SortFunc(items, func(a, b *database.Payslip) int { return cmp.Compare(a.EmployeeID, b.EmployeeID) })
+	return items, nil
+}
+
+// issued is the "JOIN payroll_runs r ... r.status <> 'draft'" of the employee's payslips, caller holds the lock
+func (s *Store) issued(p *database.Payslip) bool {
+	run, ok := s.payrollRuns[p.RunID]
+	return ok && run.Status != "draft"
+}
+
+func (s *Store) ListUserPayslips(ctx context.Context, userID int64) ([]*database.Payslip, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.Payslip
+	for _, p := range s.payslips {
+		if p.UserID == userID && s.issued(p) {
+			items = append(items, copyPayslip(p))
+		}
+	}
+	slices.SortFunc(items, comparePayslips)
+	return items, nil
+}
+
+func (s *Store) GetUserPayslip(ctx context.Context, arg database.GetUserPayslipParams) (*database.Payslip, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.payslips[arg.ID]
+	if !ok || p.UserID != arg.UserID || !s.issued(p) {
+		return noRows[database.Payslip]()
+	}
+	return copyPayslip(p), nil
+}
+
+func (s *Store) ListLockedPayslipsForYear(ctx context.Context, arg database.ListLockedPayslipsForYearParams) ([]*database.Payslip, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.Payslip
+	for _, p := range s.payslips {
+		if p.TaxYear != arg.TaxYear || (arg.UserID != nil && p.UserID != *arg.UserID) {
+			continue
+		}
+		if arg.EmployeeIds != nil && !slices.Contains(arg.EmployeeIds, p.EmployeeID) {
+			continue
+		}
+		if run, ok := s.payrollRuns[p.RunID]; ok && run.Status == "locked" {
+			items = append(items, copyPayslip(p))
+		}
+	}
+	// "ORDER BY pay_date, id"
+	slices.SortFunc(items, func(a, b *database.Payslip) int {
+		if c := a.PayDate.Time.Compare(b.PayDate.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return items, nil
+}
@@ -0,0 +1,206 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"server/sql/database"
+)
+
+// userRoleKey is the "PRIMARY KEY (user_id, role_id)" of user_roles
+type userRoleKey struct {
+	userID int64
+	roleID int32
+}
+
+// seedRBAC inserts the roles and permissions of "008_rbac.sql", "009_superadmin.sql", "010_audit_log.sql", "011_salary_history.sql", "012_tax_regimes.sql", "013_currencies.sql", "015_payroll.sql" and "019_soft_delete.sql", caller holds the lock
+func (s *Store) seedRBAC() {
+	roles := []struct{ name, description string }{
+		{"employee", "Self service on the own employee record"},
+		{"hr", "Manages employee records, no salary analytics"},
+		{"payroll", "Reads employee records and salary analytics"},
+		{"admin", "Everything but handing out the admin role"},
+		{"superadmin", "Everything"},
+	}
+	permissions := []struct{ name, description string }{
+		{"employees:read", "View any employee"},
+		{"employees:write", "Update or delete any employee"},
+		{"salaries:read", "Salary metrics and averages"},
+		{"tokens:revoke", "Log any user out everywhere"},
+		{"roles:read", "View roles and who holds them"},
+		{"roles:write", "Assign and remove roles"},
+		{"admins:write", "Assign and remove the admin and superadmin roles"},
+		{"audit:read", "Query the audit log"},
+		{"salaries:write", "Record and schedule salary changes"},
+		{"taxes:read", "View the tax regimes"},
+		{"taxes:write", "Create, update and delete tax regimes"},
+		{"fx:read", "View the exchange rates"},
+		{"fx:write", "Add, import and delete exchange rates"},
+		{"payroll:read", "View payroll runs and every payslip"},
+		{"payroll:write", "Run, lock, reverse and delete payroll runs"},
+		{"users:write", "Delete and restore users"},
+	}
+	grants := map[string]func(permission string) bool{
+		"hr": func(p string) bool { return p == "employees:read" || p == "employees:write" },
+		"payroll": func(p string) bool {
+			return p == "employees:read" || strings.HasPrefix(p, "salaries:") || strings.HasPrefix(p, "taxes:") ||
+				strings.HasPrefix(p, "fx:") || strings.HasPrefix(p, "payroll:")
+		},
+		"admin":      func(p string) bool { return p != "admins:write" },
+		"superadmin": func(p string) bool { return true },
+	}
+
+	for _, r := range roles {
+		s.roleSeq++
+		s.roles[s.roleSeq] = &database.Role{ID: s.roleSeq, Name: r.name, Description: r.description, CreatedAt: s.currentTimestamp()}
+	}
+	for _, p := range permissions {
+		s.permissionSeq++
+		s.permissions[s.permissionSeq] = &database.Permission{ID: s.permissionSeq, Name: p.name, Description: p.description, CreatedAt: s.currentTimestamp()}
+	}
+	for _, role := range s.roles {
+		granted, ok := grants[role.Name]
+		if !ok {
+			continue
+		}
+		for _, permission := range s.permissions {
+			if granted(permission.Name) {
+				s.rolePermissions[database.RolePermission{RoleID: role.ID, PermissionID: permission.ID}] = struct{}{}
+			}
+		}
+	}
+}
+
+func (s *Store) ListRoles(ctx context.Context) ([]*database.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.Role
+	for _, r := range s.roles {
+		c := *r
+		items = append(items, &c)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
+func (s *Store) GetRoleByName(ctx context.Context, name string) (*database.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.roles {
+		if r.Name == name {
+			c := *r
+			return &c, nil
+		}
+	}
+	return noRows[database.Role]()
+}
+
+// rolePermissionNames collects the permission names of the roles, sorted and distinct, caller holds the lock
+func (s *Store) rolePermissionNames(roleIDs map[int32]bool) []string {
+	seen := make(map[string]bool)
+	var items []string
+	for rp := range s.rolePermissions {
+		if !roleIDs[rp.RoleID] {
+			continue
+		}
+		name := s.permissions[rp.PermissionID].Name
+		if !seen[name] {
+			seen[name] = true
+			items = append(items, name)
+		}
+	}
+	sort.Strings(items)
+	return items
+}
+
+func (s *Store) ListRolePermissions(ctx context.Context, roleID int32) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rolePermissionNames(map[int32]bool{roleID: true}), nil
+}
+
+// userRoleIDs is the set of roles held by the user, caller holds the lock
+func (s *Store) userRoleIDs(userID int64) map[int32]bool {
+	ids := make(map[int32]bool)
+	for key := range s.userRoles {
+		if key.userID == userID {
+			ids[key.roleID] = true
+		}
+	}
+	return ids
+}
+
+func (s *Store) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []string
+	for id := range s.userRoleIDs(userID) {
+		items = append(items, s.roles[id].Name)
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (s *Store) ListUserPermissions(ctx context.Context, userID int64) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rolePermissionNames(s.userRoleIDs(userID)), nil
+}
+
+func (s *Store) AssignUserRole(ctx context.Context, arg database.AssignUserRoleParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := userRoleKey{userID: arg.UserID, roleID: arg.RoleID}
+	// "ON CONFLICT (user_id, role_id) DO NOTHING"
+	if _, ok := s.userRoles[key]; ok {
+		return 0, nil
+	}
+	if !s.userExists(arg.UserID) {
+		return 0, foreignKeyErr("user_roles", "fk_user_role_user")
+	}
+	if _, ok := s.roles[arg.RoleID]; !ok {
+		return 0, foreignKeyErr("user_roles", "fk_user_role_role")
+	}
+
+	s.userRoles[key] = &database.UserRole{
+		UserID:    arg.UserID,
+		RoleID:    arg.RoleID,
+		CreatedAt: s.currentTimestamp(),
+	}
+	return 1, nil
+}
+
+func (s *Store) RemoveUserRole(ctx context.Context, arg database.RemoveUserRoleParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := userRoleKey{userID: arg.UserID, roleID: arg.RoleID}
+	if _, ok := s.userRoles[key]; !ok {
+		return 0, nil
+	}
+	delete(s.userRoles, key)
+	return 1, nil
+}
+
+func (s *Store) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for key := range s.userRoles {
+		if user, ok := s.users[int32(key.userID)]; !ok || user.DeletedAt.Valid {
+			continue
+		}
+		if s.roles[key.roleID].Name == name {
+			count++
+		}
+	}
+	return count, nil
+}
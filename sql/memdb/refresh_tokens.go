@@ -0,0 +1,100 @@
+package memdb
+
+import (
+	"context"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (*database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.refreshTokens {
+		if t.TokenHash == arg.TokenHash {
+			return fail[database.RefreshToken](uniqueErr("refresh_tokens", "refresh_tokens_token_hash_key"))
+		}
+	}
+	if !s.userExists(arg.UserID) {
+		return fail[database.RefreshToken](foreignKeyErr("refresh_tokens", "fk_refresh_token_user"))
+	}
+
+	s.refreshTokenSeq++
+	token := &database.RefreshToken{
+		ID:        s.refreshTokenSeq,
+		UserID:    arg.UserID,
+		FamilyID:  arg.FamilyID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: s.currentTimestamp(),
+	}
+	s.refreshTokens[token.ID] = token
+
+	return copyRefreshToken(token), nil
+}
+
+func (s *Store) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.refreshTokens {
+		if t.TokenHash == tokenHash {
+			return copyRefreshToken(t), nil
+		}
+	}
+	return noRows[database.RefreshToken]()
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, id int32) (*database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// "WHERE id = $1 AND revoked_at IS NULL", only one caller can win a rotation
+	token, ok := s.refreshTokens[id]
+	if !ok || token.RevokedAt.Valid {
+		return noRows[database.RefreshToken]()
+	}
+	token.RevokedAt = s.currentTimestamp()
+
+	return copyRefreshToken(token), nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.refreshTokens {
+		if t.FamilyID == familyID && !t.RevokedAt.Valid {
+			t.RevokedAt = s.currentTimestamp()
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.refreshTokens {
+		if t.UserID == userID && !t.RevokedAt.Valid {
+			t.RevokedAt = s.currentTimestamp()
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredRefreshTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, t := range s.refreshTokens {
+		if t.ExpiresAt.Time.Before(expiresAt.Time) {
+			delete(s.refreshTokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,93 @@
+package memdb
+
+import (
+	"context"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *Store) RevokeToken(ctx context.Context, arg database.RevokeTokenParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// "ON CONFLICT (jti) DO NOTHING"
+	if _, ok := s.revokedTokens[arg.Jti]; ok {
+		return nil
+	}
+	if !s.userExists(arg.UserID) {
+		return foreignKeyErr("revoked_tokens", "fk_revoked_token_user")
+	}
+
+	s.revokedTokens[arg.Jti] = &database.RevokedToken{
+		Jti:       arg.Jti,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: s.currentTimestamp(),
+	}
+	return nil
+}
+
+func (s *Store) RevokeUserTokens(ctx context.Context, arg database.RevokeUserTokensParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return foreignKeyErr("user_token_cutoffs", "fk_token_cutoff_user")
+	}
+
+	// "ON CONFLICT (user_id) DO UPDATE"
+	if cutoff, ok := s.tokenCutoffs[arg.UserID]; ok {
+		cutoff.NotBefore = arg.NotBefore
+		cutoff.ExpiresAt = arg.ExpiresAt
+		return nil
+	}
+
+	s.tokenCutoffs[arg.UserID] = &database.UserTokenCutoff{
+		UserID:    arg.UserID,
+		NotBefore: arg.NotBefore,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: s.currentTimestamp(),
+	}
+	return nil
+}
+
+func (s *Store) IsTokenRevoked(ctx context.Context, arg database.IsTokenRevokedParams) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.revokedTokens[arg.Jti]; ok {
+		return true, nil
+	}
+	cutoff, ok := s.tokenCutoffs[arg.UserID]
+	return ok && cutoff.NotBefore.Time.After(arg.IssuedAt.Time), nil
+}
+
+func (s *Store) DeleteExpiredRevokedTokens(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for jti, t := range s.revokedTokens {
+		if t.ExpiresAt.Time.Before(expiresAt.Time) {
+			delete(s.revokedTokens, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *Store) DeleteExpiredUserTokenCutoffs(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for userID, c := range s.tokenCutoffs {
+		if c.ExpiresAt.Time.Before(expiresAt.Time) {
+			delete(s.tokenCutoffs, userID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
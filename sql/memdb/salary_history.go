@@ -0,0 +1,149 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Reasons allowed by "chk_salary_history_reason"
+var salaryChangeReasons = []string{
+	"hire", "promotion", "merit", "market", "cost_of_living", "adjustment", "correction", "demotion",
+}
+
+func copySalaryChange(h *database.SalaryHistory) *database.SalaryHistory {
+	c := *h
+	if h.ApprovedBy != nil {
+		approvedBy := *h.ApprovedBy
+		c.ApprovedBy = &approvedBy
+	}
+	return &c
+}
+
+// deleteSalaryHistory is the "ON DELETE CASCADE" of "fk_salary_history_employee", caller holds the lock
+func (s *Store) deleteSalaryHistory(employeeID int32) {
+	for id, h := range s.salaryHistory {
+		if h.EmployeeID == employeeID {
+			delete(s.salaryHistory, id)
+		}
+	}
+}
+
+// compareSalaryChanges is "ORDER BY effective_from, id"
+func compareSalaryChanges(a, b *database.SalaryHistory) int {
+	if c := a.EffectiveFrom.Time.Compare(b.EffectiveFrom.Time); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func (s *Store) CreateSalaryChange(ctx context.Context, arg database.CreateSalaryChangeParams) (*database.SalaryHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	salary, err := decimal(arg.Salary, 19, 4)
+	if err != nil {
+		return fail[database.SalaryHistory](err)
+	}
+	if !slices.Contains(salaryChangeReasons, arg.Reason) {
+		return fail[database.SalaryHistory](checkErr("salary_history", "chk_salary_history_reason"))
+	}
+	if !currencyOK(arg.Currency) {
+		return fail[database.SalaryHistory](checkErr("salary_history", "chk_salary_history_currency"))
+	}
+	if _, ok := s.employees[arg.EmployeeID]; !ok {
+		return fail[database.SalaryHistory](foreignKeyErr("salary_history", "fk_salary_history_employee"))
+	}
+	if arg.ApprovedBy != nil && !s.userExists(*arg.ApprovedBy) {
+		return fail[database.SalaryHistory](foreignKeyErr("salary_history", "fk_salary_history_approver"))
+	}
+
+	s.salaryHistorySeq++
+	change := &database.SalaryHistory{
+		ID:            s.salaryHistorySeq,
+		EmployeeID:    arg.EmployeeID,
+		Salary:        salary,
+		EffectiveFrom: arg.EffectiveFrom,
+		Reason:        arg.Reason,
+		ApprovedBy:    arg.ApprovedBy,
+		Note:          arg.Note,
+		CreatedAt:     s.currentTimestamp(),
+		Currency:      arg.Currency,
+	}
+	change = copySalaryChange(change)
+	s.salaryHistory[change.ID] = change
+
+	return copySalaryChange(change), nil
+}
+
+func (s *Store) ListSalaryHistory(ctx context.Context, employeeID int32) ([]*database.SalaryHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.SalaryHistory
+	for _, h := range s.salaryHistory {
+		if h.EmployeeID == employeeID {
+			items = append(items, copySalaryChange(h))
+		}
+	}
+	slices.SortFunc(items, compareSalaryChanges)
+	return items, nil
+}
+
+func (s *Store) DeleteScheduledSalaryChange(ctx context.Context, arg database.DeleteScheduledSalaryChangeParams) (*database.SalaryHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.salaryHistory[arg.ID]
+	if !ok || h.EmployeeID != arg.EmployeeID || !h.EffectiveFrom.Time.After(arg.AsOf.Time) {
+		return noRows[database.SalaryHistory]()
+	}
+	delete(s.salaryHistory, arg.ID)
+
+	return copySalaryChange(h), nil
+}
+
+func (s *Store) ApplyEffectiveSalaries(ctx context.Context, arg database.ApplyEffectiveSalariesParams) ([]*database.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Latest entry in effect per employee, "DISTINCT ON (employee_id) ... effective_from DESC, id DESC"
+	current := make(map[int32]*database.SalaryHistory)
+	for _, h := range s.salaryHistory {
+		if h.EffectiveFrom.Time.After(arg.AsOf.Time) {
+			continue
+		}
+		if latest, ok := current[h.EmployeeID]; !ok || compareSalaryChanges(h, latest) > 0 {
+			current[h.EmployeeID] = h
+		}
+	}
+
+	var items []*database.Employee
+	for employeeID, h := range current {
+		if arg.EmployeeID != nil && *arg.EmployeeID != employeeID {
+			continue
+		}
+		emp, ok := s.employees[employeeID]
+		if !ok || emp.DeletedAt.Valid || (numericEqual(emp.Salary, h.Salary) && emp.Currency == h.Currency) {
+			continue
+		}
+		emp.Salary = h.Salary
+		emp.Currency = h.Currency
+		emp.Version++
+		items = append(items, copyEmployee(emp))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+// numericEqual compares like "=" does, 1.5 = 1.50
+func numericEqual(a, b pgtype.Numeric) bool {
+	ra, okA := numericToRat(a)
+	rb, okB := numericToRat(b)
+	return okA && okB && ra.Cmp(rb) == 0
+}
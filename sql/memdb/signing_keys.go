@@ -0,0 +1,66 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *Store) CreateSigningKey(ctx context.Context, arg database.CreateSigningKeyParams) (*database.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.signingKeys[arg.Kid]; ok {
+		return fail[database.SigningKey](uniqueErr("signing_keys", "signing_keys_pkey"))
+	}
+
+	key := &database.SigningKey{
+		Kid:        arg.Kid,
+		Algorithm:  arg.Algorithm,
+		PrivateKey: arg.PrivateKey,
+		PublicKey:  arg.PublicKey,
+		RetiresAt:  arg.RetiresAt,
+		ExpiresAt:  arg.ExpiresAt,
+		CreatedAt:  s.currentTimestamp(),
+	}
+	s.signingKeys[key.Kid] = key
+
+	c := *key
+	return &c, nil
+}
+
+func (s *Store) ListSigningKeys(ctx context.Context, expiresAt pgtype.Timestamp) ([]*database.SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.SigningKey
+	for _, k := range s.signingKeys {
+		if k.ExpiresAt.Time.After(expiresAt.Time) {
+			c := *k
+			items = append(items, &c)
+		}
+	}
+
+	// "ORDER BY retires_at DESC"
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].RetiresAt.Time.After(items[j].RetiresAt.Time)
+	})
+	return items, nil
+}
+
+func (s *Store) DeleteExpiredSigningKeys(ctx context.Context, expiresAt pgtype.Timestamp) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for kid, k := range s.signingKeys {
+		if k.ExpiresAt.Time.Before(expiresAt.Time) {
+			delete(s.signingKeys, kid)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
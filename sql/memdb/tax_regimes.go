@@ -0,0 +1,185 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"math/big"
+	"slices"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func copyTaxRegime(t *database.TaxRegime) *database.TaxRegime {
+	c := *t
+	c.Brackets = append([]byte(nil), t.Brackets...)
+	c.Contributions = append([]byte(nil), t.Contributions...)
+	return &c
+}
+
+// seedTaxRegimes inserts the regimes of "012_tax_regimes.sql" in the currencies of "013_currencies.sql"
+// and the country codes of "017_country_codes.sql", caller holds the lock
+func (s *Store) seedTaxRegimes() {
+	seeds := []struct {
+		country       string
+		currency      string
+		allowance     int64
+		brackets      string
+		contributions string
+	}{
+		{"IN", "INR", 75000,
+			`[{"from": 0, "up_to": 400000, "rate": 0}, {"from": 400000, "up_to": 800000, "rate": 5},
+			  {"from": 800000, "up_to": 1200000, "rate": 10}, {"from": 1200000, "up_to": 1600000, "rate": 15},
+			  {"from": 1600000, "up_to": 2000000, "rate": 20}, {"from": 2000000, "up_to": 2400000, "rate": 25},
+			  {"from": 2400000, "up_to": null, "rate": 30}]`,
+			`[{"name": "provident_fund", "rate": 12, "cap": 180000, "deductible": false}]`},
+		{"US", "USD", 15000,
+			`[{"from": 0, "up_to": 11925, "rate": 10}, {"from": 11925, "up_to": 48475, "rate": 12},
+			  {"from": 48475, "up_to": 103350, "rate": 22}, {"from": 103350, "up_to": 197300, "rate": 24},
+			  {"from": 197300, "up_to": 250525, "rate": 32}, {"from": 250525, "up_to": 626350, "rate": 35},
+			  {"from": 626350, "up_to": null, "rate": 37}]`,
+			`[{"name": "social_security", "rate": 6.2, "cap": 176100, "deductible": false},
+			  {"name": "medicare", "rate": 1.45, "cap": null, "deductible": false}]`},
+	}
+
+	for _, seed := range seeds {
+		s.taxRegimeSeq++
+		s.taxRegimes[s.taxRegimeSeq] = &database.TaxRegime{
+			ID:                s.taxRegimeSeq,
+			Country:           seed.country,
+			TaxYear:           2025,
+			StandardAllowance: pgtype.Numeric{Int: big.NewInt(seed.allowance * 10000), Exp: -4, Valid: true},
+			Brackets:          []byte(seed.brackets),
+			Contributions:     []byte(seed.contributions),
+			CreatedAt:         s.currentTimestamp(),
+			UpdatedAt:         s.currentTimestamp(),
+			Currency:          seed.currency,
+		}
+	}
+}
+
+// taxRegimeFor finds the row for "UNIQUE (country, tax_year)", caller holds the lock
+func (s *Store) taxRegimeFor(country string, taxYear int32) *database.TaxRegime {
+	for _, t := range s.taxRegimes {
+		if t.Country == country && t.TaxYear == taxYear {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateTaxRegime(ctx context.Context, arg database.CreateTaxRegimeParams) (*database.TaxRegime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allowance, err := decimal(arg.StandardAllowance, 19, 4)
+	if err != nil {
+		return fail[database.TaxRegime](err)
+	}
+	if !currencyOK(arg.Currency) {
+		return fail[database.TaxRegime](checkErr("tax_regimes", "chk_tax_regimes_currency"))
+	}
+	if s.taxRegimeFor(arg.Country, arg.TaxYear) != nil {
+		return fail[database.TaxRegime](uniqueErr("tax_regimes", "tax_regimes_country_tax_year_key"))
+	}
+
+	s.taxRegimeSeq++
+	regime := &database.TaxRegime{
+		ID:                s.taxRegimeSeq,
+		Country:           arg.Country,
+		TaxYear:           arg.TaxYear,
+		StandardAllowance: allowance,
+		Brackets:          arg.Brackets,
+		Contributions:     arg.Contributions,
+		CreatedAt:         s.currentTimestamp(),
+		UpdatedAt:         s.currentTimestamp(),
+		Currency:          arg.Currency,
+	}
+	regime = copyTaxRegime(regime)
+	s.taxRegimes[regime.ID] = regime
+
+	return copyTaxRegime(regime), nil
+}
+
+func (s *Store) GetTaxRegimeById(ctx context.Context, id int32) (*database.TaxRegime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	regime, ok := s.taxRegimes[id]
+	if !ok {
+		return noRows[database.TaxRegime]()
+	}
+	return copyTaxRegime(regime), nil
+}
+
+func (s *Store) GetTaxRegimeForYear(ctx context.Context, arg database.GetTaxRegimeForYearParams) (*database.TaxRegime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *database.TaxRegime
+	for _, t := range s.taxRegimes {
+		if t.Country == arg.Country && t.TaxYear <= arg.TaxYear && (latest == nil || t.TaxYear > latest.TaxYear) {
+			latest = t
+		}
+	}
+	if latest == nil {
+		return noRows[database.TaxRegime]()
+	}
+	return copyTaxRegime(latest), nil
+}
+
+func (s *Store) ListTaxRegimes(ctx context.Context, country *string) ([]*database.TaxRegime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*database.TaxRegime
+	for _, t := range s.taxRegimes {
+		if country == nil || t.Country == *country {
+			items = append(items, copyTaxRegime(t))
+		}
+	}
+	// "ORDER BY country, tax_year DESC"
+	slices.SortFunc(items, func(a, b *database.TaxRegime) int {
+		if c := cmp.Compare(a.Country, b.Country); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.TaxYear, a.TaxYear)
+	})
+	return items, nil
+}
+
+func (s *Store) UpdateTaxRegime(ctx context.Context, arg database.UpdateTaxRegimeParams) (*database.TaxRegime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	regime, ok := s.taxRegimes[arg.ID]
+	if !ok {
+		return noRows[database.TaxRegime]()
+	}
+
+	allowance, err := decimal(arg.StandardAllowance, 19, 4)
+	if err != nil {
+		return fail[database.TaxRegime](err)
+	}
+
+	regime.StandardAllowance = allowance
+	regime.Brackets = append([]byte(nil), arg.Brackets...)
+	regime.Contributions = append([]byte(nil), arg.Contributions...)
+	regime.UpdatedAt = s.currentTimestamp()
+
+	return copyTaxRegime(regime), nil
+}
+
+func (s *Store) DeleteTaxRegime(ctx context.Context, id int32) (*database.TaxRegime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	regime, ok := s.taxRegimes[id]
+	if !ok {
+		return noRows[database.TaxRegime]()
+	}
+	delete(s.taxRegimes, id)
+
+	return copyTaxRegime(regime), nil
+}
@@ -0,0 +1,59 @@
+package memdb
+
+import (
+	"context"
+	"maps"
+
+	"server/sql/database"
+)
+
+// InTx runs fn on a copy of the store, the copy becomes the store once fn returns nil.
+// The store stays locked meanwhile, other queries wait for the transaction to end.
+func (s *Store) InTx(ctx context.Context, fn func(database.Querier) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Store{tables: s.tables.clone(), now: s.now}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.tables = tx.tables
+	return nil
+}
+
+// clone copies every row, nothing done to the copy shows in t
+func (t tables) clone() tables {
+	c := t
+	c.users = cloneRows(t.users)
+	c.employees = cloneRows(t.employees)
+	c.salaryHistory = cloneRows(t.salaryHistory)
+	c.taxRegimes = cloneRows(t.taxRegimes)
+	c.fxRates = cloneRows(t.fxRates)
+	c.payrollRuns = cloneRows(t.payrollRuns)
+	c.payslips = cloneRows(t.payslips)
+	c.bankAccounts = cloneRows(t.bankAccounts)
+	c.roles = cloneRows(t.roles)
+	c.permissions = cloneRows(t.permissions)
+	c.rolePermissions = maps.Clone(t.rolePermissions)
+	c.userRoles = cloneRows(t.userRoles)
+	c.refreshTokens = cloneRows(t.refreshTokens)
+	c.revokedTokens = cloneRows(t.revokedTokens)
+	c.tokenCutoffs = cloneRows(t.tokenCutoffs)
+	c.signingKeys = cloneRows(t.signingKeys)
+
+	c.auditLog = make([]*database.AuditLog, len(t.auditLog))
+	for i, entry := range t.auditLog {
+		row := *entry
+		c.auditLog[i] = &row
+	}
+	return c
+}
+
+func cloneRows[K comparable, V any](rows map[K]*V) map[K]*V {
+	c := make(map[K]*V, len(rows))
+	for k, v := range rows {
+		row := *v
+		c[k] = &row
+	}
+	return c
+}
@@ -0,0 +1,85 @@
+package memdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestInTxCommits(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	err := s.InTx(ctx, func(q database.Querier) error {
+		_, err := q.CreateUser(ctx, database.CreateUserParams{Username: "jane", Email: "jane@example.com"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetUserByEmail(ctx, "jane@example.com"); err != nil {
+		t.Errorf("committed user: %v", err)
+	}
+}
+
+// Nothing done in a failed transaction shows in the store, not even the rows written before the failure
+func TestInTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	existing, err := s.CreateUser(ctx, database.CreateUserParams{Username: "john", Email: "john@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.InTx(ctx, func(q database.Querier) error {
+		user, err := q.CreateUser(ctx, database.CreateUserParams{Username: "jane", Email: "jane@example.com"})
+		if err != nil {
+			return err
+		}
+		// Seen inside the transaction
+		if _, err := q.GetUserByEmail(ctx, "jane@example.com"); err != nil {
+			t.Errorf("user in the transaction: %v", err)
+		}
+		if _, err := q.DeleteUserById(ctx, existing.ID); err != nil {
+			return err
+		}
+		if _, err := q.CreateEmployee(ctx, database.CreateEmployeeParams{UserID: int64(user.ID), JobTitle: "dev", Country: "US", Currency: "USD"}); err != nil {
+			return err
+		}
+		// users_email_key
+		_, err = q.CreateUser(ctx, database.CreateUserParams{Username: "jane2", Email: "jane@example.com"})
+		return err
+	})
+	if err == nil {
+		t.Fatal("the duplicate email passed")
+	}
+
+	if _, err := s.GetUserByEmail(ctx, "jane@example.com"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("user of the failed transaction: %v, want no rows", err)
+	}
+	if _, err := s.GetUserByEmail(ctx, "john@example.com"); err != nil {
+		t.Errorf("user deleted in the failed transaction: %v, want them live", err)
+	}
+	employees, err := s.ListEmployees(ctx, database.ListEmployeesParams{PageSize: 10})
+	if err != nil || len(employees) != 0 {
+		t.Errorf("employees of the failed transaction: %v, %v", employees, err)
+	}
+
+	// The store isn't left locked
+	if _, err := s.CreateUser(ctx, database.CreateUserParams{Username: "jane", Email: "jane@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An error of fn is what InTx returns
+func TestInTxReturnsError(t *testing.T) {
+	errStop := errors.New("stop")
+	err := New().InTx(context.Background(), func(q database.Querier) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("InTx = %v, want %v", err, errStop)
+	}
+}
@@ -0,0 +1,212 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// email VARCHAR(255) UNIQUE NOT NULL
+	for _, u := range s.users {
+		if u.Email == arg.Email {
+			return fail[database.User](uniqueErr("users", "users_email_key"))
+		}
+	}
+
+	s.userSeq++
+	user := &database.User{
+		ID:           s.userSeq,
+		Username:     arg.Username,
+		Email:        arg.Email,
+		PasswordHash: arg.PasswordHash,
+		CreatedAt:    arg.CreatedAt,
+	}
+	s.users[user.ID] = user
+
+	return copyUser(user), nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email && !u.DeletedAt.Valid {
+			return copyUser(u), nil
+		}
+	}
+	return noRows[database.User]()
+}
+
+func (s *Store) GetUserById(ctx context.Context, id int32) (*database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return noRows[database.User]()
+	}
+	return copyUser(user), nil
+}
+
+func (s *Store) GetDeletedUserById(ctx context.Context, id int32) (*database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok || !user.DeletedAt.Valid {
+		return noRows[database.User]()
+	}
+	return copyUser(user), nil
+}
+
+func (s *Store) GetUserByName(ctx context.Context, username string) (*database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// "LIMIT 1" without ORDER BY, lowest id is as good as any
+	var found *database.User
+	for _, u := range s.users {
+		if u.Username == username && !u.DeletedAt.Valid && (found == nil || u.ID < found.ID) {
+			found = u
+		}
+	}
+	if found == nil {
+		return noRows[database.User]()
+	}
+	return copyUser(found), nil
+}
+
+func (s *Store) ListUsersByIDs(ctx context.Context, ids []int32) ([]*database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var users []*database.User
+	for _, id := range ids {
+		if u, ok := s.users[id]; ok && !u.DeletedAt.Valid {
+			users = append(users, copyUser(u))
+		}
+	}
+	slices.SortFunc(users, func(a, b *database.User) int { return cmp.Compare(a.ID, b.ID) })
+	// "= ANY" matches a row once, however often its id is listed
+	return slices.CompactFunc(users, func(a, b *database.User) bool { return a.ID == b.ID }), nil
+}
+
+func (s *Store) DeleteUserById(ctx context.Context, id int32) (*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return noRows[database.User]()
+	}
+	user.DeletedAt = s.currentTimestamp()
+	return copyUser(user), nil
+}
+
+func (s *Store) RestoreUserById(ctx context.Context, id int32) (*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || !user.DeletedAt.Valid || user.AnonymizedAt.Valid {
+		return noRows[database.User]()
+	}
+	user.DeletedAt = pgtype.Timestamp{}
+	return copyUser(user), nil
+}
+
+// deletedBefore lists the users deleted before the cutoff, by id, caller holds the lock
+func (s *Store) deletedBefore(cutoff pgtype.Timestamp) []*database.User {
+	var users []*database.User
+	for _, u := range s.users {
+		if u.DeletedAt.Valid && u.DeletedAt.Time.Before(cutoff.Time) {
+			users = append(users, u)
+		}
+	}
+	slices.SortFunc(users, func(a, b *database.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users
+}
+
+func (s *Store) PurgeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*database.User
+	for _, u := range s.deletedBefore(deletedBefore) {
+		s.deleteUser(u.ID)
+		items = append(items, copyUser(u))
+	}
+	return items, nil
+}
+
+// deleteUser removes the user along with the rows of every "ON DELETE CASCADE" and clears the
+// "ON DELETE SET NULL" references, caller holds the lock
+func (s *Store) deleteUser(id int32) {
+	userID := int64(id)
+	delete(s.users, id)
+
+	for empID, e := range s.employees {
+		if e.UserID == userID {
+			delete(s.employees, empID)
+			s.deleteSalaryHistory(empID)
+		}
+	}
+	delete(s.bankAccounts, userID)
+	for key := range s.userRoles {
+		if key.userID == userID {
+			delete(s.userRoles, key)
+		}
+	}
+	for tokenID, t := range s.refreshTokens {
+		if t.UserID == userID {
+			delete(s.refreshTokens, tokenID)
+		}
+	}
+	for jti, t := range s.revokedTokens {
+		if t.UserID == userID {
+			delete(s.revokedTokens, jti)
+		}
+	}
+	delete(s.tokenCutoffs, userID)
+
+	for _, h := range s.salaryHistory {
+		if h.ApprovedBy != nil && *h.ApprovedBy == userID {
+			h.ApprovedBy = nil
+		}
+	}
+	for _, r := range s.payrollRuns {
+		for _, by := range []**int64{&r.CreatedBy, &r.LockedBy, &r.ReversedBy} {
+			if *by != nil && **by == userID {
+				*by = nil
+			}
+		}
+	}
+}
+
+func (s *Store) AnonymizeDeletedUsers(ctx context.Context, deletedBefore pgtype.Timestamp) ([]*database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*database.User
+	for _, u := range s.deletedBefore(deletedBefore) {
+		if u.AnonymizedAt.Valid {
+			continue
+		}
+		u.Username = "deleted user"
+		u.Email = fmt.Sprintf("deleted-%d@anonymized.invalid", u.ID)
+		u.PasswordHash = ""
+		u.AnonymizedAt = s.currentTimestamp()
+		items = append(items, copyUser(u))
+	}
+	return items, nil
+}
@@ -0,0 +1,38 @@
+-- name: CreateAuditEntry :one
+INSERT INTO audit_log
+(
+    actor_id,
+    action,
+    target_type,
+    target_id,
+    diff,
+    request_id,
+    ip,
+    created_at,
+    prev_hash,
+    hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING * ;
+
+-- name: GetLatestAuditEntry :one
+SELECT * FROM audit_log ORDER BY id DESC LIMIT 1;
+
+-- name: ListAuditEntries :many
+SELECT * FROM audit_log
+WHERE
+    (sqlc.narg('actor_id')::bigint IS NULL OR actor_id = sqlc.narg('actor_id'))
+    AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+    AND (sqlc.narg('target_type')::text IS NULL OR target_type = sqlc.narg('target_type'))
+    AND (sqlc.narg('target_id')::text IS NULL OR target_id = sqlc.narg('target_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+    AND (sqlc.narg('cursor_id')::bigint IS NULL OR id < sqlc.narg('cursor_id'))
+ORDER BY id DESC
+LIMIT @page_size::int;
+
+-- name: ListAuditEntriesAfter :many
+SELECT * FROM audit_log
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2;
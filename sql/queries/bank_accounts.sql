@@ -0,0 +1,38 @@
+-- name: UpsertBankAccount :one
+-- One account per user, registering another replaces it
+INSERT INTO bank_accounts
+(
+    user_id,
+    scheme,
+    holder_name,
+    iban,
+    bic,
+    routing_number,
+    account_number,
+    account_type
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (user_id) DO UPDATE SET
+    scheme = EXCLUDED.scheme,
+    holder_name = EXCLUDED.holder_name,
+    iban = EXCLUDED.iban,
+    bic = EXCLUDED.bic,
+    routing_number = EXCLUDED.routing_number,
+    account_number = EXCLUDED.account_number,
+    account_type = EXCLUDED.account_type,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetUserBankAccount :one
+SELECT * FROM bank_accounts WHERE user_id = $1;
+
+-- name: DeleteUserBankAccount :one
+DELETE FROM bank_accounts WHERE user_id = $1 RETURNING *;
+
+-- name: ListRunBankAccounts :many
+-- The accounts of the employees paid by a run
+SELECT b.* FROM bank_accounts b
+JOIN payslips p ON p.user_id = b.user_id
+WHERE p.run_id = $1
+ORDER BY b.user_id;
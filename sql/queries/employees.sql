@@ -1,6 +1,6 @@
 
 -- name: GetEmployeByuserById :one
-SELECT * FROM employees WHERE user_id = $1;
+SELECT * FROM employees WHERE user_id = $1 AND deleted_at IS NULL;
 
 -- name: CreateEmployee :one
 INSERT INTO employees
@@ -8,36 +8,143 @@ INSERT INTO employees
     user_id,
     job_title,
     country,
-    salary
+    salary,
+    currency
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 ) RETURNING * ;
 
--- name: UpdateEmployeeByUserId :one
-UPDATE employees
-SET 
-    job_title  = $2,
-    country    = $3,
-    salary     = $4
-WHERE user_id = $1
-RETURNING *;                                 
-
 -- name: DeleteEmployeeByUserId :one
-DELETE FROM employees WHERE user_id = $1 RETURNING *;
+-- Soft delete, misses the row once it's no longer at "version"
+UPDATE employees
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    version    = version + 1
+WHERE user_id = $1 AND version = $2 AND deleted_at IS NULL
+RETURNING *;
 
 
--- name: GetSalaryMetricsByCountry :one
+-- name: GetSalaryMetricsByCountry :many
+-- One row per currency, amounts in different currencies only add up once converted
 SELECT 
-    ROUND(MIN(salary), 2)   AS min_salary,
-    ROUND(MAX(salary), 2)   AS max_salary,
-    ROUND(AVG(salary), 2)   AS avg_salary,
+    currency,
+    MIN(salary)             AS min_salary,
+    MAX(salary)             AS max_salary,
+    SUM(salary)             AS total_salary,
     COUNT(*)                AS employee_count
 FROM employees
-WHERE country = $1;
+WHERE country = $1 AND deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency;
 
--- name: GetAvgSalaryPerJobTitle :one
+-- name: GetAvgSalaryPerJobTitle :many
+-- One row per currency, amounts in different currencies only add up once converted
 SELECT 
-    ROUND(AVG(salary), 2)   AS average_salary,
+    currency,
+    SUM(salary)             AS total_salary,
     COUNT(*)                AS employee_count
 FROM employees
-WHERE job_title = $1;
+WHERE job_title = $1 AND deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetEmployeeById :one
+SELECT * FROM employees WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetDeletedEmployeeById :one
+SELECT * FROM employees WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: UpdateEmployeeById :one
+-- Misses the row once it's no longer at "version", the one read before the change
+UPDATE employees
+SET 
+    job_title  = $2,
+    country    = $3,
+    version    = version + 1
+WHERE id = $1 AND version = $4 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteEmployeeById :one
+-- Soft delete, misses the row once it's no longer at "version"
+UPDATE employees
+SET
+    deleted_at = CURRENT_TIMESTAMP,
+    version    = version + 1
+WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreEmployeeById :one
+-- Fails on "employees_user_id_key" once the user has another live employee
+UPDATE employees
+SET
+    deleted_at = NULL,
+    version    = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: RestoreEmployeeByUserId :one
+-- The employee deleted along with its user, at the same time or later
+UPDATE employees
+SET
+    deleted_at = NULL,
+    version    = version + 1
+WHERE user_id = @user_id AND deleted_at >= @deleted_since::timestamp
+RETURNING *;
+
+-- name: PurgeDeletedEmployees :many
+-- Deleted before "deleted_before", their salary history goes with them. With "keep_of_deleted_users"
+-- the employees of a deleted user stay, anonymized along with it.
+DELETE FROM employees e
+WHERE e.deleted_at < @deleted_before::timestamp
+    AND NOT (
+        @keep_of_deleted_users::bool
+        AND EXISTS (SELECT 1 FROM users u WHERE u.id = e.user_id AND u.deleted_at IS NOT NULL)
+    )
+RETURNING *;
+
+-- name: ListEmployees :many
+-- The live employees, or the deleted ones with "deleted"
+SELECT * FROM employees
+WHERE
+    (deleted_at IS NOT NULL) = @deleted::bool
+    AND (sqlc.narg('country')::text IS NULL OR country = sqlc.narg('country'))
+    AND (sqlc.narg('job_title')::text IS NULL OR job_title = sqlc.narg('job_title'))
+    AND (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+    AND (sqlc.narg('min_salary')::numeric IS NULL OR salary >= sqlc.narg('min_salary'))
+    AND (sqlc.narg('max_salary')::numeric IS NULL OR salary <= sqlc.narg('max_salary'))
+    AND (
+        sqlc.narg('cursor_id')::int IS NULL
+        OR CASE WHEN @sort_desc::bool THEN
+            CASE @sort_by::text
+                WHEN 'user_id'    THEN (user_id, id)    < (sqlc.narg('cursor_int')::bigint, sqlc.narg('cursor_id'))
+                WHEN 'job_title'  THEN (job_title, id)  < (sqlc.narg('cursor_text')::text, sqlc.narg('cursor_id'))
+                WHEN 'country'    THEN (country, id)    < (sqlc.narg('cursor_text'), sqlc.narg('cursor_id'))
+                WHEN 'salary'     THEN (salary, id)     < (sqlc.narg('cursor_salary')::numeric, sqlc.narg('cursor_id'))
+                WHEN 'created_at' THEN (created_at, id) < (sqlc.narg('cursor_time')::timestamp, sqlc.narg('cursor_id'))
+                ELSE id < sqlc.narg('cursor_id')
+            END
+        ELSE
+            CASE @sort_by::text
+                WHEN 'user_id'    THEN (user_id, id)    > (sqlc.narg('cursor_int'), sqlc.narg('cursor_id'))
+                WHEN 'job_title'  THEN (job_title, id)  > (sqlc.narg('cursor_text'), sqlc.narg('cursor_id'))
+                WHEN 'country'    THEN (country, id)    > (sqlc.narg('cursor_text'), sqlc.narg('cursor_id'))
+                WHEN 'salary'     THEN (salary, id)     > (sqlc.narg('cursor_salary'), sqlc.narg('cursor_id'))
+                WHEN 'created_at' THEN (created_at, id) > (sqlc.narg('cursor_time'), sqlc.narg('cursor_id'))
+                ELSE id > sqlc.narg('cursor_id')
+            END
+        END
+    )
+ORDER BY
+    CASE WHEN @sort_by::text = 'user_id'    AND NOT @sort_desc::bool THEN user_id    END ASC,
+    CASE WHEN @sort_by::text = 'user_id'    AND @sort_desc::bool     THEN user_id    END DESC,
+    CASE WHEN @sort_by::text = 'job_title'  AND NOT @sort_desc::bool THEN job_title  END ASC,
+    CASE WHEN @sort_by::text = 'job_title'  AND @sort_desc::bool     THEN job_title  END DESC,
+    CASE WHEN @sort_by::text = 'country'    AND NOT @sort_desc::bool THEN country    END ASC,
+    CASE WHEN @sort_by::text = 'country'    AND @sort_desc::bool     THEN country    END DESC,
+    CASE WHEN @sort_by::text = 'salary'     AND NOT @sort_desc::bool THEN salary     END ASC,
+    CASE WHEN @sort_by::text = 'salary'     AND @sort_desc::bool     THEN salary     END DESC,
+    CASE WHEN @sort_by::text = 'created_at' AND NOT @sort_desc::bool THEN created_at END ASC,
+    CASE WHEN @sort_by::text = 'created_at' AND @sort_desc::bool     THEN created_at END DESC,
+    CASE WHEN NOT @sort_desc::bool THEN id END ASC,
+    CASE WHEN @sort_desc::bool     THEN id END DESC
+LIMIT @page_size::int;
@@ -0,0 +1,34 @@
+-- name: UpsertFxRate :one
+-- Imports are idempotent, the same pair and day again replaces the rate
+INSERT INTO fx_rates
+(
+    base_currency,
+    quote_currency,
+    rate,
+    rate_date,
+    source
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (base_currency, quote_currency, rate_date)
+DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source
+RETURNING * ;
+
+-- name: ListFxRates :many
+SELECT * FROM fx_rates
+WHERE
+    (sqlc.narg('currency')::text IS NULL OR base_currency = sqlc.narg('currency') OR quote_currency = sqlc.narg('currency'))
+    AND (sqlc.narg('since')::date IS NULL OR rate_date >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::date IS NULL OR rate_date <= sqlc.narg('until'))
+ORDER BY rate_date DESC, base_currency, quote_currency
+LIMIT @page_size::int;
+
+-- name: GetFxRatesAsOf :many
+-- The latest rate of every currency pair not after "as_of"
+SELECT DISTINCT ON (base_currency, quote_currency) *
+FROM fx_rates
+WHERE rate_date <= @as_of::date
+ORDER BY base_currency, quote_currency, rate_date DESC;
+
+-- name: DeleteFxRate :one
+DELETE FROM fx_rates WHERE id = $1 RETURNING *;
@@ -0,0 +1,112 @@
+-- name: CreatePayrollRun :one
+INSERT INTO payroll_runs
+(
+    frequency,
+    period_start,
+    period_end,
+    pay_date,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING * ;
+
+-- name: GetPayrollRun :one
+SELECT * FROM payroll_runs WHERE id = $1;
+
+-- name: GetPayrollRunForUpdate :one
+-- Locks the run until the end of the transaction, while its payslips are replaced
+SELECT * FROM payroll_runs WHERE id = $1 FOR UPDATE;
+
+-- name: ListPayrollRuns :many
+SELECT * FROM payroll_runs
+WHERE sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')
+ORDER BY period_start DESC, id DESC
+LIMIT @page_size::int;
+
+-- name: LockPayrollRun :one
+UPDATE payroll_runs
+SET status = 'locked', locked_by = @locked_by, locked_at = CURRENT_TIMESTAMP
+WHERE id = @id AND status = 'draft'
+RETURNING *;
+
+-- name: ReversePayrollRun :one
+UPDATE payroll_runs
+SET status = 'reversed', reversed_by = @reversed_by, reversed_at = CURRENT_TIMESTAMP, reversal_reason = @reversal_reason
+WHERE id = @id AND status = 'locked'
+RETURNING *;
+
+-- name: DeletePayrollRun :one
+-- Only drafts, their payslips go with them
+DELETE FROM payroll_runs WHERE id = $1 AND status = 'draft' RETURNING *;
+
+-- name: ListPayrollEmployees :many
+-- Every employee with a salary in effect on "as_of", with that salary
+SELECT e.id, e.user_id, e.job_title, e.country, h.salary, h.currency
+FROM employees e
+JOIN LATERAL (
+    SELECT salary, currency
+    FROM salary_history
+    WHERE employee_id = e.id AND effective_from <= @as_of::date
+    ORDER BY effective_from DESC, id DESC
+    LIMIT 1
+) h ON true
+WHERE e.deleted_at IS NULL
+ORDER BY e.id;
+
+-- name: CreatePayslip :one
+INSERT INTO payslips
+(
+    run_id,
+    employee_id,
+    user_id,
+    job_title,
+    country,
+    period_start,
+    period_end,
+    pay_date,
+    tax_regime_id,
+    tax_year,
+    currency,
+    annual_salary,
+    salary_currency,
+    exchange_rate,
+    gross_pay,
+    deductions,
+    income_tax,
+    contributions,
+    total_deductions,
+    net_pay
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+) RETURNING * ;
+
+-- name: DeleteRunPayslips :exec
+-- Clears a draft before it is calculated again
+DELETE FROM payslips WHERE run_id = $1;
+
+-- name: ListRunPayslips :many
+SELECT * FROM payslips
+WHERE run_id = $1
+ORDER BY employee_id;
+
+-- name: ListUserPayslips :many
+-- Payslips of locked and reversed runs, drafts are not issued yet
+SELECT p.* FROM payslips p
+JOIN payroll_runs r ON r.id = p.run_id
+WHERE p.user_id = $1 AND r.status <> 'draft'
+ORDER BY p.period_start DESC, p.id DESC;
+
+-- name: GetUserPayslip :one
+SELECT p.* FROM payslips p
+JOIN payroll_runs r ON r.id = p.run_id
+WHERE p.id = $1 AND p.user_id = $2 AND r.status <> 'draft';
+
+-- name: ListLockedPayslipsForYear :many
+-- Payslips of the locked runs of "tax_year", what year to date totals add up, only those
+-- of "user_id" or of "employee_ids" when set
+SELECT p.* FROM payslips p
+JOIN payroll_runs r ON r.id = p.run_id
+WHERE p.tax_year = @tax_year AND r.status = 'locked'
+    AND (sqlc.narg('user_id')::bigint IS NULL OR p.user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('employee_ids')::int[] IS NULL OR p.employee_id = ANY(sqlc.narg('employee_ids')::int[]))
+ORDER BY p.pay_date, p.id;
@@ -0,0 +1,43 @@
+-- name: ListRoles :many
+SELECT * FROM roles ORDER BY name;
+
+-- name: GetRoleByName :one
+SELECT * FROM roles WHERE name = $1 LIMIT 1;
+
+-- name: ListRolePermissions :many
+SELECT p.name FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+WHERE rp.role_id = $1
+ORDER BY p.name;
+
+-- name: ListUserRoles :many
+SELECT r.name FROM roles r
+JOIN user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = $1
+ORDER BY r.name;
+
+-- name: ListUserPermissions :many
+SELECT DISTINCT p.name FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+JOIN user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name;
+
+-- name: AssignUserRole :execrows
+INSERT INTO user_roles
+(
+    user_id,
+    role_id
+) VALUES (
+    $1, $2
+) ON CONFLICT (user_id, role_id) DO NOTHING;
+
+-- name: RemoveUserRole :execrows
+DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2;
+
+-- name: CountUsersWithRole :one
+-- Deleted users don't count
+SELECT COUNT(*) FROM user_roles ur
+JOIN roles r ON r.id = ur.role_id
+JOIN users u ON u.id = ur.user_id
+WHERE r.name = $1 AND u.deleted_at IS NULL;
@@ -0,0 +1,34 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens
+(
+    jti,
+    user_id,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT (jti) DO NOTHING;
+
+-- name: RevokeUserTokens :exec
+INSERT INTO user_token_cutoffs
+(
+    user_id,
+    not_before,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT (user_id) DO UPDATE
+SET
+    not_before = EXCLUDED.not_before,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: IsTokenRevoked :one
+SELECT (
+    EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = @jti)
+    OR EXISTS (SELECT 1 FROM user_token_cutoffs WHERE user_id = @user_id AND not_before > @issued_at)
+)::boolean AS revoked;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens WHERE expires_at < $1;
+
+-- name: DeleteExpiredUserTokenCutoffs :execrows
+DELETE FROM user_token_cutoffs WHERE expires_at < $1;
@@ -0,0 +1,38 @@
+-- name: CreateSalaryChange :one
+INSERT INTO salary_history
+(
+    employee_id,
+    salary,
+    effective_from,
+    reason,
+    approved_by,
+    note,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING * ;
+
+-- name: ListSalaryHistory :many
+SELECT * FROM salary_history
+WHERE employee_id = $1
+ORDER BY effective_from, id;
+
+-- name: DeleteScheduledSalaryChange :one
+DELETE FROM salary_history
+WHERE id = @id AND employee_id = @employee_id AND effective_from > @as_of::date
+RETURNING *;
+
+-- name: ApplyEffectiveSalaries :many
+UPDATE employees e
+SET salary = h.salary, currency = h.currency, version = e.version + 1
+FROM (
+    SELECT DISTINCT ON (employee_id) employee_id, salary, currency
+    FROM salary_history
+    WHERE effective_from <= @as_of::date
+    ORDER BY employee_id, effective_from DESC, id DESC
+) h
+WHERE h.employee_id = e.id
+    AND e.deleted_at IS NULL
+    AND (e.salary <> h.salary OR e.currency <> h.currency)
+    AND (sqlc.narg('employee_id')::int IS NULL OR e.id = sqlc.narg('employee_id'))
+RETURNING e.*;
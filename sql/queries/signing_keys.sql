@@ -0,0 +1,20 @@
+-- name: CreateSigningKey :one
+INSERT INTO signing_keys
+(
+    kid,
+    algorithm,
+    private_key,
+    public_key,
+    retires_at,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING * ;
+
+-- name: ListSigningKeys :many
+SELECT * FROM signing_keys
+WHERE expires_at > $1
+ORDER BY retires_at DESC;
+
+-- name: DeleteExpiredSigningKeys :execrows
+DELETE FROM signing_keys WHERE expires_at < $1;
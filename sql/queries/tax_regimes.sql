@@ -0,0 +1,40 @@
+-- name: CreateTaxRegime :one
+INSERT INTO tax_regimes
+(
+    country,
+    tax_year,
+    standard_allowance,
+    brackets,
+    contributions,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING * ;
+
+-- name: GetTaxRegimeById :one
+SELECT * FROM tax_regimes WHERE id = $1;
+
+-- name: GetTaxRegimeForYear :one
+-- The regime in force in "tax_year", the latest one not after it
+SELECT * FROM tax_regimes
+WHERE country = $1 AND tax_year <= $2
+ORDER BY tax_year DESC
+LIMIT 1;
+
+-- name: ListTaxRegimes :many
+SELECT * FROM tax_regimes
+WHERE sqlc.narg('country')::text IS NULL OR country = sqlc.narg('country')
+ORDER BY country, tax_year DESC;
+
+-- name: UpdateTaxRegime :one
+UPDATE tax_regimes
+SET
+    standard_allowance = $2,
+    brackets           = $3,
+    contributions      = $4,
+    updated_at         = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTaxRegime :one
+DELETE FROM tax_regimes WHERE id = $1 RETURNING *;
@@ -10,7 +10,45 @@ INSERT INTO users
 ) RETURNING * ;
 
 -- name: GetUserById :one
-SELECT * FROM users WHERE id = $1 LIMIT 1;
+SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByName :one
-SELECT * FROM users WHERE username = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM users WHERE username = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: ListUsersByIDs :many
+SELECT * FROM users WHERE id = ANY(@ids::int[]) AND deleted_at IS NULL ORDER BY id;
+
+-- name: GetDeletedUserById :one
+SELECT * FROM users WHERE id = $1 AND deleted_at IS NOT NULL LIMIT 1;
+
+-- name: DeleteUserById :one
+-- Soft delete, the email stays taken until the retention job
+UPDATE users SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreUserById :one
+-- An anonymized user has nothing left to restore
+UPDATE users SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: PurgeDeletedUsers :many
+-- Deleted before "deleted_before", along with everything that is theirs: employee, bank account,
+-- roles and tokens. Payslips are snapshots and stay.
+DELETE FROM users WHERE deleted_at < @deleted_before::timestamp RETURNING *;
+
+-- name: AnonymizeDeletedUsers :many
+-- Deleted before "deleted_before" and not anonymized yet: nothing of the row tells who it was,
+-- and nobody can log in as it
+UPDATE users
+SET
+    username      = 'deleted user',
+    email         = 'deleted-' || id || '@anonymized.invalid',
+    password_hash = '',
+    anonymized_at = CURRENT_TIMESTAMP
+WHERE deleted_at < @deleted_before::timestamp AND anonymized_at IS NULL
+RETURNING *;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Filters / keyset sorts used by the admin employee list
+CREATE INDEX IF NOT EXISTS idx_employees_country   ON employees (country, id);
+CREATE INDEX IF NOT EXISTS idx_employees_job_title ON employees (job_title, id);
+CREATE INDEX IF NOT EXISTS idx_employees_salary    ON employees (salary, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_employees_salary;
+DROP INDEX IF EXISTS idx_employees_job_title;
+DROP INDEX IF EXISTS idx_employees_country;
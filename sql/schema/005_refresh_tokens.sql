@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id            SERIAL          PRIMARY KEY,
+    user_id       BIGINT          NOT NULL,
+    family_id     VARCHAR(64)     NOT NULL,             -- every rotation of one login shares a family
+    token_hash    VARCHAR(64)     UNIQUE NOT NULL,      -- sha256 of the token, the token itself is never stored
+    expires_at    TIMESTAMP       NOT NULL,
+    revoked_at    TIMESTAMP,
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_refresh_token_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Access tokens revoked one by one ("jti" claim), e.g. on logout
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti           VARCHAR(64)     PRIMARY KEY,
+    user_id       BIGINT          NOT NULL,
+    expires_at    TIMESTAMP       NOT NULL,             -- "exp" of the token, row is purged after it
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_revoked_token_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+-- Every access token of a user issued before "not_before" is revoked ("log out everywhere")
+CREATE TABLE IF NOT EXISTS user_token_cutoffs (
+    user_id       BIGINT          PRIMARY KEY,
+    not_before    TIMESTAMP       NOT NULL,
+    expires_at    TIMESTAMP       NOT NULL,             -- once every covered token expired, row is purged
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_token_cutoff_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_token_cutoffs;
+DROP TABLE IF EXISTS revoked_tokens;
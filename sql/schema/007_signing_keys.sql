@@ -0,0 +1,15 @@
+-- +goose Up
+-- Keys signing the access JWTs, identified by the "kid" header.
+-- A key signs until "retires_at", and still verifies until "expires_at" (the grace window).
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid           VARCHAR(64)     PRIMARY KEY,
+    algorithm     VARCHAR(16)     NOT NULL,             -- "EdDSA" / "RS256"
+    private_key   TEXT            NOT NULL,             -- PKCS#8 PEM
+    public_key    TEXT            NOT NULL,             -- PKIX PEM, published at "/.well-known/jwks.json"
+    retires_at    TIMESTAMP       NOT NULL,
+    expires_at    TIMESTAMP       NOT NULL,
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS signing_keys;
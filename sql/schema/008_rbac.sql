@@ -0,0 +1,110 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS roles (
+    id            SERIAL          PRIMARY KEY,
+    name          VARCHAR(50)     UNIQUE NOT NULL,
+    description   TEXT            NOT NULL DEFAULT '',
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Permissions are "<resource>:<action>", checked by name in the routes
+CREATE TABLE IF NOT EXISTS permissions (
+    id            SERIAL          PRIMARY KEY,
+    name          VARCHAR(100)    UNIQUE NOT NULL,
+    description   TEXT            NOT NULL DEFAULT '',
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       INT             NOT NULL,
+    permission_id INT             NOT NULL,
+
+    PRIMARY KEY (role_id, permission_id),
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_role_permission_role
+        FOREIGN KEY (role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_role_permission_permission
+        FOREIGN KEY (permission_id)
+        REFERENCES permissions(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id       BIGINT          NOT NULL,
+    role_id       INT             NOT NULL,
+    created_at    TIMESTAMP       DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, role_id),
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_user_role_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_user_role_role
+        FOREIGN KEY (role_id)
+        REFERENCES roles(id)
+        ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('employee',   'Self service on the own employee record'),
+    ('hr',         'Manages employee records, no salary analytics'),
+    ('payroll',    'Reads employee records and salary analytics'),
+    ('admin',      'Everything but handing out the superadmin role'),
+    ('superadmin', 'Everything');
+
+INSERT INTO permissions (name, description) VALUES
+    ('employees:read',  'View any employee'),
+    ('employees:write', 'Update or delete any employee'),
+    ('salaries:read',   'Salary metrics and averages'),
+    ('tokens:revoke',   'Log any user out everywhere'),
+    ('roles:read',      'View roles and who holds them'),
+    ('roles:write',     'Assign and remove roles'),
+    ('admins:write',    'Assign and remove the superadmin role');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (
+    (r.name = 'hr'         AND p.name IN ('employees:read', 'employees:write'))
+ OR (r.name = 'payroll'    AND p.name IN ('employees:read', 'salaries:read'))
+ OR (r.name = 'admin'      AND p.name <> 'admins:write')
+ OR (r.name = 'superadmin')
+);
+
+-- Every existing user is an employee, the old admins keep their access
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE r.name = 'employee';
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT a.user_id, r.id FROM adminUsers a, roles r WHERE r.name = 'admin';
+
+DROP TABLE IF EXISTS adminUsers;
+
+-- +goose Down
+CREATE TABLE IF NOT EXISTS adminUsers (
+    id            SERIAL          PRIMARY KEY,
+    user_id       BIGINT          UNIQUE NOT NULL,      -- each user → at most one employee
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_employee_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE RESTRICT
+        ON UPDATE CASCADE
+);
+
+INSERT INTO adminUsers (user_id)
+SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+WHERE r.name IN ('admin', 'superadmin')
+GROUP BY ur.user_id;
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Handing out "roles:write" (admin, superadmin) is reserved to superadmins
+UPDATE permissions SET description = 'Assign and remove the admin and superadmin roles' WHERE name = 'admins:write';
+UPDATE roles SET description = 'Everything but handing out the admin role' WHERE name = 'admin';
+
+-- +goose Down
+UPDATE permissions SET description = 'Assign and remove the superadmin role' WHERE name = 'admins:write';
+UPDATE roles SET description = 'Everything but handing out the superadmin role' WHERE name = 'admin';
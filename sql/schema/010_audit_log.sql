@@ -0,0 +1,47 @@
+-- +goose Up
+-- Append-only, every entry carries the hash of the previous one,
+-- editing or deleting a row breaks the chain from there on ("cmd/auditverify")
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            BIGSERIAL       PRIMARY KEY,
+    actor_id      BIGINT,                               -- NULL for the system, no FK: entries outlive users
+    action        VARCHAR(100)    NOT NULL,             -- e.g. "employee.update"
+    target_type   VARCHAR(50)     NOT NULL,
+    target_id     VARCHAR(64)     NOT NULL,
+    diff          JSON            NOT NULL,             -- JSON (not JSONB) keeps the hashed bytes as is
+    request_id    VARCHAR(64)     NOT NULL DEFAULT '',
+    ip            VARCHAR(64)     NOT NULL DEFAULT '',
+    created_at    TIMESTAMP       NOT NULL,
+    prev_hash     VARCHAR(64)     UNIQUE NOT NULL,      -- UNIQUE: two writers can't fork the chain
+    hash          VARCHAR(64)     UNIQUE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id, id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Query the audit log');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('admin', 'superadmin') AND p.name = 'audit:read';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'audit:read';
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,49 @@
+-- +goose Up
+-- Every salary an employee ever had or is scheduled to get, "employees.salary" is a copy
+-- of the latest entry already in effect (kept in sync by the app, see ApplyEffectiveSalaries)
+CREATE TABLE IF NOT EXISTS salary_history (
+    id              BIGSERIAL       PRIMARY KEY,
+    employee_id     INT             NOT NULL,
+
+    salary          DECIMAL(12,2)   NOT NULL,
+    effective_from  DATE            NOT NULL,
+    reason          VARCHAR(30)     NOT NULL,
+    approved_by     BIGINT,                             -- NULL once the approver is gone
+    note            TEXT            NOT NULL DEFAULT '',
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_salary_history_reason CHECK (reason IN (
+        'hire', 'promotion', 'merit', 'market', 'cost_of_living', 'adjustment', 'correction', 'demotion'
+    )),
+
+    -- Constraint for "Foreign Key"
+    CONSTRAINT fk_salary_history_employee
+        FOREIGN KEY (employee_id)
+        REFERENCES employees(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_salary_history_approver
+        FOREIGN KEY (approved_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_salary_history_employee ON salary_history (employee_id, effective_from, id);
+CREATE INDEX IF NOT EXISTS idx_salary_history_effective ON salary_history (effective_from);
+
+-- The salary every employee has today is where their history starts
+INSERT INTO salary_history (employee_id, salary, effective_from, reason, note)
+SELECT id, salary, COALESCE(created_at, CURRENT_TIMESTAMP)::date, 'hire', 'migrated from employees.salary'
+FROM employees;
+
+INSERT INTO permissions (name, description) VALUES
+    ('salaries:write', 'Record and schedule salary changes');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('payroll', 'admin', 'superadmin') AND p.name = 'salaries:write';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'salaries:write';
+
+DROP TABLE IF EXISTS salary_history;
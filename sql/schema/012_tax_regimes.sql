@@ -0,0 +1,45 @@
+-- +goose Up
+-- Income tax and contribution rules of a country, one row per tax year.
+-- A tax year without a row of its own uses the latest earlier one.
+CREATE TABLE IF NOT EXISTS tax_regimes (
+    id                  SERIAL          PRIMARY KEY,
+    country             VARCHAR(100)    NOT NULL,       -- lowercase, matched against employees.country
+    tax_year            INT             NOT NULL,
+    standard_allowance  DECIMAL(12,2)   NOT NULL DEFAULT 0,
+    brackets            JSONB           NOT NULL,       -- [{"from": 0, "up_to": 10000, "rate": 10}, ...], "up_to" null on the last one
+    contributions       JSONB           NOT NULL DEFAULT '[]', -- [{"name": "pension", "rate": 9.3, "cap": 90000, "deductible": true}, ...]
+    created_at          TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (country, tax_year)
+);
+
+-- Replaces the flat "<country>=<percent>" env variables
+INSERT INTO tax_regimes (country, tax_year, standard_allowance, brackets, contributions) VALUES
+    ('india', 2025, 75000,
+     '[{"from": 0, "up_to": 400000, "rate": 0}, {"from": 400000, "up_to": 800000, "rate": 5},
+       {"from": 800000, "up_to": 1200000, "rate": 10}, {"from": 1200000, "up_to": 1600000, "rate": 15},
+       {"from": 1600000, "up_to": 2000000, "rate": 20}, {"from": 2000000, "up_to": 2400000, "rate": 25},
+       {"from": 2400000, "up_to": null, "rate": 30}]',
+     '[{"name": "provident_fund", "rate": 12, "cap": 180000, "deductible": false}]'),
+    ('usa', 2025, 15000,
+     '[{"from": 0, "up_to": 11925, "rate": 10}, {"from": 11925, "up_to": 48475, "rate": 12},
+       {"from": 48475, "up_to": 103350, "rate": 22}, {"from": 103350, "up_to": 197300, "rate": 24},
+       {"from": 197300, "up_to": 250525, "rate": 32}, {"from": 250525, "up_to": 626350, "rate": 35},
+       {"from": 626350, "up_to": null, "rate": 37}]',
+     '[{"name": "social_security", "rate": 6.2, "cap": 176100, "deductible": false},
+       {"name": "medicare", "rate": 1.45, "cap": null, "deductible": false}]');
+
+INSERT INTO permissions (name, description) VALUES
+    ('taxes:read',  'View the tax regimes'),
+    ('taxes:write', 'Create, update and delete tax regimes');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('payroll', 'admin', 'superadmin') AND p.name IN ('taxes:read', 'taxes:write');
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('taxes:read', 'taxes:write');
+
+DROP TABLE IF EXISTS tax_regimes;
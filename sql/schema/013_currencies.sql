@@ -0,0 +1,98 @@
+-- +goose Up
+-- Every salary carries its ISO 4217 currency, so do the amounts of a tax regime
+ALTER TABLE employees      ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE salary_history ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE tax_regimes    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Salaries so far were in the currency of the country they were taxed in,
+-- the country is a name or an ISO 3166-1 alpha-2 code in any case
+CREATE TEMPORARY TABLE country_currencies (name TEXT PRIMARY KEY, currency CHAR(3) NOT NULL);
+INSERT INTO country_currencies (name, currency) VALUES
+    ('india', 'INR'), ('bharat', 'INR'), ('in', 'INR'),
+    ('usa', 'USD'), ('us', 'USD'), ('united states', 'USD'), ('united states of america', 'USD'), ('america', 'USD'),
+    ('uk', 'GBP'), ('gb', 'GBP'), ('united kingdom', 'GBP'), ('great britain', 'GBP'), ('england', 'GBP'),
+    ('germany', 'EUR'), ('de', 'EUR'), ('france', 'EUR'), ('fr', 'EUR'), ('spain', 'EUR'), ('es', 'EUR'),
+    ('italy', 'EUR'), ('it', 'EUR'), ('netherlands', 'EUR'), ('nl', 'EUR'), ('ireland', 'EUR'), ('ie', 'EUR'),
+    ('portugal', 'EUR'), ('pt', 'EUR'), ('belgium', 'EUR'), ('be', 'EUR'), ('austria', 'EUR'), ('at', 'EUR'),
+    ('finland', 'EUR'), ('fi', 'EUR'),
+    ('switzerland', 'CHF'), ('ch', 'CHF'), ('sweden', 'SEK'), ('se', 'SEK'), ('norway', 'NOK'), ('no', 'NOK'),
+    ('denmark', 'DKK'), ('dk', 'DKK'), ('poland', 'PLN'), ('pl', 'PLN'),
+    ('canada', 'CAD'), ('ca', 'CAD'), ('mexico', 'MXN'), ('mx', 'MXN'), ('brazil', 'BRL'), ('br', 'BRL'),
+    ('argentina', 'ARS'), ('ar', 'ARS'), ('australia', 'AUD'), ('au', 'AUD'), ('new zealand', 'NZD'), ('nz', 'NZD'),
+    ('japan', 'JPY'), ('jp', 'JPY'), ('china', 'CNY'), ('cn', 'CNY'), ('singapore', 'SGD'), ('sg', 'SGD'),
+    ('south africa', 'ZAR'), ('za', 'ZAR'), ('nigeria', 'NGN'), ('ng', 'NGN'), ('kenya', 'KES'), ('ke', 'KES'),
+    ('uae', 'AED'), ('united arab emirates', 'AED'), ('ae', 'AED');
+
+UPDATE employees e SET currency = c.currency FROM country_currencies c WHERE LOWER(TRIM(e.country)) = c.name;
+UPDATE tax_regimes t SET currency = c.currency FROM country_currencies c WHERE LOWER(TRIM(t.country)) = c.name;
+UPDATE salary_history h SET currency = e.currency FROM employees e WHERE e.id = h.employee_id;
+
+-- A salary is never guessed to be in USD, the countries above have to cover every row
+-- +goose StatementBegin
+DO $$
+DECLARE
+    unmapped TEXT;
+BEGIN
+    SELECT string_agg(DISTINCT quote_literal(country), ', ') INTO unmapped
+    FROM (
+        SELECT country FROM employees
+        UNION ALL
+        SELECT country FROM tax_regimes
+    ) c
+    WHERE LOWER(TRIM(country)) NOT IN (SELECT name FROM country_currencies);
+    IF unmapped IS NOT NULL THEN
+        RAISE EXCEPTION 'no currency for the countries %', unmapped
+            USING HINT = 'rename them to a country listed in 013_currencies.sql or add it there, then migrate again';
+    END IF;
+END;
+$$;
+-- +goose StatementEnd
+
+DROP TABLE country_currencies;
+
+ALTER TABLE employees      ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE salary_history ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE tax_regimes    ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE employees      ADD CONSTRAINT chk_employees_currency      CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE salary_history ADD CONSTRAINT chk_salary_history_currency CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE tax_regimes    ADD CONSTRAINT chk_tax_regimes_currency    CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE INDEX IF NOT EXISTS idx_employees_currency ON employees (currency, id);
+
+-- Exchange rates by day, 1 "base_currency" = "rate" "quote_currency".
+-- An amount is converted at the latest rate not after the day asked for.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id              BIGSERIAL       PRIMARY KEY,
+    base_currency   CHAR(3)         NOT NULL,
+    quote_currency  CHAR(3)         NOT NULL,
+    rate            DECIMAL(20,10)  NOT NULL,
+    rate_date       DATE            NOT NULL,
+    source          VARCHAR(20)     NOT NULL DEFAULT 'manual',  -- "manual", "csv" or "ecb"
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_fx_rates_rate CHECK (rate > 0),
+    CONSTRAINT chk_fx_rates_pair CHECK (base_currency <> quote_currency),
+    CONSTRAINT fx_rates_pair_date_key UNIQUE (base_currency, quote_currency, rate_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fx_rates_date ON fx_rates (rate_date);
+
+INSERT INTO permissions (name, description) VALUES
+    ('fx:read',  'View the exchange rates'),
+    ('fx:write', 'Add, import and delete exchange rates');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('payroll', 'admin', 'superadmin') AND p.name IN ('fx:read', 'fx:write');
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('fx:read', 'fx:write');
+
+DROP TABLE IF EXISTS fx_rates;
+
+DROP INDEX IF EXISTS idx_employees_currency;
+ALTER TABLE tax_regimes    DROP COLUMN IF EXISTS currency;
+ALTER TABLE salary_history DROP COLUMN IF EXISTS currency;
+ALTER TABLE employees      DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Amounts are rounded to the minor unit of their currency by the app, 4 decimals hold
+-- every ISO 4217 minor unit (JPY 0, USD 2, KWD 3, CLF 4) without Postgres rounding them again
+ALTER TABLE employees      ALTER COLUMN salary             TYPE DECIMAL(19,4);
+ALTER TABLE salary_history ALTER COLUMN salary             TYPE DECIMAL(19,4);
+ALTER TABLE tax_regimes    ALTER COLUMN standard_allowance TYPE DECIMAL(19,4);
+
+-- +goose Down
+ALTER TABLE tax_regimes    ALTER COLUMN standard_allowance TYPE DECIMAL(12,2);
+ALTER TABLE salary_history ALTER COLUMN salary             TYPE DECIMAL(12,2);
+ALTER TABLE employees      ALTER COLUMN salary             TYPE DECIMAL(12,2);
@@ -0,0 +1,109 @@
+-- +goose Up
+-- A payroll run pays every employee with a salary for one period. It is a draft until
+-- locked, the payslips of a locked run never change again, a mistake is reversed
+-- (kept for the record) and the period run again.
+CREATE TABLE IF NOT EXISTS payroll_runs (
+    id               BIGSERIAL      PRIMARY KEY,
+    frequency        VARCHAR(20)    NOT NULL,                   -- "monthly" or "biweekly"
+    period_start     DATE           NOT NULL,
+    period_end       DATE           NOT NULL,
+    pay_date         DATE           NOT NULL,
+    status           VARCHAR(20)    NOT NULL DEFAULT 'draft',   -- "draft", "locked" or "reversed"
+    created_by       BIGINT,
+    locked_by        BIGINT,
+    locked_at        TIMESTAMP,
+    reversed_by      BIGINT,
+    reversed_at      TIMESTAMP,
+    reversal_reason  TEXT           NOT NULL DEFAULT '',
+    created_at       TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_payroll_runs_frequency CHECK (frequency IN ('monthly', 'biweekly')),
+    CONSTRAINT chk_payroll_runs_status    CHECK (status IN ('draft', 'locked', 'reversed')),
+    CONSTRAINT chk_payroll_runs_period    CHECK (period_start <= period_end),
+    CONSTRAINT fk_payroll_runs_creator  FOREIGN KEY (created_by)  REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_payroll_runs_locker   FOREIGN KEY (locked_by)   REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_payroll_runs_reverser FOREIGN KEY (reversed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- A day is paid once whatever the frequency: the periods of the runs not reversed never overlap,
+-- a reversed run frees its period for the run that corrects it
+ALTER TABLE payroll_runs ADD CONSTRAINT payroll_runs_period_overlap
+    EXCLUDE USING gist (daterange(period_start, period_end, '[]') WITH &&) WHERE (status <> 'reversed');
+
+-- What an employee was paid for a run, a snapshot: nothing links back to the salary,
+-- the tax regime or the employee, payslips outlive all of them
+CREATE TABLE IF NOT EXISTS payslips (
+    id                BIGSERIAL       PRIMARY KEY,
+    run_id            BIGINT          NOT NULL,
+    employee_id       INT             NOT NULL,
+    user_id           BIGINT          NOT NULL,
+    job_title         VARCHAR(100)    NOT NULL,
+    country           VARCHAR(50)     NOT NULL,
+    period_start      DATE            NOT NULL,
+    period_end        DATE            NOT NULL,
+    pay_date          DATE            NOT NULL,
+    tax_regime_id     INT             NOT NULL,
+    tax_year          INT             NOT NULL,
+    currency          CHAR(3)         NOT NULL,                 -- of the tax regime, every amount below is in it
+    annual_salary     DECIMAL(19,4)   NOT NULL,                 -- in "salary_currency"
+    salary_currency   CHAR(3)         NOT NULL,
+    exchange_rate     DECIMAL(20,10)  NOT NULL,                 -- 1 "salary_currency" = "exchange_rate" "currency"
+    gross_pay         DECIMAL(19,4)   NOT NULL,
+    deductions        JSONB           NOT NULL DEFAULT '[]',
+    income_tax        DECIMAL(19,4)   NOT NULL,
+    contributions     DECIMAL(19,4)   NOT NULL,
+    total_deductions  DECIMAL(19,4)   NOT NULL,
+    net_pay           DECIMAL(19,4)   NOT NULL,
+    created_at        TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT payslips_run_employee_key    UNIQUE (run_id, employee_id),
+    CONSTRAINT chk_payslips_currency        CHECK (currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT chk_payslips_salary_currency CHECK (salary_currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT fk_payslips_run FOREIGN KEY (run_id) REFERENCES payroll_runs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payslips_user ON payslips (user_id, period_start);
+
+-- Payslips are only written while their run is a draft, and never updated
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION payslips_frozen() RETURNS trigger AS $$
+DECLARE
+    run_status VARCHAR(20);
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        RAISE EXCEPTION 'payslips are immutable';
+    END IF;
+    -- Gone already when the DELETE cascades from its run
+    SELECT status INTO run_status FROM payroll_runs
+    WHERE id = CASE WHEN TG_OP = 'INSERT' THEN NEW.run_id ELSE OLD.run_id END
+    FOR SHARE;  -- waits for a lock or reversal of the run in flight
+    IF run_status IS NOT NULL AND run_status <> 'draft' THEN
+        RAISE EXCEPTION 'payroll run is %, its payslips can no longer change', run_status;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER payslips_frozen
+    BEFORE INSERT OR UPDATE OR DELETE ON payslips
+    FOR EACH ROW EXECUTE FUNCTION payslips_frozen();
+
+INSERT INTO permissions (name, description) VALUES
+    ('payroll:read',  'View payroll runs and every payslip'),
+    ('payroll:write', 'Run, lock, reverse and delete payroll runs');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('payroll', 'admin', 'superadmin') AND p.name IN ('payroll:read', 'payroll:write');
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('payroll:read', 'payroll:write');
+
+DROP TABLE IF EXISTS payslips;
+DROP FUNCTION IF EXISTS payslips_frozen();
+DROP TABLE IF EXISTS payroll_runs;
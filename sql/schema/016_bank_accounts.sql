@@ -0,0 +1,28 @@
+-- +goose Up
+-- Where an employee's net pay goes: an IBAN (and BIC) for SEPA credit transfers or a US
+-- routing and account number for ACH. The checksums are verified by the app.
+CREATE TABLE IF NOT EXISTS bank_accounts (
+    id              BIGSERIAL      PRIMARY KEY,
+    user_id         BIGINT         NOT NULL,
+    scheme          VARCHAR(10)    NOT NULL,                -- "sepa" or "ach"
+    holder_name     VARCHAR(70)    NOT NULL,
+    iban            VARCHAR(34)    NOT NULL DEFAULT '',     -- sepa
+    bic             VARCHAR(11)    NOT NULL DEFAULT '',     -- sepa, optional
+    routing_number  VARCHAR(9)     NOT NULL DEFAULT '',     -- ach
+    account_number  VARCHAR(17)    NOT NULL DEFAULT '',     -- ach
+    account_type    VARCHAR(10)    NOT NULL DEFAULT '',     -- ach, "checking" or "savings"
+    created_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT bank_accounts_user_key UNIQUE (user_id),
+    CONSTRAINT chk_bank_accounts_scheme CHECK (
+        (scheme = 'sepa' AND iban ~ '^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$'
+            AND routing_number = '' AND account_number = '' AND account_type = '')
+        OR (scheme = 'ach' AND iban = '' AND bic = '' AND routing_number ~ '^[0-9]{9}$'
+            AND account_number ~ '^[0-9]{4,17}$' AND account_type IN ('checking', 'savings'))
+    ),
+    CONSTRAINT fk_bank_accounts_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS bank_accounts;
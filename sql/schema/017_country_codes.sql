@@ -0,0 +1,56 @@
+-- +goose Up
+-- Countries are ISO 3166-1 alpha-2 codes, upper case, the app refuses anything else.
+-- The names written so far are mapped to their codes, the codes are upper cased.
+CREATE TEMPORARY TABLE country_names (name TEXT PRIMARY KEY, code CHAR(2) NOT NULL);
+INSERT INTO country_names (name, code) VALUES
+    ('india', 'IN'), ('bharat', 'IN'),
+    ('usa', 'US'), ('us', 'US'), ('united states', 'US'), ('united states of america', 'US'), ('america', 'US'),
+    ('uk', 'GB'), ('united kingdom', 'GB'), ('great britain', 'GB'), ('england', 'GB'),
+    ('germany', 'DE'), ('france', 'FR'), ('spain', 'ES'), ('italy', 'IT'), ('netherlands', 'NL'),
+    ('ireland', 'IE'), ('portugal', 'PT'), ('belgium', 'BE'), ('austria', 'AT'), ('switzerland', 'CH'),
+    ('sweden', 'SE'), ('norway', 'NO'), ('denmark', 'DK'), ('finland', 'FI'), ('poland', 'PL'),
+    ('canada', 'CA'), ('mexico', 'MX'), ('brazil', 'BR'), ('argentina', 'AR'),
+    ('australia', 'AU'), ('new zealand', 'NZ'), ('japan', 'JP'), ('china', 'CN'), ('singapore', 'SG'),
+    ('south africa', 'ZA'), ('nigeria', 'NG'), ('kenya', 'KE'), ('uae', 'AE'), ('united arab emirates', 'AE');
+
+UPDATE employees e SET country = n.code FROM country_names n WHERE LOWER(TRIM(e.country)) = n.name;
+UPDATE employees SET country = UPPER(TRIM(country)) WHERE LENGTH(TRIM(country)) = 2;
+
+UPDATE tax_regimes t SET country = n.code FROM country_names n WHERE LOWER(TRIM(t.country)) = n.name;
+UPDATE tax_regimes SET country = UPPER(TRIM(country)) WHERE LENGTH(TRIM(country)) = 2;
+
+DROP TABLE country_names;
+
+-- A row left with a name would fail every later UPDATE of it (salary changes, soft delete),
+-- the migration stops on them instead of leaving them behind
+-- +goose StatementBegin
+DO $$
+DECLARE
+    unmatched TEXT;
+BEGIN
+    SELECT string_agg(DISTINCT quote_literal(country), ', ') INTO unmatched
+    FROM (
+        SELECT country FROM employees
+        UNION ALL
+        SELECT country FROM tax_regimes
+    ) c
+    WHERE country !~ '^[A-Z]{2}$';
+    IF unmatched IS NOT NULL THEN
+        RAISE EXCEPTION 'no country code for %', unmatched
+            USING HINT = 'set them to ISO 3166-1 alpha-2 codes or add the names to 017_country_codes.sql, then migrate again';
+    END IF;
+END;
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE employees   ADD CONSTRAINT chk_employees_country   CHECK (country ~ '^[A-Z]{2}$');
+ALTER TABLE tax_regimes ADD CONSTRAINT chk_tax_regimes_country CHECK (country ~ '^[A-Z]{2}$');
+
+-- +goose Down
+-- The codes stay, "tax_regimes" is keyed in lower case again
+ALTER TABLE tax_regimes DROP CONSTRAINT IF EXISTS chk_tax_regimes_country;
+ALTER TABLE employees   DROP CONSTRAINT IF EXISTS chk_employees_country;
+
+UPDATE tax_regimes SET country = 'india' WHERE country = 'IN';
+UPDATE tax_regimes SET country = 'usa' WHERE country = 'US';
+UPDATE tax_regimes SET country = LOWER(country);
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Optimistic concurrency: every change of a row bumps its version, answered as the ETag of the
+-- employee. An update or delete names the version it read and misses the row once another one
+-- went through first.
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE employees ADD CONSTRAINT chk_employees_version CHECK (version > 0);
+
+-- +goose Down
+ALTER TABLE employees DROP COLUMN IF EXISTS version;
@@ -0,0 +1,52 @@
+-- +goose Up
+-- Deleting a user or an employee only stamps "deleted_at": the row is left out of every query
+-- but can be restored, until the retention job purges or anonymizes it RETENTION_DAYS later.
+ALTER TABLE users     ADD COLUMN IF NOT EXISTS deleted_at    TIMESTAMP;
+ALTER TABLE users     ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;  -- set by the retention job, nothing to restore any more
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS deleted_at    TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted     ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_employees_deleted ON employees (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A user may start a new employee profile once the old one is deleted, one of them is live at most
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_user_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS employees_user_id_key ON employees (user_id) WHERE deleted_at IS NULL;
+
+-- Only the retention purge removes users, their employees go with them
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS fk_employee_user;
+ALTER TABLE employees ADD CONSTRAINT fk_employee_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:write', 'Delete and restore users');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('admin', 'superadmin') AND p.name = 'users:write';
+
+-- +goose Down
+-- Without "deleted_at" a deleted row would be live again, they are dropped instead
+DELETE FROM permissions WHERE name = 'users:write';
+
+DELETE FROM employees WHERE deleted_at IS NOT NULL;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS fk_employee_user;
+ALTER TABLE employees ADD CONSTRAINT fk_employee_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE RESTRICT
+    ON UPDATE CASCADE;
+
+DROP INDEX IF EXISTS employees_user_id_key;
+ALTER TABLE employees ADD CONSTRAINT employees_user_id_key UNIQUE (user_id);
+
+DROP INDEX IF EXISTS idx_employees_deleted;
+DROP INDEX IF EXISTS idx_users_deleted;
+
+ALTER TABLE employees DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users     DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE users     DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,264 @@
+package staff
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"server/http/helper"
+	"server/sql/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// dryRunHash stands in for the password hashes of a dry run, nothing logs in with it
+const dryRunHash = "dry-run"
+
+// Options of an Import
+type Options struct {
+	DryRun bool
+	// ApprovedBy approves the starting salaries, nil when the system does
+	ApprovedBy *int64
+}
+
+// Imported is an employee created by an import
+type Imported struct {
+	Line       int    `json:"line"`
+	UserID     int32  `json:"user_id,omitempty"` // left out on a dry run, the ids aren't taken
+	EmployeeID int32  `json:"employee_id,omitempty"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Password   string `json:"password,omitempty"` // generated for a row without one, shown only here
+}
+
+// Result of an import, nothing was written when it has errors or is a dry run
+type Result struct {
+	DryRun   bool       `json:"dry_run"`
+	Rows     int        `json:"rows"`
+	Imported []Imported `json:"imported"`
+	Errors   []RowError `json:"errors"`
+}
+
+// Import creates a user (with the default role) and an employee hired today for every row,
+// in a single transaction: every row is imported or none is. "invalid" are the lines ParseCSV
+// refused, the rows are checked against each other and the existing users on top of them and
+// every line failing, or the first row failing to be written, is in the errors of the result.
+// A dry run writes every row and rolls them back.
+// The error is for anything else, e.g. the database being down.
+func Import(ctx context.Context, db database.Transactor, rows []Row, invalid []RowError, opts Options) (Result, error) {
+	result := Result{DryRun: opts.DryRun, Rows: len(rows), Imported: []Imported{}}
+	result.Errors = append(append([]RowError{}, invalid...), checkDuplicates(rows)...)
+	lines := make(map[int]bool)
+	for _, e := range invalid {
+		lines[e.Line] = true
+	}
+	result.Rows += len(lines)
+
+	for _, row := range rows {
+		errs, err := checkExisting(ctx, db, row)
+		if err != nil {
+			return result, err
+		}
+		result.Errors = append(result.Errors, errs...)
+	}
+	if len(result.Errors) > 0 {
+		sortErrors(result.Errors)
+		return result, nil
+	}
+
+	passwords := make([]string, len(rows))
+	hashes := make([]string, len(rows))
+	for i, row := range rows {
+		passwords[i] = row.Password
+		if row.Password == "" && !opts.DryRun {
+			passwords[i] = rand.Text()
+		}
+		hashes[i] = dryRunHash
+	}
+	if !opts.DryRun {
+		var err error
+		if hashes, err = hashPasswords(ctx, passwords); err != nil {
+			return result, err
+		}
+	}
+
+	var imported []Imported
+	err := db.InTx(ctx, func(q database.Querier) error {
+		imported = imported[:0]
+		for i, row := range rows {
+			created, err := create(ctx, q, row, hashes[i], opts.ApprovedBy)
+			if err != nil {
+				return rowError(row, err)
+			}
+			if row.Password == "" {
+				created.Password = passwords[i]
+			}
+			imported = append(imported, created)
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	var failed RowError
+	switch {
+	case errors.As(err, &failed):
+		result.Errors = []RowError{failed}
+		return result, nil
+	case errors.Is(err, errDryRun):
+		for i := range imported {
+			imported[i].UserID, imported[i].EmployeeID = 0, 0
+		}
+	case err != nil:
+		return result, err
+	}
+	result.Imported = imported
+	return result, nil
+}
+
+// create writes the user, its role, the employee and the start of the salary history of a row
+func create(ctx context.Context, q database.Querier, row Row, hash string, approvedBy *int64) (Imported, error) {
+	user, err := q.CreateUser(ctx, database.CreateUserParams{
+		Username:     row.Username,
+		Email:        row.Email,
+		PasswordHash: hash,
+		CreatedAt:    pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		return Imported{}, err
+	}
+	if err := helper.AssignRole(ctx, q, int64(user.ID), helper.DefaultRole); err != nil {
+		return Imported{}, err
+	}
+
+	emp, err := q.CreateEmployee(ctx, database.CreateEmployeeParams{
+		UserID:   int64(user.ID),
+		JobTitle: row.JobTitle,
+		Country:  row.Country,
+		Salary:   row.Salary.Numeric(),
+		Currency: row.Currency,
+	})
+	if err != nil {
+		return Imported{}, err
+	}
+
+	// The starting salary opens the salary history
+	_, err = helper.RecordSalaryChange(ctx, q, database.CreateSalaryChangeParams{
+		EmployeeID:    emp.ID,
+		Salary:        emp.Salary,
+		Currency:      emp.Currency,
+		EffectiveFrom: helper.Today(),
+		Reason:        "hire",
+		ApprovedBy:    approvedBy,
+	})
+	if err != nil {
+		return Imported{}, err
+	}
+
+	return Imported{Line: row.Line, UserID: user.ID, EmployeeID: emp.ID, Username: user.Username, Email: user.Email}, nil
+}
+
+// rowError blames the row for a constraint or a value the database refused,
+// any other error is returned as is
+func rowError(row Row, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch {
+	case pgErr.ConstraintName == "users_email_key":
+		return RowError{Line: row.Line, Column: "email", Message: "already taken"}
+	// Class 22 is bad data, 23 a broken constraint
+	case strings.HasPrefix(pgErr.Code, "22"), strings.HasPrefix(pgErr.Code, "23"):
+		return RowError{Line: row.Line, Message: pgErr.Message}
+	}
+	return err
+}
+
+// checkDuplicates finds the usernames and emails used by more than one row,
+// emails are compared regardless of case
+func checkDuplicates(rows []Row) []RowError {
+	var errs []RowError
+	usernames := make(map[string]int, len(rows))
+	emails := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if first, ok := usernames[row.Username]; ok {
+			errs = append(errs, RowError{Line: row.Line, Column: "username", Message: fmt.Sprintf("same as line %d", first)})
+		} else {
+			usernames[row.Username] = row.Line
+		}
+
+		email := strings.ToLower(row.Email)
+		if first, ok := emails[email]; ok {
+			errs = append(errs, RowError{Line: row.Line, Column: "email", Message: fmt.Sprintf("same as line %d", first)})
+		} else {
+			emails[email] = row.Line
+		}
+	}
+	return errs
+}
+
+// checkExisting finds the username or email of a row already held by a user
+func checkExisting(ctx context.Context, q database.Querier, row Row) ([]RowError, error) {
+	var errs []RowError
+	_, err := q.GetUserByName(ctx, row.Username)
+	switch {
+	case err == nil:
+		errs = append(errs, RowError{Line: row.Line, Column: "username", Message: "already taken"})
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	_, err = q.GetUserByEmail(ctx, row.Email)
+	switch {
+	case err == nil:
+		errs = append(errs, RowError{Line: row.Line, Column: "email", Message: "already taken"})
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+	return errs, nil
+}
+
+// hashPasswords runs bcrypt on every CPU, at its cost a password takes about a second
+func hashPasswords(ctx context.Context, passwords []string) ([]string, error) {
+	hashes := make([]string, len(passwords))
+	errs := make([]error, len(passwords))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, password := range passwords {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Go(func() {
+			defer func() { <-slots }()
+			hashes[i], errs[i] = helper.HashPassword(password)
+		})
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// sortErrors orders the errors by line, the errors of a line keep their order
+func sortErrors(errs []RowError) {
+	slices.SortStableFunc(errs, func(a, b RowError) int { return a.Line - b.Line })
+}
@@ -0,0 +1,100 @@
+package staff
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"server/money"
+	"server/sql/database"
+)
+
+// exportColumns is the header of an export, it imports again as is (the ids and "created_at"
+// are ignored then)
+var exportColumns = []string{"id", "user_id", "username", "email", "job_title", "country", "salary", "currency", "created_at"}
+
+// salaryColumns are left out of an export without the salaries
+var salaryColumns = []string{"salary", "currency"}
+
+// Exporter writes employees as CSV, a page at a time so an export never holds every employee
+type Exporter struct {
+	csv      *csv.Writer
+	queries  database.Querier
+	salaries bool
+}
+
+// NewExporter writes the header, the users of the employees are looked up with "queries".
+// Without "salaries" the salary and currency columns are left out, the file doesn't import then.
+func NewExporter(w io.Writer, queries database.Querier, salaries bool) (*Exporter, error) {
+	e := &Exporter{csv: csv.NewWriter(w), queries: queries, salaries: salaries}
+	if err := e.csv.Write(e.line(exportColumns)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// line drops the salary columns of a full line when the export has none
+func (e *Exporter) line(fields []string) []string {
+	if e.salaries {
+		return fields
+	}
+	kept := make([]string, 0, len(fields)-len(salaryColumns))
+	for i, field := range fields {
+		if !slices.Contains(salaryColumns, exportColumns[i]) {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// Write adds a line per employee and flushes them, an employee whose user is gone has no
+// username and email
+func (e *Exporter) Write(ctx context.Context, emps []*database.Employee) error {
+	ids := make([]int32, 0, len(emps))
+	for _, emp := range emps {
+		ids = append(ids, int32(emp.UserID))
+	}
+	users, err := e.queries.ListUsersByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int64]*database.User, len(users))
+	for _, u := range users {
+		byID[int64(u.ID)] = u
+	}
+
+	for _, emp := range emps {
+		salary, err := money.AmountFromNumeric(emp.Salary)
+		if err != nil {
+			return err
+		}
+		var username, email, createdAt string
+		if u, ok := byID[emp.UserID]; ok {
+			username, email = u.Username, u.Email
+		}
+		if emp.CreatedAt.Valid {
+			createdAt = emp.CreatedAt.Time.UTC().Format(time.RFC3339)
+		}
+
+		err = e.csv.Write(e.line([]string{
+			strconv.Itoa(int(emp.ID)),
+			strconv.FormatInt(emp.UserID, 10),
+			username,
+			email,
+			emp.JobTitle,
+			emp.Country,
+			salary.Fixed(emp.Currency),
+			emp.Currency,
+			createdAt,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	e.csv.Flush()
+	return e.csv.Error()
+}
@@ -0,0 +1,182 @@
+// Package staff imports employees, together with their user accounts, from CSV and exports
+// them back to it
+package staff
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"server/money"
+	"server/tax"
+	"server/validate"
+)
+
+// csvColumns are the header names an import can use, in any order, other columns are ignored
+var csvColumns = map[string][]string{
+	"username":  {"username", "user"},
+	"email":     {"email", "e-mail"},
+	"password":  {"password"},
+	"job_title": {"job_title", "title"},
+	"country":   {"country"},
+	"salary":    {"salary"},
+	"currency":  {"currency", "salary_currency"},
+}
+
+// requiredColumns have to be in the header, "password" may be left out
+var requiredColumns = []string{"username", "email", "job_title", "country", "salary", "currency"}
+
+// Row is one employee to import, "Line" is where it is in the file
+type Row struct {
+	Line     int
+	Username string
+	Email    string
+	Password string // generated when empty
+	JobTitle string
+	Country  string
+	Salary   money.Amount
+	Currency string
+}
+
+// RowError is why the line "Line" can't be imported
+type RowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"error"`
+}
+
+func (e RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseCSV reads the employees of a CSV with a "username,email,job_title,country,salary,currency"
+// header and an optional "password" column. Every line is checked, an invalid value is a RowError
+// of its line and the lines after it are still read. The error is for a file that can't be read
+// at all: no header, a missing column or broken CSV.
+func ParseCSV(r io.Reader) ([]Row, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := make(map[string]int, len(csvColumns))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, names := range csvColumns {
+			if slices.Contains(names, name) {
+				index[column] = i
+			}
+		}
+	}
+	for _, column := range requiredColumns {
+		if _, ok := index[column]; !ok {
+			return nil, nil, fmt.Errorf("line 1: missing %q column", column)
+		}
+	}
+
+	var rows []Row
+	var rowErrors []RowError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Message: fmt.Sprintf("%d fields, the header has %d", len(record), len(header))})
+			continue
+		}
+
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := Row{
+			Line:     line,
+			Username: field("username"),
+			Email:    field("email"),
+			JobTitle: field("job_title"),
+			Country:  tax.NormalizeCountry(field("country")),
+			Currency: money.NormalizeCurrency(field("currency")),
+		}
+		// Spaces around a password are part of it
+		if i, ok := index["password"]; ok {
+			row.Password = record[i]
+		}
+		errs := row.validate()
+
+		salary, err := money.ParseAmount(field("salary"))
+		switch {
+		case err != nil:
+			errs = append(errs, RowError{Line: line, Column: "salary", Message: "not a number"})
+		case salary.Sign() < 0:
+			errs = append(errs, RowError{Line: line, Column: "salary", Message: "must not be negative"})
+		case money.IsCurrency(row.Currency):
+			row.Salary = salary.Round(row.Currency)
+		}
+
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && len(rowErrors) == 0 {
+		return nil, nil, errors.New("no employees in the file")
+	}
+	return rows, rowErrors, nil
+}
+
+// validate checks the text fields against the columns they go to
+func (r Row) validate() []RowError {
+	var errs []RowError
+	check := func(column, value string, maxLength int) {
+		switch {
+		case value == "":
+			errs = append(errs, RowError{Line: r.Line, Column: column, Message: "required"})
+		case utf8.RuneCountInString(value) > maxLength:
+			errs = append(errs, RowError{Line: r.Line, Column: column, Message: fmt.Sprintf("longer than %d characters", maxLength)})
+		}
+	}
+	check("username", r.Username, 255)
+	check("email", r.Email, 255)
+	check("job_title", r.JobTitle, 100)
+	check("country", r.Country, 2)
+
+	if r.Email != "" && !validate.IsEmail(r.Email) {
+		errs = append(errs, RowError{Line: r.Line, Column: "email", Message: "not an email address"})
+	}
+	if r.Country != "" && !validate.IsCountry(r.Country) {
+		errs = append(errs, RowError{Line: r.Line, Column: "country", Message: "must be an ISO 3166-1 alpha-2 code (e.g. IN)"})
+	}
+	// A generated password is strong, one in the file has to be
+	if r.Password != "" {
+		if err := validate.Password(r.Password); err != nil {
+			errs = append(errs, RowError{Line: r.Line, Column: "password", Message: err.Error()})
+		}
+	}
+	if !money.IsCurrency(r.Currency) {
+		errs = append(errs, RowError{Line: r.Line, Column: "currency", Message: "must be an ISO 4217 code (e.g. USD)"})
+	}
+	return errs
+}
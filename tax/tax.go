@@ -0,0 +1,239 @@
+// Package tax computes the deductions on a yearly gross salary from the rules in "tax_regimes".
+//
+// Contributions (pension, social security, ...) are a rate on the salary up to a cap,
+// income tax is progressive over the brackets, on the salary less the standard allowance
+// and the deductible contributions.
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"server/money"
+	"server/sql/database"
+	"server/validate"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoRegime means there are no rules for the country in or before the tax year
+var ErrNoRegime = errors.New("no tax regime for this country and year")
+
+// Bracket taxes the income between From and UpTo at Rate percent, UpTo is nil on the top bracket
+type Bracket struct {
+	From money.Amount  `json:"from"`
+	UpTo *money.Amount `json:"up_to"`
+	Rate money.Rate    `json:"rate"`
+}
+
+// Contribution is a Rate percent of the salary, only counting the salary up to Cap (nil for no cap).
+// Deductible contributions are taken off the income before income tax.
+type Contribution struct {
+	Name       string        `json:"name"`
+	Rate       money.Rate    `json:"rate"`
+	Cap        *money.Amount `json:"cap"`
+	Deductible bool          `json:"deductible"`
+}
+
+// Regime is the rule set of a country for a tax year, its amounts are in Currency
+type Regime struct {
+	ID                int32          `json:"id,omitempty"`
+	Country           string         `json:"country"`
+	TaxYear           int32          `json:"tax_year"`
+	Currency          string         `json:"currency"`
+	StandardAllowance money.Amount   `json:"standard_allowance"`
+	Brackets          []Bracket      `json:"brackets"`
+	Contributions     []Contribution `json:"contributions"`
+}
+
+// NormalizeCountry is how countries are keyed, an ISO 3166-1 alpha-2 code: " in" and "IN" are the same regime
+func NormalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
+
+// maxRate is the highest rate, brackets and contributions are percentages
+var maxRate = money.NewRate(100)
+
+// Validate checks the brackets cover every income exactly once and the rates are percentages
+func (r *Regime) Validate() error {
+	if r.Country == "" {
+		return errors.New("country is required")
+	}
+	if !validate.IsCountry(r.Country) {
+		return errors.New("country must be an ISO 3166-1 alpha-2 code")
+	}
+	if r.TaxYear < 1900 || r.TaxYear > 9999 {
+		return errors.New("tax_year is out of range")
+	}
+	if !money.IsCurrency(r.Currency) {
+		return errors.New("currency must be an ISO 4217 code")
+	}
+	if r.StandardAllowance.Sign() < 0 {
+		return errors.New("standard_allowance can't be negative")
+	}
+
+	if len(r.Brackets) == 0 {
+		return errors.New("at least one bracket is required")
+	}
+	for i, b := range r.Brackets {
+		if b.Rate.Sign() < 0 || b.Rate.Cmp(maxRate) > 0 {
+			return fmt.Errorf("bracket %d: rate must be between 0 and 100", i)
+		}
+		if i == 0 && !b.From.IsZero() {
+			return errors.New("the first bracket must start at 0")
+		}
+		if i > 0 && (r.Brackets[i-1].UpTo == nil || r.Brackets[i-1].UpTo.Cmp(b.From) != 0) {
+			return fmt.Errorf("bracket %d must start where bracket %d ends", i, i-1)
+		}
+		last := i == len(r.Brackets)-1
+		if last && b.UpTo != nil {
+			return errors.New("the last bracket must be open ended (\"up_to\": null)")
+		}
+		if !last && (b.UpTo == nil || b.UpTo.Cmp(b.From) <= 0) {
+			return fmt.Errorf("bracket %d: up_to must be above from", i)
+		}
+	}
+
+	names := make(map[string]bool, len(r.Contributions))
+	for i, c := range r.Contributions {
+		if c.Name == "" || names[c.Name] {
+			return fmt.Errorf("contribution %d: name must be set and unique", i)
+		}
+		names[c.Name] = true
+		if c.Rate.Sign() < 0 || c.Rate.Cmp(maxRate) > 0 {
+			return fmt.Errorf("contribution %q: rate must be between 0 and 100", c.Name)
+		}
+		if c.Cap != nil && c.Cap.Sign() <= 0 {
+			return fmt.Errorf("contribution %q: cap must be positive", c.Name)
+		}
+	}
+	return nil
+}
+
+// LineItem is a single deduction, "Base" is the amount "Rate" percent was taken of
+type LineItem struct {
+	Kind   string       `json:"kind"` // "income_tax" or "contribution"
+	Name   string       `json:"name"`
+	Base   money.Amount `json:"base"`
+	Rate   money.Rate   `json:"rate"`
+	Amount money.Amount `json:"amount"`
+}
+
+// Breakdown is the gross to net calculation of a yearly salary, in the currency of the regime
+type Breakdown struct {
+	Country           string       `json:"country"`
+	TaxYear           int32        `json:"tax_year"`
+	Currency          string       `json:"currency"`
+	GrossSalary       money.Amount `json:"gross_salary"`
+	StandardAllowance money.Amount `json:"standard_allowance"`
+	TaxableIncome     money.Amount `json:"taxable_income"`
+	Deductions        []LineItem   `json:"deductions"`
+	IncomeTax         money.Amount `json:"income_tax"`
+	Contributions     money.Amount `json:"contributions"`
+	TotalDeductions   money.Amount `json:"total_deductions"`
+	NetSalary         money.Amount `json:"net_salary"`
+
+	// Set when the salary is paid in another currency, 1 SalaryCurrency is worth ExchangeRate Currency
+	SalaryCurrency string     `json:"salary_currency,omitempty"`
+	ExchangeRate   money.Rate `json:"exchange_rate,omitzero"`
+}
+
+// Calculate applies the regime to a yearly gross salary in its currency. Every deduction
+// is rounded to the minor unit of the currency, the totals are the sums of the rounded
+// deductions so the lines always add up to them.
+func (r *Regime) Calculate(gross money.Amount) Breakdown {
+	gross = gross.Round(r.Currency)
+
+	b := Breakdown{
+		Country:           r.Country,
+		TaxYear:           r.TaxYear,
+		Currency:          r.Currency,
+		GrossSalary:       gross,
+		StandardAllowance: r.StandardAllowance,
+		Deductions:        []LineItem{},
+	}
+
+	var deductible money.Amount
+	for _, c := range r.Contributions {
+		base := gross
+		if c.Cap != nil {
+			base = money.Min(base, *c.Cap)
+		}
+		amount := base.Percent(c.Rate).Round(r.Currency)
+		if c.Deductible {
+			deductible = deductible.Add(amount)
+		}
+		b.Contributions = b.Contributions.Add(amount)
+		b.Deductions = append(b.Deductions, LineItem{Kind: "contribution", Name: c.Name, Base: base, Rate: c.Rate, Amount: amount})
+	}
+
+	b.TaxableIncome = money.Max(money.Amount{}, gross.Sub(r.StandardAllowance).Sub(deductible))
+	for _, bracket := range r.Brackets {
+		if b.TaxableIncome.Cmp(bracket.From) <= 0 {
+			break
+		}
+		top := b.TaxableIncome
+		if bracket.UpTo != nil {
+			top = money.Min(top, *bracket.UpTo)
+		}
+		base := top.Sub(bracket.From)
+		amount := base.Percent(bracket.Rate).Round(r.Currency)
+		b.IncomeTax = b.IncomeTax.Add(amount)
+		b.Deductions = append(b.Deductions, LineItem{Kind: "income_tax", Name: bracketName(bracket), Base: base, Rate: bracket.Rate, Amount: amount})
+	}
+
+	b.TotalDeductions = b.IncomeTax.Add(b.Contributions)
+	b.NetSalary = money.Max(money.Amount{}, gross.Sub(b.TotalDeductions))
+	return b
+}
+
+func bracketName(b Bracket) string {
+	if b.UpTo == nil {
+		return "over " + b.From.String()
+	}
+	return b.From.String() + " - " + b.UpTo.String()
+}
+
+// Load fetches the regime of the country in force in the tax year
+func Load(ctx context.Context, queries database.Querier, country string, taxYear int32) (*Regime, error) {
+	row, err := queries.GetTaxRegimeForYear(ctx, database.GetTaxRegimeForYearParams{
+		Country: NormalizeCountry(country),
+		TaxYear: taxYear,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoRegime
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FromDB(row)
+}
+
+// FromDB decodes a "tax_regimes" row
+func FromDB(row *database.TaxRegime) (*Regime, error) {
+	allowance, err := money.AmountFromNumeric(row.StandardAllowance)
+	if err != nil {
+		return nil, fmt.Errorf("tax regime %d: standard_allowance: %w", row.ID, err)
+	}
+
+	r := &Regime{
+		ID:                row.ID,
+		Country:           row.Country,
+		TaxYear:           row.TaxYear,
+		Currency:          row.Currency,
+		StandardAllowance: allowance,
+	}
+	if err := json.Unmarshal(row.Brackets, &r.Brackets); err != nil {
+		return nil, fmt.Errorf("tax regime %d: brackets: %w", row.ID, err)
+	}
+	if err := json.Unmarshal(row.Contributions, &r.Contributions); err != nil {
+		return nil, fmt.Errorf("tax regime %d: contributions: %w", row.ID, err)
+	}
+	if r.Contributions == nil {
+		r.Contributions = []Contribution{}
+	}
+	return r, nil
+}
@@ -0,0 +1,284 @@
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"server/money"
+	"server/sql/database"
+	"server/sql/memdb"
+)
+
+// usRegime is the US regime seeded for 2025
+const usRegime = `{
+	"country": "US", "tax_year": 2025, "currency": "USD", "standard_allowance": 15000,
+	"brackets": [
+		{"from": 0, "up_to": 11925, "rate": 10}, {"from": 11925, "up_to": 48475, "rate": 12},
+		{"from": 48475, "up_to": 103350, "rate": 22}, {"from": 103350, "up_to": 197300, "rate": 24},
+		{"from": 197300, "up_to": 250525, "rate": 32}, {"from": 250525, "up_to": 626350, "rate": 35},
+		{"from": 626350, "up_to": null, "rate": 37}
+	],
+	"contributions": [
+		{"name": "social_security", "rate": 6.2, "cap": 176100, "deductible": false},
+		{"name": "medicare", "rate": 1.45, "cap": null, "deductible": false}
+	]
+}`
+
+// deRegime has a deductible contribution, taken off the income before the brackets
+const deRegime = `{
+	"country": "DE", "tax_year": 2025, "currency": "EUR", "standard_allowance": 1000,
+	"brackets": [{"from": 0, "up_to": 10000, "rate": 0}, {"from": 10000, "up_to": null, "rate": 20}],
+	"contributions": [{"name": "pension", "rate": 9.3, "cap": 50000, "deductible": true}]
+}`
+
+func regime(t *testing.T, doc string) *Regime {
+	t.Helper()
+	var r Regime
+	if err := json.Unmarshal([]byte(doc), &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func amount(t *testing.T, s string) money.Amount {
+	t.Helper()
+	a, err := money.ParseAmount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name          string
+		regime        string
+		gross         string
+		taxable       string
+		incomeTax     string
+		contributions string
+		net           string
+		brackets      int // income tax lines
+	}{
+		{
+			// 11925 × 10% + 36550 × 12% + 36525 × 22% = 1192.50 + 4386 + 8035.50
+			// 100000 × 6.2% + 100000 × 1.45% = 6200 + 1450
+			name: "three brackets", regime: usRegime, gross: "100000",
+			taxable: "85000", incomeTax: "13614", contributions: "7650", net: "78736", brackets: 3,
+		},
+		{
+			// 1192.50 + 4386 + 54875 × 22% + 81650 × 24% = 1192.50 + 4386 + 12072.50 + 19596
+			// social security stops at the cap: 176100 × 6.2% = 10918.20, medicare has none: 2900
+			name: "over the social security cap", regime: usRegime, gross: "200000",
+			taxable: "185000", incomeTax: "37247", contributions: "13818.2", net: "148934.8", brackets: 4,
+		},
+		{
+			// 1192.50 + 4386 + 12072.50 + 57750 × 24%, 10918.20 + 176100 × 1.45% = 10918.20 + 2553.45
+			name: "at the social security cap", regime: usRegime, gross: "176100",
+			taxable: "161100", incomeTax: "31511", contributions: "13471.65", net: "131117.35", brackets: 4,
+		},
+		{
+			// The taxable income ends the first bracket, the second taxes nothing and has no line
+			// 26925 × 6.2% = 1669.35, 26925 × 1.45% = 390.4125 → 390.41
+			name: "at a bracket boundary", regime: usRegime, gross: "26925",
+			taxable: "11925", incomeTax: "1192.5", contributions: "2059.76", net: "23672.74", brackets: 1,
+		},
+		{
+			// 1192.50 + 1 × 12% = 1192.62
+			// 26926 × 6.2% = 1669.412 → 1669.41, 26926 × 1.45% = 390.427 → 390.43
+			name: "a unit over a bracket boundary", regime: usRegime, gross: "26926",
+			taxable: "11926", incomeTax: "1192.62", contributions: "2059.84", net: "23673.54", brackets: 2,
+		},
+		{
+			// The allowance covers it all, the taxable income never goes negative
+			name: "under the allowance", regime: usRegime, gross: "10000",
+			taxable: "0", incomeTax: "0", contributions: "765", net: "9235", brackets: 0,
+		},
+		{
+			name: "no salary", regime: usRegime, gross: "0",
+			taxable: "0", incomeTax: "0", contributions: "0", net: "0", brackets: 0,
+		},
+		{
+			// 60000 - 1000 - 4650 (50000 × 9.3%, capped and deductible) = 54350, 44350 × 20% = 8870
+			name: "deductible contribution over its cap", regime: deRegime, gross: "60000",
+			taxable: "54350", incomeTax: "8870", contributions: "4650", net: "46480", brackets: 2,
+		},
+		{
+			// 12345.67 × 9.3% = 1148.14731 → 1148.15
+			// 12345.67 - 1000 - 1148.15 = 10197.52, 197.52 × 20% = 39.504 → 39.50
+			name: "every line rounded to the cent", regime: deRegime, gross: "12345.67",
+			taxable: "10197.52", incomeTax: "39.5", contributions: "1148.15", net: "11158.02", brackets: 2,
+		},
+		{
+			// The gross is rounded first, 12345.674 is 12345.67
+			name: "gross below the cent", regime: deRegime, gross: "12345.674",
+			taxable: "10197.52", incomeTax: "39.5", contributions: "1148.15", net: "11158.02", brackets: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := regime(t, tt.regime).Calculate(amount(t, tt.gross))
+
+			for _, check := range []struct {
+				field     string
+				got, want money.Amount
+			}{
+				{"taxable_income", b.TaxableIncome, amount(t, tt.taxable)},
+				{"income_tax", b.IncomeTax, amount(t, tt.incomeTax)},
+				{"contributions", b.Contributions, amount(t, tt.contributions)},
+				{"net_salary", b.NetSalary, amount(t, tt.net)},
+			} {
+				if check.got.Cmp(check.want) != 0 {
+					t.Errorf("%s = %s, want %s", check.field, check.got, check.want)
+				}
+			}
+
+			// The lines add up to the totals
+			var incomeTax, contributions money.Amount
+			brackets := 0
+			for _, line := range b.Deductions {
+				switch line.Kind {
+				case "income_tax":
+					incomeTax = incomeTax.Add(line.Amount)
+					brackets++
+				case "contribution":
+					contributions = contributions.Add(line.Amount)
+				}
+			}
+			if incomeTax.Cmp(b.IncomeTax) != 0 || contributions.Cmp(b.Contributions) != 0 {
+				t.Errorf("lines add up to %s and %s, totals are %s and %s", incomeTax, contributions, b.IncomeTax, b.Contributions)
+			}
+			if brackets != tt.brackets {
+				t.Errorf("%d income tax lines, want %d", brackets, tt.brackets)
+			}
+			if total := b.IncomeTax.Add(b.Contributions); b.TotalDeductions.Cmp(total) != 0 {
+				t.Errorf("total_deductions = %s, want %s", b.TotalDeductions, total)
+			}
+		})
+	}
+}
+
+func TestCalculateLines(t *testing.T) {
+	b := regime(t, usRegime).Calculate(amount(t, "200000"))
+
+	want := []struct {
+		name   string
+		base   string
+		amount string
+	}{
+		{"social_security", "176100", "10918.2"},
+		{"medicare", "200000", "2900"},
+		{"0 - 11925", "11925", "1192.5"},
+		{"11925 - 48475", "36550", "4386"},
+		{"48475 - 103350", "54875", "12072.5"},
+		{"103350 - 197300", "81650", "19596"},
+	}
+	if len(b.Deductions) != len(want) {
+		t.Fatalf("%d deductions, want %d: %+v", len(b.Deductions), len(want), b.Deductions)
+	}
+	for i, w := range want {
+		line := b.Deductions[i]
+		if line.Name != w.name || line.Base.Cmp(amount(t, w.base)) != 0 || line.Amount.Cmp(amount(t, w.amount)) != 0 {
+			t.Errorf("line %d is %s on %s = %s, want %s on %s = %s", i, line.Name, line.Base, line.Amount, w.name, w.base, w.amount)
+		}
+	}
+
+	top := regime(t, usRegime).Calculate(amount(t, "1000000"))
+	if last := top.Deductions[len(top.Deductions)-1]; last.Name != "over 626350" {
+		t.Errorf("top bracket is named %q", last.Name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"gap between brackets", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 0, "up_to": 100, "rate": 10}, {"from": 200, "up_to": null, "rate": 20}]}`},
+		{"first bracket above 0", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 10, "up_to": null, "rate": 10}]}`},
+		{"closed top bracket", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 0, "up_to": 100, "rate": 10}]}`},
+		{"rate over 100", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 0, "up_to": null, "rate": 101}]}`},
+		{"zero cap", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 0, "up_to": null, "rate": 10}],
+			"contributions": [{"name": "pension", "rate": 5, "cap": 0}]}`},
+		{"duplicate contribution", `{"country": "US", "tax_year": 2025, "currency": "USD",
+			"brackets": [{"from": 0, "up_to": null, "rate": 10}],
+			"contributions": [{"name": "pension", "rate": 5}, {"name": "pension", "rate": 1}]}`},
+		{"unknown currency", `{"country": "US", "tax_year": 2025, "currency": "ABC",
+			"brackets": [{"from": 0, "up_to": null, "rate": 10}]}`},
+	}
+	for _, tt := range tests {
+		var r Regime
+		if err := json.Unmarshal([]byte(tt.doc), &r); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Validate(); err == nil {
+			t.Errorf("%s: valid", tt.name)
+		}
+	}
+}
+
+// A tax year without a regime of its own uses the latest earlier one
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	store := memdb.New() // US is seeded for 2025
+
+	brackets, _ := json.Marshal([]Bracket{{Rate: money.NewRate(20)}})
+	_, err := store.CreateTaxRegime(ctx, database.CreateTaxRegimeParams{
+		Country:           "US",
+		TaxYear:           2027,
+		StandardAllowance: money.NewAmount(16000).Numeric(),
+		Brackets:          brackets,
+		Contributions:     []byte("[]"),
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		country string
+		year    int32
+		want    int32 // the tax year of the regime, 0 for none
+	}{
+		{"US", 2024, 0},
+		{"US", 2025, 2025},
+		{"US", 2026, 2025},
+		{" us", 2027, 2027},
+		{"US", 2040, 2027},
+		{"FR", 2025, 0},
+	}
+	for _, tt := range tests {
+		r, err := Load(ctx, store, tt.country, tt.year)
+		if tt.want == 0 {
+			if !errors.Is(err, ErrNoRegime) {
+				t.Errorf("Load(%q, %d) = %v, want ErrNoRegime", tt.country, tt.year, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Load(%q, %d): %v", tt.country, tt.year, err)
+			continue
+		}
+		if r.TaxYear != tt.want {
+			t.Errorf("Load(%q, %d) is the %d regime, want %d", tt.country, tt.year, r.TaxYear, tt.want)
+		}
+	}
+
+	r, err := Load(ctx, store, "US", 2030)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Calculate(amount(t, "26000")).IncomeTax; got.Cmp(amount(t, "2000")) != 0 {
+		t.Errorf("income tax under the 2027 regime = %s, want (26000 - 16000) × 20%% = 2000", got)
+	}
+}
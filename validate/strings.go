@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A password is at least MinPasswordLength characters, bcrypt only reads MaxPasswordBytes
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72
+)
+
+// IsEmail tells whether "email" is a bare address, without a display name or angle brackets
+func IsEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// Password checks a new password is strong enough: MinPasswordLength characters, at most
+// MaxPasswordBytes, and three kinds of characters out of lower case and upper case letters,
+// digits and symbols
+func Password(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("must be at least %d characters long", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("must be at most %d bytes long", MaxPasswordBytes)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	kinds := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			kinds++
+		}
+	}
+	if kinds < 3 {
+		return errors.New("must mix three of lower case, upper case, digits and symbols")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"ana@example.com", true},
+		{"ana.maria+payroll@mail.example.co.in", true},
+		{"ana", false},
+		{"ana@", false},
+		{"@example.com", false},
+		{"Ana <ana@example.com>", false},
+		{"<ana@example.com>", false},
+		{" ana@example.com", false},
+		{"ana@example.com, bo@example.com", false},
+		{strings.Repeat("a", 64) + "@" + strings.Repeat("b", 185) + ".com", true},
+		{strings.Repeat("a", 64) + "@" + strings.Repeat("b", 186) + ".com", false},
+	}
+	for _, tt := range tests {
+		if got := IsEmail(tt.in); got != tt.want {
+			t.Errorf("IsEmail(%.30q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{in: "Corr3ct-Horse"},
+		{in: "correct-horse-1"},
+		{in: "CORRECT HORSE 1"},
+		{in: "Correct-Horse"},
+		{in: "Ünïcödé1"},
+		{in: "Sh0rt!", wantErr: "at least 8 characters"},
+		{in: "Pässwö1", wantErr: "at least 8 characters"},
+		{in: "correcthorse", wantErr: "three of"},
+		{in: "correcthorse1", wantErr: "three of"},
+		{in: "CORRECT-HORSE", wantErr: "three of"},
+		{in: "Aa1" + strings.Repeat("x", 69), wantErr: ""},
+		{in: "Aa1" + strings.Repeat("x", 70), wantErr: "at most 72 bytes"},
+		{in: "Aa1" + strings.Repeat("é", 35), wantErr: "at most 72 bytes"},
+	}
+	for _, tt := range tests {
+		err := Password(tt.in)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Password(%.20q): %v", tt.in, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Password(%.20q) = %v, want %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package validate checks a struct against the "validate" tags of its fields and reports every
+// field at fault at once, named as in its JSON:
+//
+//	type EmpBody struct {
+//		JobTitle string       `json:"job_title" validate:"required,max=100"`
+//		Country  string       `json:"country" validate:"required,country"`
+//		Salary   money.Amount `json:"salary" validate:"min=0"`
+//	}
+//
+// The rules, separated by commas:
+//
+//	required   more than spaces in a string, a pointer, slice or map that isn't nil, a number that isn't 0
+//	min=N      at least N characters in a string, N items in a slice, a number or an amount of at least N
+//	max=N      at most the same
+//	email      a bare email address, "ana@example.com"
+//	country    an ISO 3166-1 alpha-2 code, "IN"
+//	currency   an ISO 4217 code, "EUR"
+//	date       a "YYYY-MM-DD" day
+//	oneof=a b  one of the values listed
+//	password   strong enough, see Password
+//
+// Besides required, the rules skip an empty string and a nil pointer: an optional field can be
+// left out. The fields of nested structs and of the structs in a slice are checked too, named
+// like "brackets[1].rate".
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"server/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// FieldError is a field breaking a rule
+type FieldError struct {
+	Field   string // its JSON name, "brackets[1].rate"
+	Rule    string // "required", "max", ...
+	Message string
+}
+
+// Errors are every field at fault, in the order of the struct
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Struct checks the fields of the struct "v" points to, or is
+func Struct(v any) Errors {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	checkStruct(&errs, value, "")
+	return errs
+}
+
+func checkStruct(errs *Errors, value reflect.Value, prefix string) {
+	t := value.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldValue := value.Field(i)
+
+		// The fields of an embedded struct are the fields of this one
+		if field.Anonymous && name == "" {
+			for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				checkStruct(errs, fieldValue, prefix)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		path := prefix + name
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+				if message := check(fieldValue, rule, param); message != "" {
+					*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: message})
+					// The other rules of a missing field would only repeat it
+					if rule == "required" {
+						break
+					}
+				}
+			}
+		}
+		dive(errs, fieldValue, path)
+	}
+}
+
+// dive checks the structs within a field
+func dive(errs *Errors, value reflect.Value, path string) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if isNumber(value) || value.Type() == reflect.TypeFor[time.Time]() {
+			return
+		}
+		checkStruct(errs, value, path+".")
+	case reflect.Slice, reflect.Array:
+		for i := range value.Len() {
+			dive(errs, value.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// rules are the rules a tag can use
+var rules = map[string]bool{
+	"required": true, "min": true, "max": true, "email": true, "country": true,
+	"currency": true, "date": true, "oneof": true, "password": true,
+}
+
+// check is what's wrong with "value" by "rule", "" when nothing
+func check(value reflect.Value, rule, param string) string {
+	// A misspelt tag fails on the first request, not on the first one that fills the field
+	if !rules[rule] {
+		panic("validate: unknown rule " + rule)
+	}
+	if rule == "min" || rule == "max" {
+		if _, err := decimal.NewFromString(param); err != nil {
+			panic("validate: bad " + rule + " bound " + param)
+		}
+	}
+
+	if rule == "required" {
+		if isEmpty(value) {
+			return "required"
+		}
+		return ""
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.String && value.String() == "" {
+		return ""
+	}
+
+	switch rule {
+	case "min", "max":
+		return checkBound(value, rule, param)
+	case "email":
+		if !IsEmail(value.String()) {
+			return "not an email address"
+		}
+	case "country":
+		if !IsCountry(value.String()) {
+			return "must be an ISO 3166-1 alpha-2 code (e.g. IN)"
+		}
+	case "currency":
+		if !money.IsCurrency(value.String()) {
+			return "must be an ISO 4217 code (e.g. USD)"
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value.String()); err != nil {
+			return "must be YYYY-MM-DD"
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		for _, option := range options {
+			if value.String() == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "password":
+		if err := Password(value.String()); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// checkBound compares the length of a string or slice, or a number, with the bound "param"
+func checkBound(value reflect.Value, rule, param string) string {
+	atMost := rule == "max"
+	word := map[bool]string{false: "at least", true: "at most"}[atMost]
+
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		bound, err := strconv.Atoi(param)
+		if err != nil {
+			panic("validate: bad " + rule + " length " + param)
+		}
+		length, unit := value.Len(), "items"
+		if value.Kind() == reflect.String {
+			length, unit = utf8.RuneCountInString(value.String()), "characters"
+		}
+		if (atMost && length > bound) || (!atMost && length < bound) {
+			return fmt.Sprintf("must be %s %d %s long", word, bound, unit)
+		}
+		return ""
+	}
+
+	number, ok := numberOf(value)
+	if !ok {
+		panic("validate: " + rule + " on a " + value.Type().String())
+	}
+	bound, err := decimal.NewFromString(param)
+	if err != nil {
+		panic("validate: bad " + rule + " bound " + param)
+	}
+	if (atMost && number.GreaterThan(bound)) || (!atMost && number.LessThan(bound)) {
+		return fmt.Sprintf("must be %s %s", word, bound)
+	}
+	return ""
+}
+
+// number is an amount, a rate or anything else written as a decimal
+type number interface {
+	Sign() int
+	String() string
+}
+
+func isNumber(value reflect.Value) bool {
+	_, ok := value.Interface().(number)
+	return ok
+}
+
+func numberOf(value reflect.Value) (decimal.Decimal, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decimal.NewFromInt(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return decimal.NewFromUint64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return decimal.NewFromFloat(value.Float()), true
+	}
+	if n, ok := value.Interface().(number); ok {
+		d, err := decimal.NewFromString(n.String())
+		return d, err == nil
+	}
+	return decimal.Decimal{}, false
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return value.IsNil()
+	}
+	if n, ok := value.Interface().(number); ok {
+		return n.Sign() == 0
+	}
+	return value.IsZero()
+}
@@ -0,0 +1,257 @@
+package validate
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"server/money"
+)
+
+type address struct {
+	Country string `json:"country" validate:"required,country"`
+}
+
+type bracket struct {
+	UpTo *money.Amount `json:"up_to" validate:"min=0"`
+	Rate money.Rate    `json:"rate" validate:"min=0,max=100"`
+}
+
+type Audited struct {
+	Note string `json:"note" validate:"max=10"`
+}
+
+type body struct {
+	Name     string        `json:"name" validate:"required,max=5"`
+	Email    string        `json:"email" validate:"required,email"`
+	Salary   money.Amount  `json:"salary" validate:"required,min=0.01,max=1000"`
+	Bonus    *money.Amount `json:"bonus" validate:"min=0"`
+	Currency string        `json:"currency" validate:"currency"`
+	Start    string        `json:"start" validate:"date"`
+	Period   string        `json:"period" validate:"oneof=monthly biweekly"`
+	Password string        `json:"password" validate:"password"`
+	Tags     []string      `json:"tags" validate:"max=2"`
+	Count    int           `json:"count" validate:"min=1"`
+	Address  address       `json:"address"`
+	Manager  *address      `json:"manager"`
+	Brackets []bracket     `json:"brackets"`
+	Secret   string        `json:"-" validate:"required"`
+	Untagged string
+	Audited
+}
+
+func amount(t *testing.T, s string) money.Amount {
+	t.Helper()
+	a, err := money.ParseAmount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func rate(t *testing.T, s string) money.Rate {
+	t.Helper()
+	r, err := money.ParseRate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// fields lists the errors as "field:rule"
+func fields(errs Errors) []string {
+	list := make([]string, len(errs))
+	for i, err := range errs {
+		list[i] = err.Field + ":" + err.Rule
+	}
+	return list
+}
+
+func TestStruct(t *testing.T) {
+	valid := func() body {
+		return body{
+			Name:    "Ana",
+			Email:   "ana@example.com",
+			Salary:  amount(t, "1000"),
+			Count:   1,
+			Address: address{Country: "IN"},
+		}
+	}
+
+	tests := []struct {
+		name string
+		edit func(*body)
+		want []string
+	}{
+		{name: "valid", edit: func(b *body) {}},
+		{
+			name: "every optional field set",
+			edit: func(b *body) {
+				bonus := amount(t, "0")
+				b.Bonus = &bonus
+				b.Currency, b.Start, b.Period, b.Password = "EUR", "2025-02-28", "biweekly", "Corr3ct-Horse"
+				b.Tags = []string{"a", "b"}
+				b.Manager = &address{Country: "US"}
+				b.Brackets = []bracket{{Rate: rate(t, "100")}}
+				b.Note = "0123456789"
+			},
+		},
+		{
+			name: "required",
+			edit: func(b *body) {
+				b.Name, b.Email, b.Salary, b.Address.Country = "   ", "", money.Amount{}, ""
+			},
+			// Only "required" for a missing field, not its other rules too
+			want: []string{"name:required", "email:required", "salary:required", "address.country:required"},
+		},
+		{
+			name: "string length in characters",
+			edit: func(b *body) { b.Name = "Zoë Ó" },
+		},
+		{
+			name: "string too long",
+			edit: func(b *body) { b.Name = "Ana M." },
+			want: []string{"name:max"},
+		},
+		{
+			name: "amount bounds",
+			edit: func(b *body) { b.Salary = amount(t, "1000.001") },
+			want: []string{"salary:max"},
+		},
+		{
+			name: "amount below min",
+			edit: func(b *body) { b.Salary = amount(t, "0.001") },
+			want: []string{"salary:min"},
+		},
+		{
+			name: "negative amount behind a pointer",
+			edit: func(b *body) {
+				bonus := amount(t, "-0.01")
+				b.Bonus = &bonus
+			},
+			want: []string{"bonus:min"},
+		},
+		{
+			name: "int bound",
+			edit: func(b *body) { b.Count = 0 },
+			want: []string{"count:min"},
+		},
+		{
+			name: "slice length",
+			edit: func(b *body) { b.Tags = []string{"a", "b", "c"} },
+			want: []string{"tags:max"},
+		},
+		{
+			name: "email",
+			edit: func(b *body) { b.Email = "Ana <ana@example.com>" },
+			want: []string{"email:email"},
+		},
+		{
+			name: "country",
+			edit: func(b *body) { b.Address.Country = "in" },
+			want: []string{"address.country:country"},
+		},
+		{
+			name: "currency, date and oneof",
+			edit: func(b *body) { b.Currency, b.Start, b.Period = "XYZ", "2025-02-30", "weekly" },
+			want: []string{"currency:currency", "start:date", "period:oneof"},
+		},
+		{
+			name: "password",
+			edit: func(b *body) { b.Password = "password1" },
+			want: []string{"password:password"},
+		},
+		{
+			name: "nested pointer",
+			edit: func(b *body) { b.Manager = &address{Country: "XX"} },
+			want: []string{"manager.country:country"},
+		},
+		{
+			name: "structs in a slice",
+			edit: func(b *body) {
+				upTo := amount(t, "-1")
+				b.Brackets = []bracket{{Rate: rate(t, "10")}, {UpTo: &upTo, Rate: rate(t, "100.5")}}
+			},
+			want: []string{"brackets[1].up_to:min", "brackets[1].rate:max"},
+		},
+		{
+			name: "embedded struct",
+			edit: func(b *body) { b.Note = "01234567890" },
+			want: []string{"note:max"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := valid()
+			tt.edit(&b)
+			if got := fields(Struct(&b)); !slices.Equal(got, tt.want) {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	b := body{Salary: amount(t, "5000"), Count: 1, Address: address{Country: "IN"}, Name: "Ana", Email: "ana"}
+	errs := Struct(b)
+	want := "email: not an email address; salary: must be at most 1000"
+	if errs.Error() != want {
+		t.Errorf("Error() = %q, want %q", errs.Error(), want)
+	}
+
+	if errs := Struct((*body)(nil)); errs != nil {
+		t.Errorf("nil pointer: %v", errs)
+	}
+	if errs := Struct("not a struct"); errs != nil {
+		t.Errorf("string: %v", errs)
+	}
+}
+
+// A misspelt tag panics even while the field is empty, the first test of the body finds it
+func TestBadTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			name: "unknown rule",
+			v: &struct {
+				Country string `json:"country" validate:"contry"`
+			}{},
+			want: "validate: unknown rule contry",
+		},
+		{
+			name: "bad bound",
+			v: &struct {
+				Name string `json:"name" validate:"max=ten"`
+			}{},
+			want: "validate: bad max bound ten",
+		},
+		{
+			name: "length of characters not whole",
+			v: &struct {
+				Name string `json:"name" validate:"max=1.5"`
+			}{Name: "Ana"},
+			want: "validate: bad max length 1.5",
+		},
+		{
+			name: "bound on a bool",
+			v: &struct {
+				Active bool `json:"active" validate:"min=1"`
+			}{},
+			want: "validate: min on a bool",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				got, _ := recover().(string)
+				if !strings.HasPrefix(got, tt.want) {
+					t.Errorf("panic %q, want %q", got, tt.want)
+				}
+			}()
+			Struct(tt.v)
+		})
+	}
+}